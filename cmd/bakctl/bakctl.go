@@ -13,8 +13,11 @@
 //   - run: 执行备份任务
 //   - log: 查看备份日志
 //   - restore: 恢复备份文件
+//   - files: 查看备份版本的文件清单
+//   - verify: 校验备份文件的完整性
 //   - delete: 删除备份任务
 //   - export: 导出任务配置
+//   - keygen: 生成用于公钥加密的密钥对
 //
 // 使用示例：
 //
@@ -32,11 +35,15 @@ import (
 	"gitee.com/MM-Q/bakctl/cmd/subcmd/delete"
 	"gitee.com/MM-Q/bakctl/cmd/subcmd/edit"
 	"gitee.com/MM-Q/bakctl/cmd/subcmd/export"
+	"gitee.com/MM-Q/bakctl/cmd/subcmd/files"
+	"gitee.com/MM-Q/bakctl/cmd/subcmd/keygen"
 	"gitee.com/MM-Q/bakctl/cmd/subcmd/list"
 	"gitee.com/MM-Q/bakctl/cmd/subcmd/log"
 	"gitee.com/MM-Q/bakctl/cmd/subcmd/restore"
 	"gitee.com/MM-Q/bakctl/cmd/subcmd/run"
+	"gitee.com/MM-Q/bakctl/cmd/subcmd/verify"
 	"gitee.com/MM-Q/bakctl/internal/db"
+	"gitee.com/MM-Q/bakctl/internal/lock"
 	"gitee.com/MM-Q/bakctl/internal/types"
 	"gitee.com/MM-Q/colorlib"
 	"gitee.com/MM-Q/qflag"
@@ -82,8 +89,17 @@ func BakctlMain() {
 	// 获取restore命令
 	restoreCmd := restore.InitRestoreCmd()
 
+	// 获取keygen命令
+	keygenCmd := keygen.InitKeygenCmd()
+
+	// 获取files命令
+	filesCmd := files.InitFilesCmd()
+
+	// 获取verify命令
+	verifyCmd := verify.InitVerifyCmd()
+
 	// 注册子命令
-	if err := qflag.AddSubCmd(addCmd, editCmd, listCmd, logCmd, runCmd, deleteCmd, exportCmd, restoreCmd); err != nil {
+	if err := qflag.AddSubCmd(addCmd, editCmd, listCmd, logCmd, runCmd, deleteCmd, exportCmd, restoreCmd, keygenCmd, filesCmd, verifyCmd); err != nil {
 		CL.PrintError(err)
 		os.Exit(1)
 	}
@@ -94,8 +110,14 @@ func BakctlMain() {
 		os.Exit(1)
 	}
 
-	// 初始化数据库配置
+	// 初始化数据库配置（持有任务目录锁，避免多个进程同时升级表结构）
+	catalogLock, err := lock.AcquireCatalog(types.DataDirPath, CL)
+	if err != nil {
+		CL.PrintError(err)
+		os.Exit(1)
+	}
 	db, err := db.InitSQLite(types.DBFilename, types.DataDirPath)
+	_ = catalogLock.Release()
 	if err != nil {
 		CL.PrintError(err)
 		os.Exit(1)
@@ -170,6 +192,27 @@ func BakctlMain() {
 		}
 		return
 
+	case keygenCmd.LongName(), keygenCmd.ShortName(): // keygen 命令
+		if err := keygen.KeygenCmdMain(CL); err != nil {
+			CL.PrintError(err)
+			os.Exit(1)
+		}
+		return
+
+	case filesCmd.LongName(), filesCmd.ShortName(): // files 命令
+		if err := files.FilesCmdMain(db, CL); err != nil {
+			CL.PrintError(err)
+			os.Exit(1)
+		}
+		return
+
+	case verifyCmd.LongName(), verifyCmd.ShortName(): // verify 命令
+		if err := verify.VerifyCmdMain(db, CL); err != nil {
+			CL.PrintError(err)
+			os.Exit(1)
+		}
+		return
+
 	default:
 		CL.PrintErrorf("unknown command: %s\n", cmdName)
 		os.Exit(1)
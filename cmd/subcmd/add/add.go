@@ -18,8 +18,10 @@ package add
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	DB "gitee.com/MM-Q/bakctl/internal/db"
+	"gitee.com/MM-Q/bakctl/internal/lock"
 	"gitee.com/MM-Q/bakctl/internal/types"
 	"gitee.com/MM-Q/colorlib"
 	"github.com/jmoiron/sqlx"
@@ -45,6 +47,13 @@ func AddCmdMain(db *sqlx.DB, cl *colorlib.ColorLib) error {
 		return nil
 	}
 
+	// 获取任务目录锁，避免与其他进程同时修改任务配置
+	catalogLock, err := lock.AcquireCatalog(types.DataDirPath, cl)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = catalogLock.Release() }()
+
 	// 获取配置文件路径
 	configPath := configF.Get()
 
@@ -105,18 +114,64 @@ func addTaskFromConfigFile(db *sqlx.DB, configPath string, cl *colorlib.ColorLib
 		}
 	}
 
+	// 获取分卷大小
+	var volumeSize int64
+	if config.AddTaskConfig.VolumeSize != "" {
+		if err := volumeSizeF.Set(config.AddTaskConfig.VolumeSize); err != nil {
+			return fmt.Errorf("无效的分卷大小: %v", err)
+		}
+		volumeSize = volumeSizeF.Get() // 获取转为字节的分卷大小
+	}
+
+	// 获取读取限速
+	var readLimit int64
+	if config.AddTaskConfig.ReadLimit != "" {
+		if err := readLimitF.Set(config.AddTaskConfig.ReadLimit); err != nil {
+			return fmt.Errorf("无效的读取限速: %v", err)
+		}
+		readLimit = readLimitF.Get() // 获取转为字节的每秒读取限速
+	}
+
 	// 转换为任务配置
+	encrypt := config.AddTaskConfig.Encrypt
 	taskConfig := &types.TaskConfig{
-		Name:         config.AddTaskConfig.Name,         // 任务名称
-		BackupDir:    config.AddTaskConfig.BackupDir,    // 备份目录
-		StorageDir:   config.AddTaskConfig.StorageDir,   // 存储目录
-		Compress:     config.AddTaskConfig.Compress,     // 是否压缩
-		RetainCount:  config.AddTaskConfig.RetainCount,  // 保留数量
-		RetainDays:   config.AddTaskConfig.RetainDays,   // 保留天数
-		IncludeRules: config.AddTaskConfig.IncludeRules, // 包含规则
-		ExcludeRules: config.AddTaskConfig.ExcludeRules, // 排除规则
-		MaxFileSize:  maxFileSize,                       // 最大文件大小
-		MinFileSize:  minFileSize,                       // 最小文件大小
+		Name:          config.AddTaskConfig.Name,          // 任务名称
+		BackupDir:     config.AddTaskConfig.BackupDir,     // 备份目录
+		BackupSources: config.AddTaskConfig.BackupSources, // 其他备份源路径
+		StorageDir:    config.AddTaskConfig.StorageDir,    // 存储目录
+		Compress:      config.AddTaskConfig.Compress,      // 是否压缩
+		Compression:   config.AddTaskConfig.Compression,   // 压缩等级
+		StoreExts:     config.AddTaskConfig.StoreExts,     // 不压缩的文件扩展名
+		Encryption:    encryptionName(encrypt),            // 加密算法
+		KeyFile:       config.AddTaskConfig.KeyFile,       // 密钥文件路径
+		Recipients:    config.AddTaskConfig.Recipients,    // 接收者公钥
+		RetainCount:   config.AddTaskConfig.RetainCount,   // 保留数量
+		RetainDays:    config.AddTaskConfig.RetainDays,    // 保留天数
+		IncludeRules:  config.AddTaskConfig.IncludeRules,  // 包含规则
+		ExcludeRules:  config.AddTaskConfig.ExcludeRules,  // 排除规则
+		IgnoreFiles:   config.AddTaskConfig.IgnoreFiles,   // 是否使用忽略文件
+		NewerThan:     config.AddTaskConfig.NewerThan,     // 修改时间下限
+		OlderThan:     config.AddTaskConfig.OlderThan,     // 修改时间上限
+		FileTypes:     config.AddTaskConfig.FileTypes,     // 文件类型
+		FileOwner:     config.AddTaskConfig.FileOwner,     // 文件属主
+		FileGroup:     config.AddTaskConfig.FileGroup,     // 文件属组
+		ExcludeHidden: config.AddTaskConfig.ExcludeHidden, // 是否排除隐藏文件
+		Symlinks:      config.AddTaskConfig.Symlinks,      // 符号链接处理策略
+		ErrorPolicy:   config.AddTaskConfig.ErrorPolicy,   // 错误处理策略
+		MaxFileSize:   maxFileSize,                        // 最大文件大小
+		MinFileSize:   minFileSize,                        // 最小文件大小
+		BackupMode:    config.AddTaskConfig.BackupMode,    // 备份模式
+		FullEvery:     config.AddTaskConfig.FullEvery,     // 增量备份链的最大长度
+		StorageMode:   config.AddTaskConfig.StorageMode,   // 存储模式
+		Format:        config.AddTaskConfig.Format,        // 归档格式
+		VolumeSize:    volumeSize,                         // 分卷大小
+		HashAlgorithm: config.AddTaskConfig.HashAlgorithm, // 校验码哈希算法
+		PreHook:       config.AddTaskConfig.PreHook,       // 备份前钩子
+		PostHook:      config.AddTaskConfig.PostHook,      // 备份后钩子
+		OnFailureHook: config.AddTaskConfig.OnFailureHook, // 失败钩子
+		HookTimeout:   config.AddTaskConfig.HookTimeout,   // 钩子超时时间
+		Timeout:       config.AddTaskConfig.Timeout,       // 任务超时时间
+		ReadLimit:     readLimit,                          // 读取限速
 	}
 
 	// 将配置文件中的内容保存到数据库中
@@ -140,16 +195,42 @@ func addTaskFromConfigFile(db *sqlx.DB, configPath string, cl *colorlib.ColorLib
 func addTaskFromFlags(db *sqlx.DB, cl *colorlib.ColorLib) error {
 	// 构建任务配置
 	config := &types.TaskConfig{
-		Name:         nameF.Get(),        // 任务名称
-		RetainCount:  retainCountF.Get(), // 保留备份数量
-		RetainDays:   retainDaysF.Get(),  // 保留天数
-		BackupDir:    backupDirF.Get(),   // 备份源目录
-		StorageDir:   storageDirF.Get(),  // 存储目录
-		Compress:     compressF.Get(),    // 是否压缩
-		IncludeRules: includeF.Get(),     // 包含规则
-		ExcludeRules: excludeF.Get(),     // 排除规则
-		MaxFileSize:  maxSizeF.Get(),     // 最大文件大小
-		MinFileSize:  minSizeF.Get(),     // 最小文件大小
+		Name:          nameF.Get(),                         // 任务名称
+		RetainCount:   retainCountF.Get(),                  // 保留备份数量
+		RetainDays:    retainDaysF.Get(),                   // 保留天数
+		BackupSources: backupDirF.Get(),                    // 备份源路径
+		StorageDir:    storageDirF.Get(),                   // 存储目录
+		Compress:      compressF.Get(),                     // 是否压缩
+		Compression:   compressionFromFlags(),              // 压缩等级
+		StoreExts:     storeExtsF.Get(),                    // 不压缩的文件扩展名
+		Encryption:    encryptionName(encryptF.Get()),      // 加密算法
+		KeyFile:       keyFileF.Get(),                      // 密钥文件路径
+		Recipients:    recipientsF.Get(),                   // 接收者公钥
+		IncludeRules:  includeF.Get(),                      // 包含规则
+		ExcludeRules:  excludeF.Get(),                      // 排除规则
+		IgnoreFiles:   ignoreF.Get(),                       // 是否使用忽略文件
+		NewerThan:     newerThanF.Get(),                    // 修改时间下限
+		OlderThan:     olderThanF.Get(),                    // 修改时间上限
+		FileTypes:     fileTypesF.Get(),                    // 文件类型
+		FileOwner:     ownerF.Get(),                        // 文件属主
+		FileGroup:     groupF.Get(),                        // 文件属组
+		ExcludeHidden: hiddenF.Get(),                       // 是否排除隐藏文件
+		Symlinks:      strings.ToLower(symlinksF.Get()),    // 符号链接处理策略
+		ErrorPolicy:   strings.ToLower(errorPolicyF.Get()), // 错误处理策略
+		MaxFileSize:   maxSizeF.Get(),                      // 最大文件大小
+		MinFileSize:   minSizeF.Get(),                      // 最小文件大小
+		BackupMode:    strings.ToLower(modeF.Get()),        // 备份模式
+		FullEvery:     fullEveryF.Get(),                    // 增量备份链的最大长度
+		StorageMode:   strings.ToLower(storageModeF.Get()), // 存储模式
+		Format:        strings.ToLower(formatF.Get()),      // 归档格式
+		VolumeSize:    volumeSizeF.Get(),                   // 分卷大小
+		HashAlgorithm: strings.ToLower(hashF.Get()),        // 校验码哈希算法
+		PreHook:       preHookF.Get(),                      // 备份前钩子
+		PostHook:      postHookF.Get(),                     // 备份后钩子
+		OnFailureHook: onFailureHookF.Get(),                // 失败钩子
+		HookTimeout:   hookTimeoutF.Get(),                  // 钩子超时时间
+		Timeout:       timeoutF.Get(),                      // 任务超时时间
+		ReadLimit:     readLimitF.Get(),                    // 读取限速
 	}
 
 	// 检查必须参数
@@ -172,6 +253,36 @@ func addTaskFromFlags(db *sqlx.DB, cl *colorlib.ColorLib) error {
 	return nil
 }
 
+// compressionFromFlags 根据命令行标志确定压缩等级
+//
+// 只指定 --compress 时使用默认压缩等级，同时指定时以 --compression 为准。
+//
+// 返回值:
+//   - string: 压缩等级
+func compressionFromFlags() string {
+	if compressionF.IsSet() {
+		return strings.ToLower(compressionF.Get())
+	}
+	if compressF.Get() {
+		return types.CompressionDefault
+	}
+	return types.CompressionNone
+}
+
+// encryptionName 根据是否加密确定加密算法
+//
+// 参数:
+//   - encrypt: 是否加密
+//
+// 返回值:
+//   - string: 加密算法（不加密时为空）
+func encryptionName(encrypt bool) string {
+	if encrypt {
+		return types.EncryptionAES256GCM
+	}
+	return ""
+}
+
 // GenerateConfigFile 生成配置文件
 //
 // 返回值:
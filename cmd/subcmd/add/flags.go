@@ -3,7 +3,10 @@
 // 该文件定义了 add 子命令支持的所有命令行参数，包括：
 //   - 基本配置参数：任务名称、备份目录、存储目录
 //   - 压缩和保留策略参数：压缩开关、保留数量、保留天数
-//   - 文件过滤参数：包含规则、排除规则、文件大小限制
+//   - 备份模式参数：全量备份或增量备份
+//   - 分卷参数：备份文件的分卷大小
+//   - 限速参数：每秒读取的最大字节数
+//   - 文件过滤参数：包含规则、排除规则、文件大小限制、文件属性（修改时间、类型、属主、隐藏文件）
 //   - 配置文件参数：从 TOML 文件读取配置
 //
 // 所有参数都提供了详细的帮助信息和默认值，支持短参数和长参数两种形式。
@@ -13,6 +16,7 @@ package add
 import (
 	"flag"
 
+	"gitee.com/MM-Q/bakctl/internal/types"
 	"gitee.com/MM-Q/qflag"
 	"gitee.com/MM-Q/qflag/cmd"
 )
@@ -23,24 +27,68 @@ var (
 	genF    *qflag.BoolFlag   // 生成配置文件
 
 	// 基本任务信息
-	nameF       *qflag.StringFlag // 任务名称
-	backupDirF  *qflag.StringFlag // 备份源目录
-	storageDirF *qflag.StringFlag // 存储目录
+	nameF       *qflag.StringFlag      // 任务名称
+	backupDirF  *qflag.StringSliceFlag // 备份源路径
+	storageDirF *qflag.StringFlag      // 存储目录
 
 	// 保留策略
 	retainCountF *qflag.IntFlag // 保留备份数量
 	retainDaysF  *qflag.IntFlag // 保留天数
 
 	// 压缩选项
-	compressF *qflag.BoolFlag // 是否压缩
+	compressF    *qflag.BoolFlag        // 是否压缩
+	compressionF *qflag.EnumFlag        // 压缩等级 (none/fast/default/best/huffman)
+	storeExtsF   *qflag.StringSliceFlag // 始终不压缩的文件扩展名
+
+	// 备份模式
+	modeF        *qflag.EnumFlag // 备份模式 (full/incremental)
+	fullEveryF   *qflag.IntFlag  // 增量备份链的最大长度
+	storageModeF *qflag.EnumFlag // 存储模式 (archive/repository)
+	formatF      *qflag.EnumFlag // 归档格式 (zip/tar/tar.gz/tar.bz2)
+
+	// 分卷选项
+	volumeSizeF *qflag.SizeFlag // 分卷大小
+
+	// 校验选项
+	hashF *qflag.EnumFlag // 校验码哈希算法 (sha256/sha512/blake2b/xxhash)
+
+	// 加密选项
+	encryptF    *qflag.BoolFlag        // 是否加密
+	keyFileF    *qflag.StringFlag      // 密钥文件路径
+	recipientsF *qflag.StringSliceFlag // 接收者公钥
 
 	// 文件过滤规则
 	includeF *qflag.StringSliceFlag // 包含规则
 	excludeF *qflag.StringSliceFlag // 排除规则
+	ignoreF  *qflag.BoolFlag        // 是否使用忽略文件
 
 	// 文件大小限制
 	maxSizeF *qflag.SizeFlag // 最大文件大小
 	minSizeF *qflag.SizeFlag // 最小文件大小
+
+	// 文件属性过滤
+	newerThanF *qflag.StringFlag      // 修改时间下限
+	olderThanF *qflag.StringFlag      // 修改时间上限
+	fileTypesF *qflag.StringSliceFlag // 文件类型
+	ownerF     *qflag.StringFlag      // 文件属主
+	groupF     *qflag.StringFlag      // 文件属组
+	hiddenF    *qflag.BoolFlag        // 是否排除隐藏文件
+
+	// 链接处理
+	symlinksF *qflag.EnumFlag // 符号链接处理策略 (store/follow/skip)
+
+	// 错误处理
+	errorPolicyF *qflag.EnumFlag // 单个条目出错时的处理策略 (strict/skip-unreadable/skip-changed)
+
+	// 钩子命令
+	preHookF       *qflag.StringFlag // 备份前执行的命令
+	postHookF      *qflag.StringFlag // 备份成功后执行的命令
+	onFailureHookF *qflag.StringFlag // 备份失败时执行的命令
+	hookTimeoutF   *qflag.IntFlag    // 钩子超时时间（秒）
+	timeoutF       *qflag.IntFlag    // 任务超时时间（秒）
+
+	// 限速选项
+	readLimitF *qflag.SizeFlag // 读取限速（每秒）
 )
 
 // InitAddCmd 初始化添加备份命令
@@ -55,7 +103,7 @@ func InitAddCmd() *qflag.Cmd {
 
 	// 基本任务信息
 	nameF = addCmd.String("name", "n", "", "任务名称 (必需)")
-	backupDirF = addCmd.String("backup-dir", "b", "", "备份源目录 (必需)")
+	backupDirF = addCmd.StringSlice("backup-dir", "b", []string{}, "备份源路径 (必需, 目录或单个文件, 多个路径用逗号分隔)")
 	storageDirF = addCmd.String("storage-dir", "s", "", "存储目录 (必需)")
 
 	// 保留策略
@@ -63,15 +111,61 @@ func InitAddCmd() *qflag.Cmd {
 	retainDaysF = addCmd.Int("retain-days", "t", 7, "保留天数")
 
 	// 压缩选项
-	compressF = addCmd.Bool("compress", "c", false, "是否压缩备份")
+	compressF = addCmd.Bool("compress", "c", false, "是否压缩备份 (未指定压缩等级时使用 default 等级)")
+	compressionF = addCmd.Enum("compression", "cl", types.CompressionNone, "压缩等级 (none, fast, default, best, huffman)", types.CompressionList)
+	storeExtsF = addCmd.StringSlice("store-ext", "se", []string{}, "始终不压缩的文件扩展名 (仅zip格式有效), 多个扩展名用逗号分隔, 如 jpg,mp4,zip,7z")
+
+	// 备份模式
+	modeF = addCmd.Enum("mode", "m", types.BackupModeFull, "备份模式 (full: 全量备份, incremental: 增量备份)", types.BackupModeList)
+	fullEveryF = addCmd.Int("full-every", "fe", types.DefaultFullEvery, "增量备份每隔多少次执行一次全量备份, 之后的增量备份以它为基础开始新的备份链 (仅增量模式有效)")
+	storageModeF = addCmd.Enum("storage-mode", "sm", types.StorageModeArchive, "存储模式 (archive: 归档文件, repository: 去重数据块仓库)", types.StorageModeList)
+	formatF = addCmd.Enum("format", "f", types.FormatZip, "归档格式 (zip, tar, tar.gz, tar.bz2; 仅归档存储模式有效)", types.FormatList)
+
+	// 分卷选项
+	volumeSizeF = addCmd.Size("volume-size", "vs", 0, "分卷大小, 如 4GB, 备份文件按此大小拆分为多个分卷 (0表示不分卷, 仅归档存储模式有效)")
+
+	// 校验选项
+	hashF = addCmd.Enum("hash-algorithm", "ha", types.DefaultHashAlgorithm, "备份文件校验码的哈希算法 (sha256, sha512, blake2b, xxhash; xxhash 速度最快但只能发现意外损坏)", types.HashAlgorithmList)
+
+	// 加密选项
+	encryptF = addCmd.Bool("encrypt", "en", false, "加密备份文件 (AES-256-GCM, 未指定密钥文件时使用密码, 密码通过 BAKCTL_PASSPHRASE 环境变量或终端输入)")
+	keyFileF = addCmd.String("key-file", "kf", "", "密钥文件路径 (至少32字节, 指定后自动启用加密)")
+	recipientsF = addCmd.StringSlice("recipient", "rc", []string{}, "接收者公钥 (由 keygen 命令生成, 指定后自动启用加密, 只有对应的身份文件能解密), 多个公钥用逗号分隔")
 
 	// 文件过滤规则
-	includeF = addCmd.StringSlice("include", "i", []string{}, "包含规则, 多个规则用逗号分隔")
-	excludeF = addCmd.StringSlice("exclude", "e", []string{}, "排除规则, 多个规则用逗号分隔")
+	includeF = addCmd.StringSlice("include", "i", []string{}, "包含规则, 多个规则用逗号分隔, 支持 glob:、path:、re: 前缀")
+	excludeF = addCmd.StringSlice("exclude", "e", []string{}, "排除规则, 多个规则用逗号分隔, 支持 glob:、path:、re: 前缀")
+	includeF.SetDelimiters([]string{","}) // 规则中可能包含 :、; 和 |（如 path: 前缀和正则表达式），只按逗号分隔
+	excludeF.SetDelimiters([]string{","}) // 同上
+	ignoreF = addCmd.Bool("ignore-files", "if", false, "使用备份源目录及其子目录中的 .bakignore 忽略文件 (语法同 .gitignore, 支持 ! 否定规则)")
 
 	// 文件大小限制
 	maxSizeF = addCmd.Size("max-size", "mx", 0, "最大文件大小 (0表示无限制)")
 	minSizeF = addCmd.Size("min-size", "ms", 0, "最小文件大小 (0表示无限制)")
 
+	// 文件属性过滤
+	newerThanF = addCmd.String("newer-than", "nt", "", "只备份在此之后修改的文件, 时长如 7d、12h 或日期如 2024-01-02")
+	olderThanF = addCmd.String("older-than", "ot", "", "只备份在此之前修改的文件, 时长如 30d 或日期如 2024-01-02")
+	fileTypesF = addCmd.StringSlice("file-type", "ft", []string{}, "只备份这些类型的文件 (file, symlink), 多个类型用逗号分隔")
+	ownerF = addCmd.String("owner", "ow", "", "只备份属于此用户的文件 (用户名或UID, 仅Unix平台)")
+	groupF = addCmd.String("group", "gp", "", "只备份属于此用户组的文件 (组名或GID, 仅Unix平台)")
+	hiddenF = addCmd.Bool("exclude-hidden", "eh", false, "排除名称以 . 开头的隐藏文件和目录")
+
+	// 链接处理
+	symlinksF = addCmd.Enum("symlinks", "sl", types.SymlinkStore, "符号链接处理策略 (store: 存储链接本身, follow: 跟随链接备份其指向的内容, skip: 跳过)", types.SymlinkPolicyList)
+
+	// 错误处理
+	errorPolicyF = addCmd.Enum("error-policy", "ep", types.ErrorPolicyStrict, "单个条目出错时的处理策略 (strict: 中止备份, skip-unreadable: 跳过无法读取的文件并记录警告, skip-changed: 同时容忍读取过程中被修改的文件)", types.ErrorPolicyList)
+
+	// 钩子命令
+	preHookF = addCmd.String("pre-hook", "", "", "备份前执行的命令, 执行失败时中止备份")
+	postHookF = addCmd.String("post-hook", "", "", "备份成功后执行的命令")
+	onFailureHookF = addCmd.String("on-failure-hook", "", "", "备份失败时执行的命令")
+	hookTimeoutF = addCmd.Int("hook-timeout", "", types.DefaultHookTimeout, "钩子命令的超时时间 (秒)")
+	timeoutF = addCmd.Int("timeout", "", 0, "任务超时时间, 超时后中止备份 (秒, 0表示不限制)")
+
+	// 限速选项
+	readLimitF = addCmd.Size("read-limit", "rl", 0, "读取限速, 如 50MB, 打包和校验时每秒读取的最大字节数 (0表示不限速)")
+
 	return addCmd
 }
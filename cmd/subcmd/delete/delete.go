@@ -17,11 +17,14 @@
 package delete
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 
 	DB "gitee.com/MM-Q/bakctl/internal/db"
+	"gitee.com/MM-Q/bakctl/internal/lock"
+	"gitee.com/MM-Q/bakctl/internal/repo"
 	"gitee.com/MM-Q/bakctl/internal/types"
 	"gitee.com/MM-Q/colorlib"
 	"github.com/jmoiron/sqlx"
@@ -151,6 +154,13 @@ func deleteTasksMode(db *sqlx.DB, cl *colorlib.ColorLib) error {
 		return nil // 用户取消，不需要额外提示
 	}
 
+	// 获取任务目录锁，避免与其他进程同时修改任务配置
+	catalogLock, err := lock.AcquireCatalog(types.DataDirPath, cl)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = catalogLock.Release() }()
+
 	// 执行删除
 	summary, err := deleteTasks(db, tasks, cl)
 	if err != nil {
@@ -331,6 +341,14 @@ func deleteTask(db *sqlx.DB, task types.BackupTask, cl *colorlib.ColorLib) Delet
 		Success:  false,
 	}
 
+	// 正在执行的任务不能删除，否则会删除其正在写入的备份文件
+	taskLock, err := lock.TryAcquire(lock.TaskPath(types.DataDirPath, task.ID), fmt.Sprintf("任务 '%s' (ID: %d) ", task.Name, task.ID))
+	if err != nil {
+		result.ErrorMsg = err.Error()
+		return result
+	}
+	defer func() { _ = taskLock.Release() }()
+
 	// 获取备份记录
 	records, err := DB.GetBackupRecordsByTaskID(db, task.ID)
 	if err != nil {
@@ -354,6 +372,13 @@ func deleteTask(db *sqlx.DB, task types.BackupTask, cl *colorlib.ColorLib) Delet
 		} else {
 			cl.Red("删除备份文件失败")
 		}
+
+		// 仓库模式删除快照后回收不再被引用的数据块（仓库可能与其他任务共用，不能整体删除）
+		if task.StorageMode == types.StorageModeRepository {
+			if err := collectRepositoryGarbage(task); err != nil {
+				cl.Redf("回收数据块失败: %v\n", err)
+			}
+		}
 	} else {
 		cl.White("跳过文件删除: 0个")
 	}
@@ -379,6 +404,34 @@ func deleteTask(db *sqlx.DB, task types.BackupTask, cl *colorlib.ColorLib) Delet
 	return result
 }
 
+// collectRepositoryGarbage 回收任务所在数据块仓库中不再被任何快照引用的数据块
+//
+// 参数:
+//   - task: 备份任务
+//
+// 返回:
+//   - error: 回收失败时返回错误信息
+func collectRepositoryGarbage(task types.BackupTask) error {
+	root := repo.Root(task.StorageDir)
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil
+	}
+
+	// 其他进程可能正在向共用的仓库写入数据块，回收前需要持有仓库锁
+	repoLock, err := lock.Acquire(context.Background(), repo.LockPath(task.StorageDir), "数据块仓库 ", nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = repoLock.Release() }()
+
+	r, err := repo.Open(root)
+	if err != nil {
+		return err
+	}
+	_, _, err = r.GC()
+	return err
+}
+
 // deleteBackupFiles 删除备份文件
 //
 // 参数:
@@ -398,20 +451,25 @@ func deleteBackupFiles(records []types.BackupRecord) (int, int, error) {
 			continue
 		}
 
-		// 检查文件是否存在
-		if _, err := os.Stat(record.StoragePath); os.IsNotExist(err) {
-			skipped++
-			continue
+		// 删除项（分卷备份删除所有分卷）
+		removed, failed := 0, false
+		for _, path := range record.VolumePaths() {
+			// 检查文件是否存在
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				continue
+			}
+			if err := os.RemoveAll(path); err != nil {
+				errors = append(errors, fmt.Sprintf("删除文件 %s 失败: %v", path, err))
+				failed = true
+				continue
+			}
+			removed++
 		}
 
-		// 删除项
-		err := os.RemoveAll(record.StoragePath)
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("删除文件 %s 失败: %v", record.StoragePath, err))
+		if failed || removed == 0 {
 			skipped++
 			continue
 		}
-
 		deleted++
 	}
 
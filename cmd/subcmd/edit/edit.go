@@ -18,9 +18,14 @@ package edit
 
 import (
 	"fmt"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 
 	DB "gitee.com/MM-Q/bakctl/internal/db"
+	"gitee.com/MM-Q/bakctl/internal/filter"
+	"gitee.com/MM-Q/bakctl/internal/lock"
 	"gitee.com/MM-Q/bakctl/internal/types"
 	"gitee.com/MM-Q/bakctl/internal/utils"
 	"gitee.com/MM-Q/colorlib"
@@ -52,6 +57,13 @@ func EditCmdMain(db *sqlx.DB, cl *colorlib.ColorLib) error {
 		return fmt.Errorf("没有指定要更新的配置项")
 	}
 
+	// 获取任务目录锁，避免与其他进程同时修改任务配置
+	catalogLock, err := lock.AcquireCatalog(types.DataDirPath, cl)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = catalogLock.Release() }()
+
 	// 执行批量更新
 	successCount := 0
 	for _, taskID := range taskIDs {
@@ -110,15 +122,49 @@ func getTaskIDs() ([]int64, error) {
 // 返回值:
 //   - bool: 如果有任何一个更新标志被设置，则返回 true，否则返回 false
 func hasAnyUpdateFlags() bool {
-	return retainCountF.Get() != -1 ||
+	return len(backupDirF.Get()) > 0 ||
+		len(addSourceF.Get()) > 0 ||
+		len(removeSourceF.Get()) > 0 ||
+		retainCountF.Get() != -1 ||
 		retainDaysF.Get() != -1 ||
 		compressF.Get() != "" ||
+		compressionF.Get() != "" ||
+		len(storeExtsF.Get()) > 0 ||
+		clearStoreExtsF.Get() ||
 		len(includeF.Get()) > 0 ||
 		len(excludeF.Get()) > 0 ||
 		clearIncludeF.Get() ||
 		clearExcludeF.Get() ||
 		maxSizeF.Get() != -1 ||
-		minSizeF.Get() != -1
+		minSizeF.Get() != -1 ||
+		modeF.Get() != "" ||
+		storageModeF.Get() != "" ||
+		formatF.Get() != "" ||
+		volumeSizeF.Get() != -1 ||
+		hashF.Get() != "" ||
+		ignoreFilesF.Get() != "" ||
+		newerThanF.Get() != "" ||
+		olderThanF.Get() != "" ||
+		len(fileTypesF.Get()) > 0 ||
+		ownerF.Get() != "" ||
+		groupF.Get() != "" ||
+		hiddenF.Get() != "" ||
+		symlinksF.Get() != "" ||
+		errorPolicyF.Get() != "" ||
+		clearAttrsF.Get() ||
+		encryptF.Get() != "" ||
+		keyFileF.Get() != "" ||
+		clearKeyFileF.Get() ||
+		len(recipientsF.Get()) > 0 ||
+		clearRecipientF.Get() ||
+		preHookF.Get() != "" ||
+		postHookF.Get() != "" ||
+		onFailureHookF.Get() != "" ||
+		hookTimeoutF.Get() != -1 ||
+		fullEveryF.Get() != -1 ||
+		timeoutF.Get() != -1 ||
+		readLimitF.Get() != -1 ||
+		clearHooksF.Get()
 }
 
 // updateTask 更新单个任务
@@ -137,6 +183,18 @@ func updateTask(db *sqlx.DB, taskID int64) error {
 	}
 
 	// 准备更新的值，如果用户指定了新值且与当前值不同，则使用新值，否则使用当前值
+	// 备份源路径
+	newSources, err := updateSources(currentTask.Sources(), backupDirF.Get(), addSourceF.Get(), removeSourceF.Get())
+	if err != nil {
+		return err // 如果备份源路径无效，直接返回错误
+	}
+	var newSourcesJSON string // 只有一个备份源时不记录列表
+	if len(newSources) > 1 {
+		if newSourcesJSON, err = utils.MarshalRules(newSources); err != nil {
+			return fmt.Errorf("编码备份源路径失败: %w", err)
+		}
+	}
+
 	// 备份保留数量
 	newRetainCount := updateInt(currentTask.RetainCount, retainCountF.Get(), -1)
 
@@ -155,6 +213,108 @@ func updateTask(db *sqlx.DB, taskID int64) error {
 		return err // 如果解析失败，直接返回错误
 	}
 
+	// 压缩等级（是否压缩由压缩等级决定）
+	newCompression, err := updateCompression(currentTask.CompressionName(), compressionF.Get(), newCompress)
+	if err != nil {
+		return err // 如果压缩等级无效，直接返回错误
+	}
+	newCompress = newCompression != types.CompressionNone
+
+	// 不压缩的文件扩展名
+	storeExts, err := types.NormalizeStoreExts(storeExtsF.Get())
+	if err != nil {
+		return err
+	}
+	newStoreExts, err := updateRuleString(currentTask.StoreExts, storeExts, "不压缩的文件扩展名", clearStoreExtsF.Get())
+	if err != nil {
+		return err
+	}
+
+	// 备份模式
+	newBackupMode, err := updateBackupMode(currentTask.BackupMode, modeF.Get())
+	if err != nil {
+		return err // 如果备份模式无效，直接返回错误
+	}
+
+	// 存储模式
+	newStorageMode, err := updateStorageMode(currentTask.StorageMode, storageModeF.Get(), newBackupMode)
+	if err != nil {
+		return err // 如果存储模式无效或与备份模式冲突，直接返回错误
+	}
+
+	// 归档格式
+	newFormat, err := updateFormat(currentTask.Format, formatF.Get())
+	if err != nil {
+		return err // 如果归档格式无效，直接返回错误
+	}
+
+	// 符号链接处理策略
+	newSymlinks, err := updateSymlinks(currentTask.SymlinkPolicy(), symlinksF.Get())
+	if err != nil {
+		return err // 如果处理策略无效，直接返回错误
+	}
+
+	// 错误处理策略
+	newErrorPolicy, err := updateErrorPolicy(currentTask.ErrorPolicyName(), errorPolicyF.Get())
+	if err != nil {
+		return err // 如果处理策略无效，直接返回错误
+	}
+
+	// 分卷大小（0表示不分卷）
+	newVolumeSize := updateInt64(currentTask.VolumeSize, volumeSizeF.Get(), -1)
+	if err := types.ValidateVolumeSize(newVolumeSize, newStorageMode); err != nil {
+		return err // 如果分卷大小无效或与存储模式冲突，直接返回错误
+	}
+
+	// 校验码哈希算法（只影响之后的备份，已有备份仍按各自记录的算法验证）
+	newHashAlgorithm, err := updateHashAlgorithm(currentTask.HashAlgorithmName(), hashF.Get())
+	if err != nil {
+		return err // 如果哈希算法无效，直接返回错误
+	}
+
+	// 加密设置
+	newEncryption, newKeyFile, newRecipients, err := updateEncryption(*currentTask, newStorageMode)
+	if err != nil {
+		return err // 如果密钥文件无效或与存储模式冲突，直接返回错误
+	}
+
+	// 钩子命令
+	newPreHook := updateString(currentTask.PreHook, preHookF.Get(), clearHooksF.Get())
+	newPostHook := updateString(currentTask.PostHook, postHookF.Get(), clearHooksF.Get())
+	newOnFailureHook := updateString(currentTask.OnFailureHook, onFailureHookF.Get(), clearHooksF.Get())
+
+	// 钩子超时时间
+	newHookTimeout := updateInt(currentTask.HookTimeout, hookTimeoutF.Get(), -1)
+	if newHookTimeout <= 0 {
+		return fmt.Errorf("钩子超时时间必须大于0")
+	}
+
+	// 增量备份链的最大长度
+	newFullEvery := updateInt(currentTask.FullEveryCount(), fullEveryF.Get(), -1)
+	if newFullEvery <= 0 {
+		return fmt.Errorf("全量备份间隔必须大于0")
+	}
+
+	// 任务超时时间（0表示不限制）
+	newTimeout := updateInt(currentTask.Timeout, timeoutF.Get(), -1)
+	if newTimeout < 0 {
+		return fmt.Errorf("任务超时时间不能为负数")
+	}
+
+	// 读取限速（0表示不限速）
+	newReadLimit := updateInt64(currentTask.ReadLimit, readLimitF.Get(), -1)
+	if newReadLimit < 0 {
+		return fmt.Errorf("读取限速不能为负数")
+	}
+
+	// 验证包含和排除规则的语法
+	if err := filter.Validate(includeF.Get()); err != nil {
+		return fmt.Errorf("包含规则无效: %w", err)
+	}
+	if err := filter.Validate(excludeF.Get()); err != nil {
+		return fmt.Errorf("排除规则无效: %w", err)
+	}
+
 	// 包含规则
 	newIncludeRules, includrErr := updateRuleString(currentTask.IncludeRules, includeF.Get(), "包含规则", clearIncludeF.Get())
 	if includrErr != nil {
@@ -167,16 +327,56 @@ func updateTask(db *sqlx.DB, taskID int64) error {
 		return excludeErr // 如果解析失败，直接返回错误
 	}
 
+	// 是否使用忽略文件
+	newIgnoreFiles, err := updateBooleanFromFlag(currentTask.IgnoreFiles, ignoreFilesF.Get, "忽略文件参数")
+	if err != nil {
+		return err // 如果解析失败，直接返回错误
+	}
+
+	// 文件属性过滤条件
+	attrs, err := updateAttrFilters(*currentTask)
+	if err != nil {
+		return err // 如果过滤条件无效，直接返回错误
+	}
+
 	// 创建 UpdateTaskParams 结构体实例
 	params := types.UpdateTaskParams{
-		ID:           taskID,          // 任务ID
-		RetainCount:  newRetainCount,  // 备份保留数量
-		RetainDays:   newRetainDays,   // 备份保留天数
-		Compress:     newCompress,     // 备份是否压缩
-		IncludeRules: newIncludeRules, // 包含规则
-		ExcludeRules: newExcludeRules, // 排除规则
-		MaxFileSize:  newMaxFileSize,  // 最大文件大小
-		MinFileSize:  newMinFileSize,  // 最小文件大小
+		ID:            taskID,           // 任务ID
+		BackupDir:     newSources[0],    // 备份源目录
+		BackupSources: newSourcesJSON,   // 备份源路径列表
+		RetainCount:   newRetainCount,   // 备份保留数量
+		RetainDays:    newRetainDays,    // 备份保留天数
+		Compress:      newCompress,      // 备份是否压缩
+		IncludeRules:  newIncludeRules,  // 包含规则
+		ExcludeRules:  newExcludeRules,  // 排除规则
+		IgnoreFiles:   newIgnoreFiles,   // 是否使用忽略文件
+		NewerThan:     attrs.newerThan,  // 修改时间下限
+		OlderThan:     attrs.olderThan,  // 修改时间上限
+		FileTypes:     attrs.fileTypes,  // 文件类型
+		FileOwner:     attrs.owner,      // 文件属主
+		FileGroup:     attrs.group,      // 文件属组
+		ExcludeHidden: attrs.hidden,     // 是否排除隐藏文件
+		Symlinks:      newSymlinks,      // 符号链接处理策略
+		ErrorPolicy:   newErrorPolicy,   // 错误处理策略
+		MaxFileSize:   newMaxFileSize,   // 最大文件大小
+		MinFileSize:   newMinFileSize,   // 最小文件大小
+		BackupMode:    newBackupMode,    // 备份模式
+		FullEvery:     newFullEvery,     // 增量备份链的最大长度
+		StorageMode:   newStorageMode,   // 存储模式
+		Format:        newFormat,        // 归档格式
+		VolumeSize:    newVolumeSize,    // 分卷大小
+		HashAlgorithm: newHashAlgorithm, // 校验码哈希算法
+		Compression:   newCompression,   // 压缩等级
+		StoreExts:     newStoreExts,     // 不压缩的文件扩展名
+		Encryption:    newEncryption,    // 加密算法
+		KeyFile:       newKeyFile,       // 密钥文件路径
+		Recipients:    newRecipients,    // 接收者公钥
+		PreHook:       newPreHook,       // 备份前钩子
+		PostHook:      newPostHook,      // 备份后钩子
+		OnFailureHook: newOnFailureHook, // 失败钩子
+		HookTimeout:   newHookTimeout,   // 钩子超时时间
+		Timeout:       newTimeout,       // 任务超时时间
+		ReadLimit:     newReadLimit,     // 读取限速
 	}
 
 	// 调用 db 包中的 UpdateTask 函数，传入结构体
@@ -247,6 +447,341 @@ func updateBooleanFromFlag(currentValue bool, flagGetter func() string, paramNam
 	return b, nil // 解析成功，返回新的布尔值
 }
 
+// updateBackupMode 辅助函数，用于更新备份模式
+//
+// 参数:
+//   - currentMode: 当前任务中的备份模式
+//   - newMode: 从命令行参数中获取的新备份模式（空字符串表示不修改）
+//
+// 返回值:
+//   - string: 更新后的备份模式
+//   - error: 新备份模式无效时返回错误信息，否则返回 nil
+func updateBackupMode(currentMode, newMode string) (string, error) {
+	if newMode == "" {
+		return currentMode, nil
+	}
+
+	newMode = strings.ToLower(newMode)
+	if err := types.ValidateBackupMode(newMode); err != nil {
+		return currentMode, err
+	}
+
+	return newMode, nil
+}
+
+// updateCompression 辅助函数，用于更新压缩等级
+//
+// 参数:
+//   - currentCompression: 当前任务中的压缩等级
+//   - newCompression: 从命令行参数中获取的新压缩等级（空字符串表示不修改）
+//   - compress: 更新后的是否压缩（未指定新压缩等级时，开启压缩使用默认等级，关闭压缩不压缩）
+//
+// 返回值:
+//   - string: 更新后的压缩等级
+//   - error: 新压缩等级无效时返回错误信息，否则返回 nil
+func updateCompression(currentCompression, newCompression string, compress bool) (string, error) {
+	if newCompression != "" {
+		newCompression = strings.ToLower(newCompression)
+		if err := types.ValidateCompression(newCompression); err != nil {
+			return currentCompression, err
+		}
+		return newCompression, nil
+	}
+
+	switch {
+	case !compress:
+		return types.CompressionNone, nil
+	case currentCompression == types.CompressionNone:
+		return types.CompressionDefault, nil
+	default:
+		return currentCompression, nil
+	}
+}
+
+// updateFormat 辅助函数，用于更新归档格式
+//
+// 参数:
+//   - currentFormat: 当前任务中的归档格式
+//   - newFormat: 从命令行参数中获取的新归档格式（空字符串表示不修改）
+//
+// 返回值:
+//   - string: 更新后的归档格式
+//   - error: 新归档格式无效时返回错误信息，否则返回 nil
+func updateFormat(currentFormat, newFormat string) (string, error) {
+	if newFormat == "" {
+		return currentFormat, nil
+	}
+
+	newFormat = strings.ToLower(newFormat)
+	if err := types.ValidateFormat(newFormat); err != nil {
+		return currentFormat, err
+	}
+
+	return newFormat, nil
+}
+
+// updateSymlinks 辅助函数，用于更新符号链接处理策略
+//
+// 参数:
+//   - currentPolicy: 当前任务中的符号链接处理策略
+//   - newPolicy: 从命令行参数中获取的新处理策略（空字符串表示不修改）
+//
+// 返回值:
+//   - string: 更新后的处理策略
+//   - error: 新处理策略无效时返回错误信息，否则返回 nil
+func updateSymlinks(currentPolicy, newPolicy string) (string, error) {
+	if newPolicy == "" {
+		return currentPolicy, nil
+	}
+
+	newPolicy = strings.ToLower(newPolicy)
+	if err := types.ValidateSymlinkPolicy(newPolicy); err != nil {
+		return currentPolicy, err
+	}
+
+	return newPolicy, nil
+}
+
+// updateErrorPolicy 辅助函数，用于更新错误处理策略
+//
+// 参数:
+//   - currentPolicy: 当前任务中的错误处理策略
+//   - newPolicy: 从命令行参数中获取的新处理策略（空字符串表示不修改）
+//
+// 返回值:
+//   - string: 更新后的处理策略
+//   - error: 新处理策略无效时返回错误信息，否则返回 nil
+func updateErrorPolicy(currentPolicy, newPolicy string) (string, error) {
+	if newPolicy == "" {
+		return currentPolicy, nil
+	}
+
+	newPolicy = strings.ToLower(newPolicy)
+	if err := types.ValidateErrorPolicy(newPolicy); err != nil {
+		return currentPolicy, err
+	}
+
+	return newPolicy, nil
+}
+
+// updateHashAlgorithm 辅助函数，用于更新校验码哈希算法
+//
+// 参数:
+//   - currentAlgorithm: 当前任务中的哈希算法
+//   - newAlgorithm: 从命令行参数中获取的新哈希算法（空字符串表示不修改）
+//
+// 返回值:
+//   - string: 更新后的哈希算法
+//   - error: 新哈希算法无效时返回错误信息，否则返回 nil
+func updateHashAlgorithm(currentAlgorithm, newAlgorithm string) (string, error) {
+	if newAlgorithm == "" {
+		return currentAlgorithm, nil
+	}
+
+	newAlgorithm = strings.ToLower(newAlgorithm)
+	if err := types.ValidateHashAlgorithm(newAlgorithm); err != nil {
+		return currentAlgorithm, err
+	}
+
+	return newAlgorithm, nil
+}
+
+// updateEncryption 辅助函数，用于更新加密设置
+//
+// 指定密钥文件或接收者公钥时自动启用加密，并替换原有的接收者公钥或密钥文件；
+// 关闭加密时同时清空密钥文件和接收者公钥。
+// 修改加密设置只影响之后的备份，已有的备份文件仍按各自的加密方案恢复。
+//
+// 参数:
+//   - currentTask: 当前任务
+//   - storageMode: 更新后的存储模式
+//
+// 返回值:
+//   - string: 更新后的加密算法（为空表示不加密）
+//   - string: 更新后的密钥文件路径
+//   - string: 更新后的接收者公钥（JSON数组格式）
+//   - error: 参数无效或与存储模式冲突时返回错误信息，否则返回 nil
+func updateEncryption(currentTask types.BackupTask, storageMode string) (string, string, string, error) {
+	unchanged := func(err error) (string, string, string, error) {
+		return currentTask.Encryption, currentTask.KeyFile, currentTask.Recipients, err
+	}
+
+	encrypt, err := updateBooleanFromFlag(currentTask.Encryption != "", encryptF.Get, "加密参数")
+	if err != nil {
+		return unchanged(err)
+	}
+	if keyFileF.Get() != "" && len(recipientsF.Get()) > 0 {
+		return unchanged(fmt.Errorf("不能同时指定接收者公钥和密钥文件"))
+	}
+
+	keyFile := currentTask.KeyFile
+	recipients := currentTask.Recipients
+	if clearKeyFileF.Get() {
+		keyFile = ""
+	}
+	if clearRecipientF.Get() {
+		recipients = ""
+	}
+	if keyFileF.Get() != "" {
+		if keyFile, err = types.ValidateKeyFile(keyFileF.Get()); err != nil {
+			return unchanged(err)
+		}
+		recipients = ""
+		encrypt = encrypt || encryptF.Get() == ""
+	}
+	if len(recipientsF.Get()) > 0 {
+		list, err := types.NormalizeRecipients(recipientsF.Get())
+		if err != nil {
+			return unchanged(err)
+		}
+		if len(list) == 0 {
+			return unchanged(fmt.Errorf("接收者公钥不能为空"))
+		}
+		if recipients, err = utils.MarshalRules(list); err != nil {
+			return unchanged(fmt.Errorf("编码接收者公钥失败: %w", err))
+		}
+		keyFile = ""
+		encrypt = encrypt || encryptF.Get() == ""
+	}
+
+	if !encrypt {
+		return "", "", "", nil
+	}
+	if storageMode == types.StorageModeRepository {
+		return unchanged(fmt.Errorf("仓库存储模式不支持加密备份"))
+	}
+	return types.EncryptionAES256GCM, keyFile, recipients, nil
+}
+
+// updateStorageMode 辅助函数，用于更新存储模式
+//
+// 参数:
+//   - currentMode: 当前任务中的存储模式
+//   - newMode: 从命令行参数中获取的新存储模式（空字符串表示不修改）
+//   - backupMode: 更新后的备份模式
+//
+// 返回值:
+//   - string: 更新后的存储模式
+//   - error: 新存储模式无效或与备份模式冲突时返回错误信息，否则返回 nil
+func updateStorageMode(currentMode, newMode, backupMode string) (string, error) {
+	mode := currentMode
+	if newMode != "" {
+		mode = strings.ToLower(newMode)
+	}
+
+	// 即使存储模式未修改，备份模式的修改也可能与之冲突
+	if err := types.ValidateStorageMode(mode, backupMode); err != nil {
+		return currentMode, err
+	}
+
+	return mode, nil
+}
+
+// updateSources 辅助函数，用于更新备份源路径列表
+//
+// 参数:
+//   - current: 当前任务中的备份源路径列表
+//   - replace: 替换全部备份源路径的新列表（为空表示不替换）
+//   - add: 要添加的备份源路径
+//   - remove: 要移除的备份源路径
+//
+// 返回值:
+//   - []string: 更新后的备份源路径列表
+//   - error: 要移除的路径不存在于列表中或更新后的列表无效时返回错误信息
+func updateSources(current, replace, add, remove []string) ([]string, error) {
+	sources := current
+	if len(replace) > 0 {
+		sources = replace
+	}
+	sources = append(append([]string{}, sources...), add...)
+
+	for _, r := range remove {
+		r = filepath.Clean(strings.TrimSpace(r))
+		idx := slices.IndexFunc(sources, func(s string) bool { return filepath.Clean(s) == r })
+		if idx < 0 {
+			return nil, fmt.Errorf("备份源路径不存在: %s", r)
+		}
+		sources = slices.Delete(sources, idx, idx+1)
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("不能移除全部备份源路径")
+	}
+
+	return types.ValidateSources(sources)
+}
+
+// attrFilters 更新后的文件属性过滤条件
+type attrFilters struct {
+	newerThan string // 修改时间下限
+	olderThan string // 修改时间上限
+	fileTypes string // 文件类型（JSON数组格式，为空表示不限制）
+	owner     string // 文件属主
+	group     string // 文件属组
+	hidden    bool   // 是否排除隐藏文件
+}
+
+// updateAttrFilters 辅助函数，用于更新文件属性过滤条件
+//
+// 指定 --clear-attr-filters 时先清空修改时间、文件类型、属主和属组条件，再应用同时指定的新条件。
+//
+// 参数:
+//   - currentTask: 当前任务
+//
+// 返回值:
+//   - attrFilters: 更新后的过滤条件
+//   - error: 过滤条件无效时返回错误信息，否则返回 nil
+func updateAttrFilters(currentTask types.BackupTask) (attrFilters, error) {
+	clearAttrs := clearAttrsF.Get()
+	attrs := attrFilters{
+		newerThan: updateString(currentTask.NewerThan, newerThanF.Get(), clearAttrs),
+		olderThan: updateString(currentTask.OlderThan, olderThanF.Get(), clearAttrs),
+		fileTypes: updateString(currentTask.FileTypes, "", clearAttrs),
+		owner:     updateString(currentTask.FileOwner, ownerF.Get(), clearAttrs),
+		group:     updateString(currentTask.FileGroup, groupF.Get(), clearAttrs),
+	}
+
+	if len(fileTypesF.Get()) > 0 {
+		fileTypes, err := filter.NormalizeTypes(fileTypesF.Get())
+		if err != nil {
+			return attrFilters{}, err
+		}
+		if attrs.fileTypes, err = utils.MarshalRules(fileTypes); err != nil {
+			return attrFilters{}, fmt.Errorf("编码文件类型失败: %w", err)
+		}
+	}
+
+	if err := types.ValidateAttrFilters(attrs.newerThan, attrs.olderThan, attrs.owner, attrs.group); err != nil {
+		return attrFilters{}, err
+	}
+
+	hidden, err := updateBooleanFromFlag(currentTask.ExcludeHidden, hiddenF.Get, "排除隐藏文件参数")
+	if err != nil {
+		return attrFilters{}, err
+	}
+	attrs.hidden = hidden
+
+	return attrs, nil
+}
+
+// updateString 辅助函数，用于更新可以清空的字符串配置（如钩子命令和文件属性过滤条件）
+//
+// 参数:
+//   - currentVal: 当前任务中的值
+//   - newVal: 从命令行参数中获取的新值（空字符串表示不修改）
+//   - clear: 是否清空
+//
+// 返回值:
+//   - string: 更新后的值（同时指定新值和清空时使用新值）
+func updateString(currentVal, newVal string, clear bool) string {
+	if newVal != "" {
+		return newVal
+	}
+	if clear {
+		return ""
+	}
+	return currentVal
+}
+
 // updateInt64 辅助函数，用于更新 int64 类型的值
 //
 // 参数:
@@ -23,17 +23,55 @@ var (
 	idsF *qflag.Int64SliceFlag // 多个任务ID (切片类型)
 
 	// 可编辑的配置项
-	retainCountF *qflag.IntFlag         // 保留备份数量
-	retainDaysF  *qflag.IntFlag         // 保留天数
-	compressF    *qflag.StringFlag      // 是否压缩 (使用字符串来区分未设置)
-	includeF     *qflag.StringSliceFlag // 包含规则 (切片类型)
-	excludeF     *qflag.StringSliceFlag // 排除规则 (切片类型)
-	maxSizeF     *qflag.SizeFlag        // 最大文件大小
-	minSizeF     *qflag.SizeFlag        // 最小文件大小
+	backupDirF    *qflag.StringSliceFlag // 备份源路径 (替换全部备份源路径)
+	addSourceF    *qflag.StringSliceFlag // 添加备份源路径
+	removeSourceF *qflag.StringSliceFlag // 移除备份源路径
+	retainCountF  *qflag.IntFlag         // 保留备份数量
+	retainDaysF   *qflag.IntFlag         // 保留天数
+	compressF     *qflag.StringFlag      // 是否压缩 (使用字符串来区分未设置)
+	compressionF  *qflag.StringFlag      // 压缩等级 (使用字符串来区分未设置)
+	storeExtsF    *qflag.StringSliceFlag // 始终不压缩的文件扩展名 (切片类型)
+	includeF      *qflag.StringSliceFlag // 包含规则 (切片类型)
+	excludeF      *qflag.StringSliceFlag // 排除规则 (切片类型)
+	ignoreFilesF  *qflag.StringFlag      // 是否使用忽略文件 (使用字符串来区分未设置)
+	newerThanF    *qflag.StringFlag      // 修改时间下限 (空字符串表示不修改)
+	olderThanF    *qflag.StringFlag      // 修改时间上限 (空字符串表示不修改)
+	fileTypesF    *qflag.StringSliceFlag // 文件类型 (替换全部文件类型)
+	ownerF        *qflag.StringFlag      // 文件属主 (空字符串表示不修改)
+	groupF        *qflag.StringFlag      // 文件属组 (空字符串表示不修改)
+	hiddenF       *qflag.StringFlag      // 是否排除隐藏文件 (使用字符串来区分未设置)
+	symlinksF     *qflag.StringFlag      // 符号链接处理策略 (使用字符串来区分未设置)
+	errorPolicyF  *qflag.StringFlag      // 错误处理策略 (使用字符串来区分未设置)
+	maxSizeF      *qflag.SizeFlag        // 最大文件大小
+	minSizeF      *qflag.SizeFlag        // 最小文件大小
+	modeF         *qflag.StringFlag      // 备份模式 (使用字符串来区分未设置)
+	fullEveryF    *qflag.IntFlag         // 增量备份链的最大长度 (-1表示不修改)
+	storageModeF  *qflag.StringFlag      // 存储模式 (使用字符串来区分未设置)
+	formatF       *qflag.StringFlag      // 归档格式 (使用字符串来区分未设置)
+	volumeSizeF   *qflag.SizeFlag        // 分卷大小
+	hashF         *qflag.StringFlag      // 校验码哈希算法 (使用字符串来区分未设置)
+	encryptF      *qflag.StringFlag      // 是否加密 (使用字符串来区分未设置)
+	keyFileF      *qflag.StringFlag      // 密钥文件路径 (空字符串表示不修改)
+	recipientsF   *qflag.StringSliceFlag // 接收者公钥 (替换全部接收者)
+
+	// 钩子命令
+	preHookF       *qflag.StringFlag // 备份前执行的命令 (空字符串表示不修改)
+	postHookF      *qflag.StringFlag // 备份成功后执行的命令 (空字符串表示不修改)
+	onFailureHookF *qflag.StringFlag // 备份失败时执行的命令 (空字符串表示不修改)
+	hookTimeoutF   *qflag.IntFlag    // 钩子超时时间 (-1表示不修改)
+	timeoutF       *qflag.IntFlag    // 任务超时时间 (-1表示不修改)
+
+	// 限速选项
+	readLimitF *qflag.SizeFlag // 读取限速 (-1表示不修改)
 
 	// 特殊标志：用于清空规则
-	clearIncludeF *qflag.BoolFlag // 清空包含规则
-	clearExcludeF *qflag.BoolFlag // 清空排除规则
+	clearIncludeF   *qflag.BoolFlag // 清空包含规则
+	clearExcludeF   *qflag.BoolFlag // 清空排除规则
+	clearStoreExtsF *qflag.BoolFlag // 清空不压缩的文件扩展名
+	clearKeyFileF   *qflag.BoolFlag // 清空密钥文件（改为使用密码加密）
+	clearRecipientF *qflag.BoolFlag // 清空接收者公钥（改为使用密码加密）
+	clearHooksF     *qflag.BoolFlag // 清空钩子命令
+	clearAttrsF     *qflag.BoolFlag // 清空文件属性过滤条件
 )
 
 func InitEditCmd() *qflag.Cmd {
@@ -46,17 +84,57 @@ func InitEditCmd() *qflag.Cmd {
 	idsF = editCmd.Int64Slice("", "ids", []int64{}, "指定多个任务ID进行批量编辑")
 
 	// 可编辑的配置项
+	backupDirF = editCmd.StringSlice("backup-dir", "b", []string{}, "备份源路径, 替换全部备份源路径, 多个路径用逗号分隔")
+	addSourceF = editCmd.StringSlice("add-source", "", []string{}, "添加备份源路径, 多个路径用逗号分隔")
+	removeSourceF = editCmd.StringSlice("remove-source", "", []string{}, "移除备份源路径, 多个路径用逗号分隔")
 	retainCountF = editCmd.Int("retain-count", "r", -1, "保留备份数量 (-1表示不修改)")
 	retainDaysF = editCmd.Int("retain-days", "t", -1, "保留天数 (-1表示不修改)")
 	compressF = editCmd.String("compress", "c", "", "是否压缩备份 (true/false, 空字符串表示不修改)")
-	includeF = editCmd.StringSlice("include", "i", []string{}, "包含规则, 多个规则用逗号分隔")
-	excludeF = editCmd.StringSlice("exclude", "x", []string{}, "排除规则,	多个规则用逗号分隔")
+	compressionF = editCmd.String("compression", "cl", "", "压缩等级 (none/fast/default/best/huffman, 空字符串表示不修改)")
+	storeExtsF = editCmd.StringSlice("store-ext", "se", []string{}, "始终不压缩的文件扩展名, 多个扩展名用逗号分隔")
+	includeF = editCmd.StringSlice("include", "i", []string{}, "包含规则, 多个规则用逗号分隔, 支持 glob:、path:、re: 前缀")
+	excludeF = editCmd.StringSlice("exclude", "x", []string{}, "排除规则, 多个规则用逗号分隔, 支持 glob:、path:、re: 前缀")
+	includeF.SetDelimiters([]string{","}) // 规则中可能包含 :、; 和 |（如 path: 前缀和正则表达式），只按逗号分隔
+	excludeF.SetDelimiters([]string{","}) // 同上
+	ignoreFilesF = editCmd.String("ignore-files", "if", "", "是否使用备份源目录中的 .bakignore 忽略文件 (true/false, 空字符串表示不修改)")
+	newerThanF = editCmd.String("newer-than", "nt", "", "只备份在此之后修改的文件, 时长如 7d、12h 或日期如 2024-01-02 (空字符串表示不修改)")
+	olderThanF = editCmd.String("older-than", "ot", "", "只备份在此之前修改的文件, 时长如 30d 或日期如 2024-01-02 (空字符串表示不修改)")
+	fileTypesF = editCmd.StringSlice("file-type", "ft", []string{}, "只备份这些类型的文件 (file, symlink), 替换全部文件类型, 多个类型用逗号分隔")
+	ownerF = editCmd.String("owner", "ow", "", "只备份属于此用户的文件 (用户名或UID, 仅Unix平台, 空字符串表示不修改)")
+	groupF = editCmd.String("group", "gp", "", "只备份属于此用户组的文件 (组名或GID, 仅Unix平台, 空字符串表示不修改)")
+	hiddenF = editCmd.String("exclude-hidden", "eh", "", "是否排除名称以 . 开头的隐藏文件和目录 (true/false, 空字符串表示不修改)")
+	symlinksF = editCmd.String("symlinks", "sl", "", "符号链接处理策略 (store/follow/skip, 空字符串表示不修改)")
+	errorPolicyF = editCmd.String("error-policy", "ep", "", "单个条目出错时的处理策略 (strict/skip-unreadable/skip-changed, 空字符串表示不修改)")
 	maxSizeF = editCmd.Size("max-size", "mx", -1, "最大文件大小 (字节, -1表示不修改)")
 	minSizeF = editCmd.Size("min-size", "ms", -1, "最小文件大小 (字节, -1表示不修改)")
+	modeF = editCmd.String("mode", "m", "", "备份模式 (full/incremental, 空字符串表示不修改)")
+	fullEveryF = editCmd.Int("full-every", "fe", -1, "增量备份每隔多少次执行一次全量备份 (-1表示不修改)")
+	storageModeF = editCmd.String("storage-mode", "sm", "", "存储模式 (archive/repository, 空字符串表示不修改)")
+	formatF = editCmd.String("format", "f", "", "归档格式 (zip/tar/tar.gz/tar.bz2, 空字符串表示不修改)")
+	volumeSizeF = editCmd.Size("volume-size", "vs", -1, "分卷大小 (0表示不分卷, -1表示不修改)")
+	hashF = editCmd.String("hash-algorithm", "ha", "", "备份文件校验码的哈希算法 (sha256/sha512/blake2b/xxhash, 空字符串表示不修改)")
+	encryptF = editCmd.String("encrypt", "en", "", "是否加密备份文件 (true/false, 空字符串表示不修改)")
+	keyFileF = editCmd.String("key-file", "kf", "", "密钥文件路径, 指定后自动启用加密 (空字符串表示不修改)")
+	recipientsF = editCmd.StringSlice("recipient", "rc", []string{}, "接收者公钥, 替换全部接收者并自动启用加密, 多个公钥用逗号分隔")
+
+	// 钩子命令
+	preHookF = editCmd.String("pre-hook", "", "", "备份前执行的命令 (空字符串表示不修改)")
+	postHookF = editCmd.String("post-hook", "", "", "备份成功后执行的命令 (空字符串表示不修改)")
+	onFailureHookF = editCmd.String("on-failure-hook", "", "", "备份失败时执行的命令 (空字符串表示不修改)")
+	hookTimeoutF = editCmd.Int("hook-timeout", "", -1, "钩子命令的超时时间 (秒, -1表示不修改)")
+	timeoutF = editCmd.Int("timeout", "", -1, "任务超时时间 (秒, 0表示不限制, -1表示不修改)")
+
+	// 限速选项
+	readLimitF = editCmd.Size("read-limit", "rl", -1, "读取限速, 每秒读取的最大字节数 (0表示不限速, -1表示不修改)")
 
 	// 特殊标志：用于清空规则
 	clearIncludeF = editCmd.Bool("clear-include", "", false, "清空包含规则")
 	clearExcludeF = editCmd.Bool("clear-exclude", "", false, "清空排除规则")
+	clearStoreExtsF = editCmd.Bool("clear-store-ext", "", false, "清空不压缩的文件扩展名")
+	clearKeyFileF = editCmd.Bool("clear-key-file", "", false, "清空密钥文件, 改为使用密码加密")
+	clearRecipientF = editCmd.Bool("clear-recipient", "", false, "清空接收者公钥, 改为使用密码加密")
+	clearHooksF = editCmd.Bool("clear-hooks", "", false, "清空所有钩子命令 (可与钩子参数同时使用以重新设置)")
+	clearAttrsF = editCmd.Bool("clear-attr-filters", "", false, "清空修改时间、文件类型、属主和属组过滤条件 (可与对应参数同时使用以重新设置)")
 
 	return editCmd
 }
@@ -226,7 +226,7 @@ func buildAddCommand(task types.BackupTask) string {
 
 	// 基本参数 (必需) - 根据最新的flags.go更新参数名
 	parts = append(parts, fmt.Sprintf(`--name "%s"`, escapeQuotes(task.Name)))
-	parts = append(parts, fmt.Sprintf(`--backup-dir "%s"`, escapeQuotes(task.BackupDir)))
+	parts = append(parts, fmt.Sprintf(`--backup-dir "%s"`, escapeQuotes(strings.Join(task.Sources(), ","))))
 	parts = append(parts, fmt.Sprintf(`--storage-dir "%s"`, escapeQuotes(filepath.Dir(task.StorageDir))))
 
 	// 可选参数 (只有与默认值不同时才添加)
@@ -236,8 +236,39 @@ func buildAddCommand(task types.BackupTask) string {
 	if task.RetainDays != 7 { // 默认值
 		parts = append(parts, fmt.Sprintf("--retain-days %d", task.RetainDays))
 	}
-	if task.Compress {
-		parts = append(parts, "--compress")
+	if compression := task.CompressionName(); compression != types.CompressionNone { // 默认值
+		parts = append(parts, fmt.Sprintf("--compression %s", compression))
+	}
+	if exts := task.StoreExtList(); len(exts) > 0 {
+		parts = append(parts, fmt.Sprintf(`--store-ext "%s"`, strings.Join(exts, ",")))
+	}
+	if task.BackupMode != "" && task.BackupMode != types.BackupModeFull { // 默认值
+		parts = append(parts, fmt.Sprintf("--mode %s", task.BackupMode))
+	}
+	if task.BackupMode == types.BackupModeIncremental && task.FullEveryCount() != types.DefaultFullEvery { // 默认值
+		parts = append(parts, fmt.Sprintf("--full-every %d", task.FullEveryCount()))
+	}
+	if task.StorageMode != "" && task.StorageMode != types.StorageModeArchive { // 默认值
+		parts = append(parts, fmt.Sprintf("--storage-mode %s", task.StorageMode))
+	}
+	if task.Format != "" && task.Format != types.FormatZip { // 默认值
+		parts = append(parts, fmt.Sprintf("--format %s", task.Format))
+	}
+	if task.VolumeSize > 0 {
+		parts = append(parts, fmt.Sprintf("--volume-size %d", task.VolumeSize))
+	}
+	if task.ReadLimit > 0 {
+		parts = append(parts, fmt.Sprintf("--read-limit %d", task.ReadLimit))
+	}
+	if algorithm := task.HashAlgorithmName(); algorithm != types.DefaultHashAlgorithm { // 默认值
+		parts = append(parts, fmt.Sprintf("--hash-algorithm %s", algorithm))
+	}
+	if recipients := task.RecipientList(); len(recipients) > 0 {
+		parts = append(parts, fmt.Sprintf(`--recipient "%s"`, strings.Join(recipients, ",")))
+	} else if task.KeyFile != "" {
+		parts = append(parts, fmt.Sprintf(`--key-file "%s"`, escapeQuotes(task.KeyFile)))
+	} else if task.Encryption != "" {
+		parts = append(parts, "--encrypt")
 	}
 
 	// 处理包含规则 - 每个规则作为单独的参数
@@ -258,6 +289,36 @@ func buildAddCommand(task types.BackupTask) string {
 		}
 	}
 
+	if task.IgnoreFiles {
+		parts = append(parts, "--ignore-files")
+	}
+
+	// 文件属性过滤条件
+	if task.NewerThan != "" {
+		parts = append(parts, fmt.Sprintf(`--newer-than "%s"`, escapeQuotes(task.NewerThan)))
+	}
+	if task.OlderThan != "" {
+		parts = append(parts, fmt.Sprintf(`--older-than "%s"`, escapeQuotes(task.OlderThan)))
+	}
+	if fileTypes := task.FileTypeList(); len(fileTypes) > 0 {
+		parts = append(parts, fmt.Sprintf("--file-type %s", strings.Join(fileTypes, ",")))
+	}
+	if task.FileOwner != "" {
+		parts = append(parts, fmt.Sprintf(`--owner "%s"`, escapeQuotes(task.FileOwner)))
+	}
+	if task.FileGroup != "" {
+		parts = append(parts, fmt.Sprintf(`--group "%s"`, escapeQuotes(task.FileGroup)))
+	}
+	if task.ExcludeHidden {
+		parts = append(parts, "--exclude-hidden")
+	}
+	if policy := task.SymlinkPolicy(); policy != types.SymlinkStore { // 默认值
+		parts = append(parts, fmt.Sprintf("--symlinks %s", policy))
+	}
+	if policy := task.ErrorPolicyName(); policy != types.ErrorPolicyStrict { // 默认值
+		parts = append(parts, fmt.Sprintf("--error-policy %s", policy))
+	}
+
 	// 文件大小限制 - 根据最新的flags.go更新参数名
 	if task.MaxFileSize > 0 {
 		parts = append(parts, fmt.Sprintf("--max-size %d", task.MaxFileSize))
@@ -266,6 +327,23 @@ func buildAddCommand(task types.BackupTask) string {
 		parts = append(parts, fmt.Sprintf("--min-size %d", task.MinFileSize))
 	}
 
+	// 钩子命令 - 钩子中通常引用 $BAKCTL_* 环境变量，需要避免在导出的命令中被提前展开
+	if task.PreHook != "" {
+		parts = append(parts, fmt.Sprintf(`--pre-hook "%s"`, escapeHook(task.PreHook)))
+	}
+	if task.PostHook != "" {
+		parts = append(parts, fmt.Sprintf(`--post-hook "%s"`, escapeHook(task.PostHook)))
+	}
+	if task.OnFailureHook != "" {
+		parts = append(parts, fmt.Sprintf(`--on-failure-hook "%s"`, escapeHook(task.OnFailureHook)))
+	}
+	if task.HookTimeout > 0 && task.HookTimeout != types.DefaultHookTimeout { // 默认值
+		parts = append(parts, fmt.Sprintf("--hook-timeout %d", task.HookTimeout))
+	}
+	if task.Timeout > 0 { // 默认值为0
+		parts = append(parts, fmt.Sprintf("--timeout %d", task.Timeout))
+	}
+
 	return strings.Join(parts, " ")
 }
 
@@ -275,6 +353,12 @@ func escapeQuotes(s string) string {
 	return strings.ReplaceAll(s, `"`, `\"`)
 }
 
+// escapeHook 转义钩子命令中的双引号、反斜杠、美元符号和反引号
+func escapeHook(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`")
+	return replacer.Replace(s)
+}
+
 // exportScriptMode 导出一键备份脚本模式
 //
 // 参数:
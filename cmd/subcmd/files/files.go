@@ -0,0 +1,218 @@
+// Package files 实现了 bakctl 的 files 子命令功能。
+//
+// 该包提供了查看备份版本文件清单的功能，支持：
+//   - 列出指定备份版本（默认为最新的成功备份）中的所有文件
+//   - 在任务的所有成功备份中查找文件，确定文件包含在哪些版本中
+//   - 按路径模式和变化类型过滤文件
+//
+// 文件清单在每次成功备份时记录到数据库中，查询时无需解压备份文件。
+// 早于文件清单功能的备份没有记录清单，无法查询。
+package files
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"time"
+
+	DB "gitee.com/MM-Q/bakctl/internal/db"
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/bakctl/internal/utils"
+	"gitee.com/MM-Q/colorlib"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/jmoiron/sqlx"
+)
+
+// FilesCmdMain files命令的主函数
+//
+// 参数:
+//   - db: 数据库连接
+//   - cl: 颜色库
+//
+// 返回值:
+//   - error: 错误信息
+func FilesCmdMain(db *sqlx.DB, cl *colorlib.ColorLib) error {
+	// 1. 验证参数
+	if err := validateFilesFlags(); err != nil {
+		return err
+	}
+
+	taskID := taskIDFlag.Get()
+	if !DB.TaskExists(db, taskID) {
+		return fmt.Errorf("任务ID %d 不存在", taskID)
+	}
+
+	filter := types.BackupFileFilter{
+		Pattern:    patternFlag.Get(),    // 路径匹配模式
+		ChangeType: changeTypeFlag.Get(), // 变化类型
+	}
+
+	// 2. 查询文件清单
+	var files []types.BackupFile
+	if allVersionsFlag.Get() {
+		// 在所有版本中查找时，已删除的文件不属于该版本（除非指定只显示已删除的文件）
+		filter.ExcludeDeleted = filter.ChangeType == ""
+		var err error
+		if files, err = DB.GetTaskBackupFilesWithFilter(db, taskID, filter); err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			cl.Yellow("没有找到符合条件的文件")
+			return nil
+		}
+	} else {
+		record, err := selectRecord(db, taskID)
+		if err != nil {
+			return err
+		}
+		if files, err = DB.GetBackupFilesWithFilter(db, record.VersionID, filter); err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			if filter.Pattern == "" && filter.ChangeType == "" {
+				cl.Yellowf("版本 %s 没有记录文件清单 (早于文件清单功能的备份)\n", record.VersionID)
+			} else {
+				cl.Yellow("没有找到符合条件的文件")
+			}
+			return nil
+		}
+		cl.Bluef("任务ID %d 版本 %s (%s) 的文件清单:\n", taskID, record.VersionID, utils.ConvertUTCToLocal(record.CreatedAt))
+	}
+
+	// 3. 显示文件清单
+	return renderFiles(files, allVersionsFlag.Get(), cl)
+}
+
+// validateFilesFlags 验证files命令的标志参数
+func validateFilesFlags() error {
+	if taskIDFlag.Get() <= 0 {
+		return fmt.Errorf("任务ID必须大于0, 请使用 -id 指定")
+	}
+
+	if allVersionsFlag.Get() && versionIDFlag.Get() != "" {
+		return fmt.Errorf("-vid 和 --all-versions/-a 不能同时使用")
+	}
+
+	if t := changeTypeFlag.Get(); t != "" && !slices.Contains(types.ChangeTypeList, t) {
+		return fmt.Errorf("无效的变化类型: %s, 可选类型: %v", t, types.ChangeTypeList)
+	}
+
+	return nil
+}
+
+// selectRecord 返回要查看的备份记录（未指定版本ID时为最新的成功备份）
+//
+// 参数:
+//   - db: 数据库连接
+//   - taskID: 任务ID
+//
+// 返回值:
+//   - *types.BackupRecord: 备份记录
+//   - error: 查询失败或备份不是成功的备份时返回错误信息
+func selectRecord(db *sqlx.DB, taskID int64) (*types.BackupRecord, error) {
+	versionID := versionIDFlag.Get()
+	if versionID == "" {
+		return DB.GetLatestBackupRecordByTask(db, taskID)
+	}
+
+	record, err := DB.GetBackupRecordByTaskAndVersion(db, taskID, versionID)
+	if err != nil {
+		return nil, err
+	}
+	if !record.Status {
+		return nil, fmt.Errorf("版本 %s 是失败的备份, 没有文件清单", versionID)
+	}
+	return record, nil
+}
+
+// renderFiles 以表格形式显示文件清单
+//
+// 参数:
+//   - files: 文件清单
+//   - showVersion: 是否显示文件所在的版本ID（在所有版本中查找时）
+//   - cl: 颜色库
+//
+// 返回值:
+//   - error: 表格样式不存在时返回错误信息
+func renderFiles(files []types.BackupFile, showVersion bool, cl *colorlib.ColorLib) error {
+	t := table.NewWriter()
+	if style, ok := types.TableStyle[tableStyleFlag.Get()]; ok {
+		t.SetStyle(style)
+	} else {
+		return fmt.Errorf("表格样式不存在: %s, 可选样式: %v", tableStyleFlag.Get(), types.TableStyleList)
+	}
+	t.SetOutputMirror(os.Stdout)
+
+	header := table.Row{"路径", "大小", "修改时间", "权限", "变化类型", "内容哈希"}
+	if showVersion {
+		header = append(table.Row{"版本ID"}, header...)
+	}
+	t.AppendHeader(header)
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Name: "版本ID", Align: text.AlignLeft, WidthMaxEnforcer: text.WrapHard},
+		{Name: "路径", Align: text.AlignLeft, WidthMaxEnforcer: text.WrapHard},
+		{Name: "大小", Align: text.AlignRight, WidthMaxEnforcer: text.WrapHard},
+		{Name: "修改时间", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
+		{Name: "权限", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
+		{Name: "变化类型", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
+		{Name: "内容哈希", Align: text.AlignLeft, WidthMaxEnforcer: text.WrapHard},
+	})
+
+	var totalSize int64
+	for _, f := range files {
+		row := table.Row{
+			f.Path,                     // 路径
+			fileSizeCell(f),            // 大小
+			modTimeCell(f),             // 修改时间
+			modeCell(f),                // 权限
+			f.ChangeType,               // 变化类型
+			emptyToPlaceholder(f.Hash), // 内容哈希
+		}
+		if showVersion {
+			row = append(table.Row{f.VersionID}, row...)
+		}
+		t.AppendRow(row)
+
+		if f.ChangeType != types.ChangeTypeDeleted {
+			totalSize += f.Size
+		}
+	}
+
+	t.Render()
+	cl.Whitef("共 %d 个文件, 总大小 %s\n", len(files), utils.FormatBytes(totalSize))
+	return nil
+}
+
+// fileSizeCell 返回文件大小的显示内容（已删除的文件没有大小）
+func fileSizeCell(f types.BackupFile) string {
+	if f.ChangeType == types.ChangeTypeDeleted {
+		return "---"
+	}
+	return utils.FormatBytes(f.Size)
+}
+
+// modTimeCell 返回文件修改时间的显示内容（本地时间）
+func modTimeCell(f types.BackupFile) string {
+	if f.ModTime == 0 {
+		return "---"
+	}
+	return time.Unix(0, f.ModTime).Format("2006-01-02 15:04:05")
+}
+
+// modeCell 返回文件权限的显示内容（早于记录权限的清单没有权限信息）
+func modeCell(f types.BackupFile) string {
+	if f.Mode == 0 {
+		return "---"
+	}
+	return fs.FileMode(f.Mode).String()
+}
+
+// emptyToPlaceholder 如果字符串为空则返回占位符，否则返回原字符串
+func emptyToPlaceholder(s string) string {
+	if s == "" {
+		return "---"
+	}
+	return s
+}
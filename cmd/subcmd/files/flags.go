@@ -0,0 +1,53 @@
+// Package files 实现了 bakctl 的 files 子命令的命令行参数解析功能。
+//
+// 该文件定义了 files 命令支持的所有命令行标志和参数，包括：
+//   - 任务和版本选择选项
+//   - 在任务所有版本中查找文件的选项
+//   - 路径匹配和变化类型过滤选项
+//   - 输出格式选项
+package files
+
+import (
+	"flag"
+
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/qflag"
+	"gitee.com/MM-Q/qflag/cmd"
+)
+
+var (
+	filesCmd *qflag.Cmd // files命令
+
+	// 任务和版本选择
+	taskIDFlag      *qflag.Int64Flag  // 任务ID
+	versionIDFlag   *qflag.StringFlag // 版本ID
+	allVersionsFlag *qflag.BoolFlag   // 在任务的所有版本中查找
+
+	// 过滤选项
+	patternFlag    *qflag.StringFlag // 路径匹配模式
+	changeTypeFlag *qflag.StringFlag // 变化类型
+
+	// 输出选项
+	tableStyleFlag *qflag.EnumFlag // 表格样式
+)
+
+// InitFilesCmd 初始化files子命令
+func InitFilesCmd() *qflag.Cmd {
+	filesCmd = cmd.NewCmd("files", "fl", flag.ExitOnError)
+	filesCmd.SetDesc("查看备份版本的文件清单")
+	filesCmd.SetChinese(true)
+
+	// 任务和版本选择
+	taskIDFlag = filesCmd.Int64("", "id", 0, "指定任务ID")
+	versionIDFlag = filesCmd.String("", "vid", "", "指定备份版本ID (默认为最新的成功备份)")
+	allVersionsFlag = filesCmd.Bool("all-versions", "a", false, "在任务的所有成功备份中查找文件 (与-vid互斥), 用于确定文件包含在哪些版本中")
+
+	// 过滤选项
+	patternFlag = filesCmd.String("pattern", "p", "", "路径匹配模式, 支持通配符 * ? [...] (区分大小写), 不含 / 时匹配任意目录下的文件名")
+	changeTypeFlag = filesCmd.String("type", "t", "", "只显示指定变化类型的文件 (added, modified, unchanged, deleted)")
+
+	// 输出选项
+	tableStyleFlag = filesCmd.Enum("table-style", "ts", "ro", "表格样式 (df, bd, cb, cd, de, lt, ro, none)", types.TableStyleList)
+
+	return filesCmd
+}
@@ -0,0 +1,31 @@
+// Package keygen 的命令行参数定义和解析功能。
+//
+// 该文件定义了 keygen 子命令支持的命令行参数：
+//   - 输出参数：身份文件的保存路径
+package keygen
+
+import (
+	"flag"
+
+	"gitee.com/MM-Q/qflag"
+	"gitee.com/MM-Q/qflag/cmd"
+)
+
+var (
+	keygenCmd *qflag.Cmd // 生成密钥对命令
+
+	// 输出选项
+	outputF *qflag.StringFlag // 身份文件路径
+)
+
+// InitKeygenCmd 初始化生成密钥对命令
+func InitKeygenCmd() *qflag.Cmd {
+	keygenCmd = cmd.NewCmd("keygen", "kg", flag.ExitOnError)
+	keygenCmd.SetChinese(true)
+	keygenCmd.SetDesc("生成用于公钥加密的密钥对")
+
+	// 输出选项
+	outputF = keygenCmd.String("output", "o", "", "身份文件的保存路径 (不允许已存在; 未指定时输出到标准输出)")
+
+	return keygenCmd
+}
@@ -0,0 +1,55 @@
+// Package keygen 实现了 bakctl 的 keygen 子命令功能。
+//
+// 该包用于生成公钥加密使用的 X25519 密钥对：
+//   - 公钥（bakctl-pub-...）配置到备份任务中，执行备份的机器只需要公钥
+//   - 身份文件保存私钥，由有权恢复备份的人保管，恢复时通过 restore --identity 指定
+package keygen
+
+import (
+	"fmt"
+	"os"
+
+	"gitee.com/MM-Q/bakctl/internal/crypt"
+	"gitee.com/MM-Q/colorlib"
+)
+
+// KeygenCmdMain keygen命令的主函数
+//
+// 参数:
+//   - cl: 颜色库
+//
+// 返回值:
+//   - error: 生成或保存密钥对失败时返回错误信息
+func KeygenCmdMain(cl *colorlib.ColorLib) error {
+	identity, recipient, err := crypt.GenerateIdentity()
+	if err != nil {
+		return err
+	}
+	content := crypt.IdentityFileContent(identity, recipient)
+
+	// 未指定输出文件时直接输出身份文件内容
+	output := outputF.Get()
+	if output == "" {
+		fmt.Print(content)
+		return nil
+	}
+
+	// 身份文件只允许当前用户读写
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("创建身份文件失败: %w", err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		_ = f.Close()
+		_ = os.Remove(output)
+		return fmt.Errorf("写入身份文件失败: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("写入身份文件失败: %w", err)
+	}
+
+	cl.Greenf("已生成身份文件: %s\n", output)
+	cl.Whitef("公钥: %s\n", recipient)
+	cl.Yellow("请妥善保管身份文件, 丢失后无法恢复使用该公钥加密的备份")
+	return nil
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	DB "gitee.com/MM-Q/bakctl/internal/db"
 	"gitee.com/MM-Q/bakctl/internal/types"
@@ -80,15 +81,15 @@ func ListCmdMain(db *sqlx.DB, cl *colorlib.ColorLib) error {
 		// 添加简洁模式数据行
 		for _, task := range data {
 			t.AppendRow(table.Row{
-				task.ID,         // ID
-				task.Name,       // 任务名
-				task.BackupDir,  // 备份源目录
-				task.StorageDir, // 备份存储目录
+				task.ID,                            // ID
+				task.Name,                          // 任务名
+				strings.Join(task.Sources(), "\n"), // 备份源目录（每行一个备份源路径）
+				task.StorageDir,                    // 备份存储目录
 			})
 		}
 	} else {
 		// 完整模式：显示所有信息
-		t.AppendHeader(table.Row{"ID", "任务名", "保留数量", "保留天数", "备份源目录", "备份存储目录", "是否压缩", "包含规则", "排除规则", "最大文件大小", "最小文件大小"})
+		t.AppendHeader(table.Row{"ID", "任务名", "保留数量", "保留天数", "备份源目录", "备份存储目录", "备份模式", "存储模式", "归档格式", "压缩等级", "加密", "包含规则", "排除规则", "最大文件大小", "最小文件大小", "读取限速"})
 
 		t.SetColumnConfigs([]table.ColumnConfig{
 			{Name: "ID", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
@@ -97,11 +98,16 @@ func ListCmdMain(db *sqlx.DB, cl *colorlib.ColorLib) error {
 			{Name: "保留天数", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
 			{Name: "备份源目录", Align: text.AlignLeft, WidthMaxEnforcer: text.WrapHard},
 			{Name: "备份存储目录", Align: text.AlignLeft, WidthMaxEnforcer: text.WrapHard},
-			{Name: "是否压缩", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
+			{Name: "备份模式", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
+			{Name: "存储模式", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
+			{Name: "归档格式", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
+			{Name: "压缩等级", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
+			{Name: "加密", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
 			{Name: "包含规则", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
 			{Name: "排除规则", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
 			{Name: "最大文件大小", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
 			{Name: "最小文件大小", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
+			{Name: "读取限速", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
 		})
 
 		// 添加完整模式数据行
@@ -111,13 +117,18 @@ func ListCmdMain(db *sqlx.DB, cl *colorlib.ColorLib) error {
 				task.Name,                           // 任务名
 				task.RetainCount,                    // 保留数量
 				task.RetainDays,                     // 保留天数
-				task.BackupDir,                      // 备份源目录
+				strings.Join(task.Sources(), "\n"),  // 备份源目录（每行一个备份源路径）
 				task.StorageDir,                     // 备份存储目录
-				task.Compress,                       // 是否压缩
+				backupModeCell(task),                // 备份模式
+				task.StorageMode,                    // 存储模式
+				formatCell(task),                    // 归档格式
+				compressionCell(task),               // 压缩等级
+				encryptionCell(task),                // 加密
 				task.IncludeRules,                   // 包含规则
-				task.ExcludeRules,                   // 排除规则
+				excludeCell(task),                   // 排除规则
 				utils.FormatBytes(task.MaxFileSize), // 最大文件大小
 				utils.FormatBytes(task.MinFileSize), // 最小文件大小
+				readLimitCell(task),                 // 读取限速
 			})
 		}
 	}
@@ -127,3 +138,93 @@ func ListCmdMain(db *sqlx.DB, cl *colorlib.ColorLib) error {
 
 	return nil
 }
+
+// backupModeCell 返回任务备份模式的显示内容，增量模式一并显示全量备份间隔
+func backupModeCell(task types.BackupTask) string {
+	if task.BackupMode == types.BackupModeIncremental {
+		return fmt.Sprintf("%s\n每 %d 次全量", task.BackupMode, task.FullEveryCount())
+	}
+	return task.BackupMode
+}
+
+// readLimitCell 返回任务读取限速的显示内容
+func readLimitCell(task types.BackupTask) string {
+	if task.ReadLimit <= 0 {
+		return "不限速"
+	}
+	return utils.FormatBytes(task.ReadLimit) + "/s"
+}
+
+// excludeCell 返回任务排除规则的显示内容，启用了忽略文件、配置了文件属性过滤条件或非默认的符号链接和错误处理策略时一并显示
+func excludeCell(task types.BackupTask) string {
+	cell := task.ExcludeRules
+	if task.IgnoreFiles {
+		cell += "\n忽略文件: " + types.IgnoreFileName
+	}
+	if task.NewerThan != "" {
+		cell += "\n修改晚于: " + task.NewerThan
+	}
+	if task.OlderThan != "" {
+		cell += "\n修改早于: " + task.OlderThan
+	}
+	if fileTypes := task.FileTypeList(); len(fileTypes) > 0 {
+		cell += "\n文件类型: " + strings.Join(fileTypes, ", ")
+	}
+	if task.FileOwner != "" {
+		cell += "\n属主: " + task.FileOwner
+	}
+	if task.FileGroup != "" {
+		cell += "\n属组: " + task.FileGroup
+	}
+	if task.ExcludeHidden {
+		cell += "\n排除隐藏文件"
+	}
+	switch task.SymlinkPolicy() {
+	case types.SymlinkFollow:
+		cell += "\n跟随符号链接"
+	case types.SymlinkSkip:
+		cell += "\n跳过符号链接"
+	}
+	switch task.ErrorPolicyName() {
+	case types.ErrorPolicySkipUnreadable:
+		cell += "\n跳过无法读取的文件"
+	case types.ErrorPolicySkipChanged:
+		cell += "\n跳过无法读取的文件, 容忍被修改的文件"
+	}
+	return cell
+}
+
+// formatCell 返回任务归档格式的显示内容，配置了分卷大小或非默认的校验算法时一并显示
+func formatCell(task types.BackupTask) string {
+	cell := task.Format
+	if task.VolumeSize > 0 {
+		cell += "\n分卷: " + utils.FormatBytes(task.VolumeSize)
+	}
+	if algorithm := task.HashAlgorithmName(); algorithm != types.DefaultHashAlgorithm {
+		cell += "\n校验: " + algorithm
+	}
+	return cell
+}
+
+// compressionCell 返回任务压缩等级的显示内容，配置了不压缩的文件扩展名时一并显示
+func compressionCell(task types.BackupTask) string {
+	cell := task.CompressionName()
+	if exts := task.StoreExtList(); len(exts) > 0 {
+		cell += "\n不压缩: " + strings.Join(exts, ", ")
+	}
+	return cell
+}
+
+// encryptionCell 返回任务加密设置的显示内容
+func encryptionCell(task types.BackupTask) string {
+	switch {
+	case task.Encryption == "":
+		return "否"
+	case task.Recipients != "":
+		return fmt.Sprintf("%s\n接收者: %d 个", task.Encryption, len(task.RecipientList()))
+	case task.KeyFile != "":
+		return task.Encryption + "\n密钥文件: " + task.KeyFile
+	default:
+		return task.Encryption + "\n密码"
+	}
+}
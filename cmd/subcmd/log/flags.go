@@ -26,6 +26,7 @@ var (
 	logCmdLimit      *qflag.IntFlag    // 限制条数标志
 	logCmdSimple     *qflag.BoolFlag   // 简化显示
 	logCmdFailed     *qflag.BoolFlag   // 只显示失败的备份记录
+	logCmdJSON       *qflag.BoolFlag   // 以JSON格式输出
 )
 
 // InitLogCmd 初始化日志命令
@@ -49,6 +50,7 @@ func InitLogCmd() *qflag.Cmd {
 	logCmdLimit = logCmd.Int("limit", "l", 10, "限制显示的备份记录条数")
 	logCmdSimple = logCmd.Bool("simple", "s", false, "简化显示，只显示核心信息")
 	logCmdFailed = logCmd.Bool("failed", "fd", false, "只显示失败的备份记录")
+	logCmdJSON = logCmd.Bool("json", "j", false, "以JSON格式输出备份记录(包含完整的运行统计), 不能与--simple/-s同时使用")
 
 	return logCmd
 }
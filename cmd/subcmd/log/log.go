@@ -17,9 +17,12 @@
 package log
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	DB "gitee.com/MM-Q/bakctl/internal/db"
 	"gitee.com/MM-Q/bakctl/internal/types"
@@ -47,6 +50,147 @@ func emptyToPlaceholder(s string) string {
 	return s
 }
 
+// backupTypeLabel 返回备份记录的备份类型名称
+//
+// 参数:
+//   - record: 备份记录
+//
+// 返回值:
+//   - string: 增量备份返回 "增量"，否则返回 "全量"
+func backupTypeLabel(record types.BackupRecord) string {
+	if record.IsIncremental() {
+		return "增量"
+	}
+	return "全量"
+}
+
+// backupFilenameCell 返回备份记录的备份文件名显示内容，分卷备份一并显示分卷数量
+//
+// 参数:
+//   - record: 备份记录
+//
+// 返回值:
+//   - string: 备份文件名（为空时返回占位符）
+func backupFilenameCell(record types.BackupRecord) string {
+	if record.VolumeCount > 0 {
+		return fmt.Sprintf("%s\n(%d 个分卷)", record.BackupFilename, record.VolumeCount)
+	}
+	return emptyToPlaceholder(record.BackupFilename)
+}
+
+// runStatsCell 返回备份记录的运行统计显示内容
+//
+// 参数:
+//   - record: 备份记录
+//
+// 返回值:
+//   - string: 多行的运行统计（早于运行统计功能的记录返回占位符）
+func runStatsCell(record types.BackupRecord) string {
+	if record.StartedAt == "" {
+		return "---"
+	}
+
+	ratio := "---"
+	if record.SourceBytes > 0 {
+		ratio = fmt.Sprintf("%.1f%%", record.CompressionRatio()*100)
+	}
+
+	return fmt.Sprintf("耗时: %s\n文件: %d, 目录: %d\n读取: %s (压缩率 %s)\n跳过: %d\n主机: %s (%s)",
+		(time.Duration(record.DurationMs) * time.Millisecond).String(),
+		record.FileCount, record.DirCount,
+		utils.FormatBytes(record.SourceBytes), ratio,
+		record.SkippedCount,
+		emptyToPlaceholder(record.HostName), emptyToPlaceholder(record.Version))
+}
+
+// warningCellLimit 失败信息列中最多显示的警告条数
+const warningCellLimit = 5
+
+// failureCell 返回备份记录的失败信息显示内容，部分成功的备份显示未能备份的条目
+//
+// 参数:
+//   - record: 备份记录
+//   - warnings: 备份记录的警告
+//
+// 返回值:
+//   - string: 失败信息（没有失败信息和警告时返回占位符）
+func failureCell(record types.BackupRecord, warnings []types.BackupWarning) string {
+	if len(warnings) == 0 {
+		return emptyToPlaceholder(record.FailureMessage)
+	}
+
+	var sb strings.Builder
+	if record.FailureMessage != "" {
+		sb.WriteString(record.FailureMessage + "\n")
+	}
+	fmt.Fprintf(&sb, "%d 个条目未能备份:", len(warnings))
+	for i, w := range warnings {
+		if i == warningCellLimit {
+			fmt.Fprintf(&sb, "\n... 另有 %d 个", len(warnings)-warningCellLimit)
+			break
+		}
+		fmt.Fprintf(&sb, "\n%s: %s", w.Path, w.Message)
+	}
+	return sb.String()
+}
+
+// getBackupWarnings 查询部分成功的备份记录的警告
+//
+// 参数:
+//   - db: 数据库连接
+//   - data: 备份记录列表
+//
+// 返回值:
+//   - map[string][]types.BackupWarning: 版本ID -> 警告列表
+//   - error: 查询失败时返回错误信息
+func getBackupWarnings(db *sqlx.DB, data []types.BackupRecord) (map[string][]types.BackupWarning, error) {
+	warnings := make(map[string][]types.BackupWarning)
+	for _, record := range data {
+		if record.ResultState() != types.BackupStatePartial {
+			continue
+		}
+		list, err := DB.GetBackupWarningsByVersion(db, record.VersionID)
+		if err != nil {
+			return nil, err
+		}
+		warnings[record.VersionID] = list
+	}
+	return warnings, nil
+}
+
+// recordJSON 备份记录的JSON输出格式，在备份记录的基础上附加压缩率和警告
+type recordJSON struct {
+	types.BackupRecord
+	CompressionRatio float64               `json:"compression_ratio"`  // 备份大小与读取量的比值（没有读取量时为0）
+	Warnings         []types.BackupWarning `json:"warnings,omitempty"` // 未能备份的条目（仅部分成功的备份）
+}
+
+// printRecordsJSON 以JSON数组格式输出备份记录
+//
+// 参数:
+//   - data: 备份记录列表
+//   - warnings: 版本ID -> 警告列表
+//
+// 返回值:
+//   - error: 编码失败时返回错误信息
+func printRecordsJSON(data []types.BackupRecord, warnings map[string][]types.BackupWarning) error {
+	out := make([]recordJSON, 0, len(data))
+	for _, record := range data {
+		out = append(out, recordJSON{
+			BackupRecord:     record,
+			CompressionRatio: record.CompressionRatio(),
+			Warnings:         warnings[record.VersionID],
+		})
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return fmt.Errorf("输出JSON失败: %w", err)
+	}
+	return nil
+}
+
 // LogCmdMain 日志命令主函数
 //
 // 参数:
@@ -76,6 +220,15 @@ func LogCmdMain(db *sqlx.DB, cl *colorlib.ColorLib) error {
 	if err != nil {
 		return fmt.Errorf("查询备份记录失败: %w", err)
 	}
+	warnings, err := getBackupWarnings(db, data)
+	if err != nil {
+		return err
+	}
+
+	// JSON格式输出时没有记录也输出空数组，便于脚本处理
+	if logCmdJSON.Get() {
+		return printRecordsJSON(data, warnings)
+	}
 
 	// 提前检查是否有备份记录
 	if len(data) == 0 {
@@ -103,21 +256,23 @@ func LogCmdMain(db *sqlx.DB, cl *colorlib.ColorLib) error {
 		// 添加简洁模式数据行
 		for _, record := range data {
 			t.AppendRow(table.Row{
-				record.TaskID,    // 任务ID
-				record.TaskName,  // 任务名
-				record.VersionID, // 版本ID
-				record.Status,    // 状态
-				emptyToPlaceholder(record.FailureMessage), // 失败信息
+				record.TaskID,        // 任务ID
+				record.TaskName,      // 任务名
+				record.VersionID,     // 版本ID
+				record.ResultState(), // 状态
+				failureCell(record, warnings[record.VersionID]), // 失败信息
 			})
 		}
 	} else {
 		// 完整模式：显示所有信息
-		t.AppendHeader(table.Row{"任务ID", "任务名", "版本ID", "备份文件名", "文件大小", "存储路径", "状态", "失败信息", "校验码", "创建时间"})
+		t.AppendHeader(table.Row{"任务ID", "任务名", "版本ID", "备份类型", "存储模式", "备份文件名", "文件大小", "存储路径", "状态", "失败信息", "校验码", "创建时间", "运行统计"})
 
 		t.SetColumnConfigs([]table.ColumnConfig{
 			{Name: "任务ID", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
 			{Name: "任务名", Align: text.AlignLeft, WidthMaxEnforcer: text.WrapHard},
 			{Name: "版本ID", Align: text.AlignLeft, WidthMaxEnforcer: text.WrapHard},
+			{Name: "备份类型", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
+			{Name: "存储模式", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
 			{Name: "备份文件名", Align: text.AlignLeft, WidthMaxEnforcer: text.WrapHard},
 			{Name: "文件大小", Align: text.AlignRight, WidthMaxEnforcer: text.WrapHard},
 			{Name: "存储路径", Align: text.AlignLeft, WidthMaxEnforcer: text.WrapHard},
@@ -125,21 +280,25 @@ func LogCmdMain(db *sqlx.DB, cl *colorlib.ColorLib) error {
 			{Name: "失败信息", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
 			{Name: "校验码", Align: text.AlignLeft, WidthMaxEnforcer: text.WrapHard},
 			{Name: "创建时间", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
+			{Name: "运行统计", Align: text.AlignLeft, WidthMaxEnforcer: text.WrapHard},
 		})
 
 		// 添加完整模式数据行
 		for _, record := range data {
 			t.AppendRow(table.Row{
-				record.TaskID,    // 任务ID
-				record.TaskName,  // 任务名
-				record.VersionID, // 版本ID
-				emptyToPlaceholder(record.BackupFilename), // 备份文件名
-				utils.FormatBytes(record.BackupSize),      // 文件大小
-				record.StoragePath,                        // 存储路径
-				record.Status,                             // 状态
-				emptyToPlaceholder(record.FailureMessage), // 失败信息
-				emptyToPlaceholder(record.Checksum),       // 校验码
-				utils.ConvertUTCToLocal(record.CreatedAt), // 创建时间（转换为本地时间）
+				record.TaskID,                                   // 任务ID
+				record.TaskName,                                 // 任务名
+				record.VersionID,                                // 版本ID
+				backupTypeLabel(record),                         // 备份类型
+				emptyToPlaceholder(record.StorageMode),          // 存储模式
+				backupFilenameCell(record),                      // 备份文件名
+				utils.FormatBytes(record.BackupSize),            // 文件大小
+				record.StoragePath,                              // 存储路径
+				record.ResultState(),                            // 状态
+				failureCell(record, warnings[record.VersionID]), // 失败信息
+				emptyToPlaceholder(record.Checksum),             // 校验码
+				utils.ConvertUTCToLocal(record.CreatedAt),       // 创建时间（转换为本地时间）
+				runStatsCell(record),                            // 运行统计
 			})
 		}
 	}
@@ -160,6 +319,10 @@ func validateLogFlags() error {
 		return fmt.Errorf("--id 和 --name/-n 不能同时使用")
 	}
 
+	if logCmdJSON.Get() && logCmdSimple.Get() {
+		return fmt.Errorf("--json/-j 和 --simple/-s 不能同时使用")
+	}
+
 	// 验证limit参数
 	if logCmdLimit.Get() < 0 {
 		return fmt.Errorf("--limit/-l 必须大于等于0")
@@ -7,6 +7,7 @@
 //   - 文件过滤和排除选项
 //   - 恢复验证选项
 //   - 覆盖策略选项
+//   - 元数据恢复选项（属主、权限和时间）
 //
 // 通过这些参数，用户可以精确控制恢复操作的行为和目标。
 package restore
@@ -28,6 +29,13 @@ var (
 
 	// 可选参数
 	targetDirFlag *qflag.StringFlag // 目标目录
+	keyFileFlag   *qflag.StringFlag // 密钥文件路径
+	identityFlag  *qflag.StringFlag // 身份文件路径
+
+	// 元数据恢复
+	preserveOwnerFlag *qflag.BoolFlag // 恢复属主和属组
+	preservePermsFlag *qflag.BoolFlag // 恢复完整的权限位和扩展属性
+	preserveTimesFlag *qflag.BoolFlag // 恢复访问时间和修改时间
 )
 
 // InitRestoreCmd 初始化restore子命令
@@ -43,6 +51,13 @@ func InitRestoreCmd() *qflag.Cmd {
 
 	// 可选参数
 	targetDirFlag = restoreCmd.String("", "d", ".", "指定恢复到的目标目录 (默认为当前目录)")
+	keyFileFlag = restoreCmd.String("key-file", "kf", "", "解密备份使用的密钥文件 (默认使用任务配置的密钥文件)")
+	identityFlag = restoreCmd.String("identity", "", "", "解密公钥加密的备份使用的身份文件 (由 keygen 命令生成)")
+
+	// 元数据恢复
+	preserveOwnerFlag = restoreCmd.Bool("preserve-owner", "", false, "恢复文件的属主和属组 (仅 root 用户有效)")
+	preservePermsFlag = restoreCmd.Bool("preserve-perms", "", false, "恢复完整的权限位 (包括 setuid/setgid/粘滞位, 不受 umask 影响) 和扩展属性")
+	preserveTimesFlag = restoreCmd.Bool("preserve-times", "", false, "恢复文件的访问时间和修改时间 (包括符号链接本身)")
 
 	return restoreCmd
 }
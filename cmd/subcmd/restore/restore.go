@@ -3,9 +3,12 @@
 // 该包提供了从备份文件恢复数据的功能，支持：
 //   - 从指定的备份文件恢复数据到目标位置
 //   - 验证备份文件的完整性和有效性
-//   - 支持增量恢复和完整恢复
+//   - 支持增量恢复和完整恢复（增量备份按备份链依次回放）
 //   - 提供恢复进度显示和状态反馈
 //   - 支持恢复前的数据备份保护
+//   - 透明解密加密的备份文件，解压时边读取边解密（密码或密钥文件错误时给出明确的错误）
+//   - 逐个校验分卷备份的分卷文件，恢复时按顺序直接读取各个分卷（不需要先合并）
+//   - 按需恢复文件的属主、完整的权限位、扩展属性和访问时间，并报告元数据未能恢复的条目
 //
 // 主要功能包括：
 //   - 解压缩备份文件
@@ -18,17 +21,24 @@
 package restore
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
+	"gitee.com/MM-Q/bakctl/internal/archive"
+	"gitee.com/MM-Q/bakctl/internal/crypt"
 	DB "gitee.com/MM-Q/bakctl/internal/db"
+	"gitee.com/MM-Q/bakctl/internal/repo"
 	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/bakctl/internal/utils"
 	"gitee.com/MM-Q/colorlib"
-	"gitee.com/MM-Q/comprx"
-	"gitee.com/MM-Q/go-kit/hash"
 	"github.com/jmoiron/sqlx"
+	"github.com/schollz/progressbar/v3"
 )
 
 // validateRestoreParams 验证restore命令的参数
@@ -79,6 +89,8 @@ func RestoreCmdMain(database *sqlx.DB, cl *colorlib.ColorLib) error {
 		return validationErr
 	}
 
+	preserve := preserveOptions(cl)
+
 	// 显示基本信息
 	if latest {
 		cl.Bluef("恢复 %d 的最新备份到 %s\n", taskID, targetDir)
@@ -87,13 +99,13 @@ func RestoreCmdMain(database *sqlx.DB, cl *colorlib.ColorLib) error {
 	}
 
 	// 2. 检查指定的任务ID是否存在
-	if !DB.TaskExists(database, int64(taskID)) {
+	task, err := DB.GetTaskByID(database, int64(taskID))
+	if err != nil {
 		return fmt.Errorf("任务ID %d 不存在", taskID)
 	}
 
 	// 3. 根据参数获取备份记录
 	var record *types.BackupRecord
-	var err error
 
 	if latest {
 		// 获取最新的备份记录
@@ -109,19 +121,16 @@ func RestoreCmdMain(database *sqlx.DB, cl *colorlib.ColorLib) error {
 		}
 	}
 
-	// 4. 检查备份文件是否存在
-	if _, err := os.Stat(record.StoragePath); os.IsNotExist(err) {
-		return fmt.Errorf("备份文件不存在: %s", record.StoragePath)
+	// 4. 构建备份链（增量备份需要从全量备份开始依次回放）
+	chain, err := buildBackupChain(database, record)
+	if err != nil {
+		return err
 	}
 
-	// 5. 验证备份文件校验值
-	if record.Checksum != "" {
-		actualChecksum, err := hash.ChecksumProgress(record.StoragePath, types.HashAlgorithm)
-		if err != nil {
-			return fmt.Errorf("计算备份文件校验值失败: %w", err)
-		}
-		if actualChecksum != record.Checksum {
-			return fmt.Errorf("备份文件校验失败，文件可能已损坏或被篡改\n期望: %s\n实际: %s", record.Checksum, actualChecksum)
+	// 5. 检查备份文件是否存在并验证校验值
+	for _, rec := range chain {
+		if err := verifyBackupFile(database, rec); err != nil {
+			return err
 		}
 	}
 
@@ -131,39 +140,438 @@ func RestoreCmdMain(database *sqlx.DB, cl *colorlib.ColorLib) error {
 		return fmt.Errorf("无法获取目标目录的绝对路径: %w", err)
 	}
 
-	// 7. 执行恢复
-	if err := extractBackupFile(record.StoragePath, absTargetDir); err != nil {
+	// 7. 验证加密备份的密码、密钥文件或身份文件（解压时边读取边解密）
+	secrets, err := chainSecrets(chain, task)
+	if err != nil {
+		return err
+	}
+
+	// 8. 执行恢复（收到中断信号时停止解压并删除解密的临时文件）
+	ctx, cancel := signalContext(cl)
+	defer cancel()
+
+	var metaErrs []archive.MetadataError
+	if record.IsRepository() {
+		metaErrs, err = restoreSnapshot(record.StoragePath, absTargetDir, preserve, cl)
+	} else {
+		metaErrs, err = replayBackupChain(ctx, database, chain, secrets, absTargetDir, preserve, cl)
+	}
+	if err != nil {
 		return fmt.Errorf("恢复失败: %w", err)
 	}
 
-	// 8. 显示结果
+	// 9. 显示结果
 	duration := time.Since(startTime)
 	cl.Green("恢复完成!")
+	printSourceMapping(task.Sources(), absTargetDir, cl)
+	printMetadataErrors(metaErrs, cl)
 	cl.Whitef("耗时: %v\n", duration)
 
 	return nil
 }
 
-// extractBackupFile 解压备份文件到目标目录
+// signalContext 创建收到 SIGINT/SIGTERM 信号时取消的上下文
+//
+// 收到第一个信号后恢复默认的信号处理，再次发送信号会立即终止进程。
+//
+// 参数:
+//   - cl: 颜色库
+//
+// 返回:
+//   - context.Context: 收到信号时取消的上下文
+//   - context.CancelFunc: 释放信号监听的函数，恢复结束后调用
+func signalContext(cl *colorlib.ColorLib) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-sigCh:
+			signal.Stop(sigCh)
+			cl.Yellowf("\n收到 %v 信号, 正在停止恢复并清理临时文件 (再次发送信号可强制退出)\n", sig)
+			cancel()
+		case <-ctx.Done():
+			signal.Stop(sigCh)
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// preserveOptions 根据命令行参数确定恢复时需要重新应用的元数据
+//
+// 只有 root 用户能够修改文件的属主，其他用户指定 --preserve-owner 时给出警告并忽略。
+//
+// 参数:
+//   - cl: 颜色库
+//
+// 返回:
+//   - archive.Preserve: 需要恢复的元数据
+func preserveOptions(cl *colorlib.ColorLib) archive.Preserve {
+	preserve := archive.Preserve{
+		Owner: preserveOwnerFlag.Get(),
+		Perms: preservePermsFlag.Get(),
+		Times: preserveTimesFlag.Get(),
+	}
+	if preserve.Owner && os.Geteuid() != 0 {
+		cl.Yellow("警告: 只有 root 用户能够恢复文件的属主, 已忽略 --preserve-owner")
+		preserve.Owner = false
+	}
+	return preserve
+}
+
+// printMetadataErrors 显示元数据未能恢复的条目及原因
+//
+// 增量备份回放时同一条目可能在多个版本中恢复失败，只显示最后一次失败的原因。
+//
+// 参数:
+//   - errs: 元数据未能恢复的条目
+//   - cl: 颜色库
+func printMetadataErrors(errs []archive.MetadataError, cl *colorlib.ColorLib) {
+	if len(errs) == 0 {
+		return
+	}
+
+	last := make(map[string]int, len(errs))
+	var names []string
+	for i, e := range errs {
+		if _, ok := last[e.Name]; !ok {
+			names = append(names, e.Name)
+		}
+		last[e.Name] = i
+	}
+
+	cl.Yellowf("以下 %d 个条目的元数据未能恢复:\n", len(names))
+	for _, name := range names {
+		cl.Yellowf("  %s: %v\n", name, errs[last[name]].Err)
+	}
+}
+
+// printSourceMapping 显示多个备份源路径与恢复位置的对应关系
+//
+// 参数:
+//   - sources: 任务的备份源路径列表
+//   - targetDir: 目标目录
+//   - cl: 颜色库对象
+func printSourceMapping(sources []string, targetDir string, cl *colorlib.ColorLib) {
+	if len(sources) < 2 {
+		return
+	}
+
+	cl.White("备份源路径的恢复位置:")
+	for i, prefix := range archive.SourcePrefixes(sources) {
+		path := filepath.Join(targetDir, prefix)
+		if _, err := os.Stat(path); err != nil {
+			continue // 备份中不包含该备份源（如备份后新增的备份源路径）
+		}
+		cl.Whitef("  %s -> %s\n", sources[i], path)
+	}
+}
+
+// buildBackupChain 构建恢复指定备份记录所需的备份链
+//
+// 参数:
+//   - database: 数据库连接
+//   - record: 要恢复的备份记录
+//
+// 返回:
+//   - []types.BackupRecord: 备份链，从全量备份开始，以要恢复的备份记录结束
+//   - error: 如果备份链中的某个版本缺失则返回错误信息，否则返回nil
+func buildBackupChain(database *sqlx.DB, record *types.BackupRecord) ([]types.BackupRecord, error) {
+	chain := []types.BackupRecord{*record}
+	visited := map[string]bool{record.VersionID: true}
+
+	for chain[0].ParentVersionID != "" {
+		parentID := chain[0].ParentVersionID
+		if visited[parentID] {
+			return nil, fmt.Errorf("备份链存在循环依赖: %s", parentID)
+		}
+		visited[parentID] = true
+
+		parent, err := DB.GetBackupRecordByTaskAndVersion(database, record.TaskID, parentID)
+		if err != nil {
+			return nil, fmt.Errorf("备份链不完整, 无法恢复版本 %s: %w", record.VersionID, err)
+		}
+		chain = append([]types.BackupRecord{*parent}, chain...)
+	}
+
+	return chain, nil
+}
+
+// verifyBackupFile 检查备份文件是否存在并验证校验值
+//
+// 分卷备份逐个检查每个分卷文件，并使用分卷记录中的校验值验证。
+//
+// 参数:
+//   - database: 数据库连接
+//   - record: 备份记录
+//
+// 返回:
+//   - error: 如果备份文件不存在或校验失败则返回错误信息，否则返回nil
+func verifyBackupFile(database *sqlx.DB, record types.BackupRecord) error {
+	if record.VolumeCount <= 0 {
+		return verifyFile(record.StoragePath, record.Checksum)
+	}
+
+	volumes, err := DB.GetBackupVolumesByVersion(database, record.VersionID)
+	if err != nil {
+		return err
+	}
+	if len(volumes) != record.VolumeCount {
+		return fmt.Errorf("备份 %s 的分卷记录不完整: 应有 %d 个分卷, 实际记录 %d 个", record.VersionID, record.VolumeCount, len(volumes))
+	}
+	for _, v := range volumes {
+		if err := verifyFile(types.VolumePath(record.StoragePath, v.Seq), v.Checksum); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verifyFile 检查文件是否存在并验证校验值
 //
 // 参数:
-//   - backupPath: 备份文件的路径
+//   - path: 文件路径
+//   - checksum: 期望的校验值（为空时不校验）
+//
+// 返回:
+//   - error: 如果文件不存在或校验失败则返回错误信息，否则返回nil
+func verifyFile(path, checksum string) error {
+	// 检查备份文件是否存在
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("备份文件不存在: %s", path)
+	}
+
+	// 验证备份文件校验值
+	if checksum != "" {
+		// 使用备份记录中的哈希算法计算，早期版本的 sha1 校验码同样可以验证
+		ok, actualChecksum, err := utils.VerifyChecksum(context.Background(), path, checksum, true)
+		if err != nil {
+			return fmt.Errorf("计算备份文件校验值失败: %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("备份文件校验失败，文件可能已损坏或被篡改: %s\n期望: %s\n实际: %s", path, checksum, actualChecksum)
+		}
+	}
+
+	return nil
+}
+
+// chainSecrets 获取备份链中每个加密备份解密使用的密码、密钥文件或身份文件，并逐个验证
+//
+// 备份文件在解压时边读取边解密，解压前先读取每个加密备份的文件头验证密钥，
+// 密码或密钥文件错误时不会修改目标目录。使用相同加密方案的版本只获取一次密码。
+//
+// 参数:
+//   - chain: 备份链
+//   - task: 备份任务
+//
+// 返回:
+//   - []*crypt.Secret: 与备份链一一对应的密码、密钥文件或身份文件（未加密的版本为 nil）
+//   - error: 如果无法获取密码或密钥错误则返回错误信息，否则返回nil
+func chainSecrets(chain []types.BackupRecord, task *types.BackupTask) ([]*crypt.Secret, error) {
+	secrets := make([]*crypt.Secret, len(chain))
+	byScheme := make(map[string]*crypt.Secret)
+	for i, rec := range chain {
+		if rec.Encryption == "" {
+			continue
+		}
+
+		secret, ok := byScheme[rec.Encryption]
+		if !ok {
+			s, err := decryptSecret(rec.Encryption, task)
+			if err != nil {
+				return nil, err
+			}
+			secret = &s
+			byScheme[rec.Encryption] = secret
+		}
+		if err := checkSecret(rec.VolumePaths()[0], *secret); err != nil {
+			return nil, fmt.Errorf("解密备份文件 %s 失败: %w", rec.BackupFilename, err)
+		}
+		secrets[i] = secret
+	}
+
+	return secrets, nil
+}
+
+// checkSecret 读取加密备份文件（或第一个分卷）的文件头，验证密码、密钥文件或身份文件
+//
+// 参数:
+//   - path: 加密的备份文件路径
+//   - secret: 密码、密钥文件或身份文件
+//
+// 返回:
+//   - error: 如果密钥错误或文件头无效则返回错误信息，否则返回nil
+func checkSecret(path string, secret crypt.Secret) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("打开加密文件失败: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	_, err = crypt.NewReader(f, secret)
+	return err
+}
+
+// decryptSecret 根据备份记录的加密方案获取解密使用的密码、密钥文件或身份文件
+//
+// 参数:
+//   - scheme: 备份记录的加密方案
+//   - task: 备份任务
+//
+// 返回:
+//   - crypt.Secret: 密码、密钥文件或身份文件
+//   - error: 如果加密方案不受支持或无法获取密码则返回错误信息，否则返回nil
+func decryptSecret(scheme string, task *types.BackupTask) (crypt.Secret, error) {
+	switch scheme {
+	case crypt.SchemeKeyFile:
+		keyFile := keyFileFlag.Get()
+		if keyFile == "" {
+			keyFile = task.KeyFile
+		}
+		if keyFile == "" {
+			return crypt.Secret{}, fmt.Errorf("备份文件使用密钥文件加密, 请使用 --key-file 指定密钥文件")
+		}
+		return crypt.Secret{KeyFile: keyFile}, nil
+	case crypt.SchemeRecipients:
+		if identityFlag.Get() == "" {
+			return crypt.Secret{}, fmt.Errorf("备份文件使用公钥加密, 请使用 --identity 指定身份文件")
+		}
+		return crypt.Secret{Identity: identityFlag.Get()}, nil
+	case crypt.SchemePassphrase:
+		passphrase, err := crypt.Passphrase(false)
+		if err != nil {
+			return crypt.Secret{}, err
+		}
+		return crypt.Secret{Passphrase: passphrase}, nil
+	default:
+		return crypt.Secret{}, fmt.Errorf("不支持的加密方案: %s", scheme)
+	}
+}
+
+// replayBackupChain 依次回放备份链中的每个版本
+//
+// 第一个版本（全量备份）直接解压；之后的每个增量版本先删除该版本中记录为已删除的文件、
+// 目录和符号链接（包括条目类型已变化的旧条目），再覆盖解压该版本的归档。
+// 所有版本使用同一个解压器，目录的权限和修改时间在最后一个版本回放完成后才设置，
+// 之前版本中的只读目录不会导致之后的版本无法写入。
+//
+// 参数:
+//   - ctx: 上下文
+//   - database: 数据库连接
+//   - chain: 备份链（从全量备份开始）
+//   - secrets: 备份链中每个版本解密使用的密钥（未加密的版本为 nil）
 //   - targetDir: 目标目录的路径
+//   - preserve: 需要恢复的元数据
+//   - cl: colorlib.ColorLib 实例
 //
 // 返回:
+//   - []archive.MetadataError: 元数据未能恢复的条目
 //   - error: 如果发生错误则返回错误信息，否则返回nil
-func extractBackupFile(backupPath, targetDir string) error {
-	// 构建压缩配置
-	opts := comprx.Options{
-		CompressionLevel:      comprx.CompressionLevelDefault, // 压缩等级默认
-		OverwriteExisting:     false,                          // 覆盖已存在的文件
-		ProgressEnabled:       true,                           // 显示进度条
-		ProgressStyle:         comprx.ProgressStyleASCII,      // 进度条样式
-		DisablePathValidation: false,                          // 禁用路径验证
+func replayBackupChain(ctx context.Context, database *sqlx.DB, chain []types.BackupRecord, secrets []*crypt.Secret, targetDir string, preserve archive.Preserve, cl *colorlib.ColorLib) ([]archive.MetadataError, error) {
+	x, err := archive.NewExtractor(ctx, targetDir, preserve)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, rec := range chain {
+		if len(chain) > 1 {
+			cl.Whitef("[%d/%d] 回放版本 %s (%s)\n", i+1, len(chain), rec.VersionID, rec.BackupFilename)
+		}
+
+		if i > 0 {
+			files, err := DB.GetBackupFilesByVersion(database, rec.VersionID)
+			if err != nil {
+				return nil, err
+			}
+
+			// 删除该版本中已不存在（或条目类型已变化）的条目
+			if err := x.Remove(files); err != nil {
+				return nil, err
+			}
+		}
+
+		// 全量备份不覆盖目标目录中已有的文件，增量版本覆盖之前版本解压的文件
+		if err := extractBackupFile(x, rec, secrets[i], i > 0); err != nil {
+			return nil, err
+		}
 	}
 
+	_, metaErrs := x.Finish()
+	return metaErrs, nil
+}
+
+// restoreSnapshot 从数据块仓库恢复快照到目标目录
+//
+// 参数:
+//   - snapshotPath: 快照文件路径
+//   - targetDir: 目标目录
+//   - preserve: 需要恢复的元数据
+//   - cl: 颜色库
+//
+// 返回:
+//   - []archive.MetadataError: 元数据未能恢复的条目
+//   - error: 如果恢复失败则返回错误信息，否则返回nil
+func restoreSnapshot(snapshotPath, targetDir string, preserve archive.Preserve, cl *colorlib.ColorLib) ([]archive.MetadataError, error) {
+	r, err := repo.OpenBySnapshot(snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := repo.LoadSnapshot(snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+
+	files, metaErrs, err := r.Restore(snap, targetDir, preserve)
+	if err != nil {
+		return metaErrs, err
+	}
+
+	cl.Whitef("已从仓库快照恢复 %d 个文件\n", files)
+	return metaErrs, nil
+}
+
+// extractBackupFile 使用解压器解压备份文件
+//
+// 符号链接按原样重建，硬链接重建为指向同一文件的链接；覆盖已存在的文件时先删除再重新创建，
+// 不会写入已存在的符号链接指向的文件。
+//
+// 参数:
+//   - x: 解压器
+//   - rec: 备份记录（分卷备份按顺序读取所有分卷）
+//   - secret: 解密使用的密钥（未加密的备份为 nil）
+//   - overwrite: 是否覆盖已存在的文件
+//
+// 返回:
+//   - error: 如果发生错误则返回错误信息，否则返回nil
+func extractBackupFile(x *archive.Extractor, rec types.BackupRecord, secret *crypt.Secret, overwrite bool) error {
+	// 加密备份的文件名带有加密扩展名，根据之前的扩展名识别归档格式
+	name := strings.TrimSuffix(rec.BackupFilename, types.EncryptedExt)
+	src := archive.Source{
+		Paths:  rec.VolumePaths(),
+		Format: archive.DetectFormat(name),
+		Secret: secret,
+	}
+	if src.Format == "" {
+		return fmt.Errorf("无法识别备份文件的归档格式: %s", rec.BackupFilename)
+	}
+
+	bar := progressbar.NewOptions64(
+		-1,                                // 解压前无法得知内容的总大小
+		progressbar.OptionShowBytes(true), // 显示已处理的字节数
+		progressbar.OptionThrottle(100*time.Millisecond), // 限制刷新频率
+		progressbar.OptionClearOnFinish(),                // 完成后清除进度条
+		progressbar.OptionSetDescription(name+" 解压中"),    // 设置进度条描述
+	)
+	defer func() { _ = bar.Finish() }()
+
 	// 执行解压操作
-	if err := comprx.UnpackOptions(backupPath, targetDir, opts); err != nil {
+	if err := x.Extract(src, overwrite, bar); err != nil {
 		return fmt.Errorf("解压失败: %w", err)
 	}
 
@@ -0,0 +1,91 @@
+// Package run 实现了 bakctl 备份的取消和超时功能。
+//
+// run 命令执行期间收到 SIGINT/SIGTERM 信号，或者单个任务的执行时间超过任务配置的
+// 超时时间（timeout）时，会通过上下文中止正在进行的钩子、打包和哈希计算：
+//   - 删除未完成的备份文件
+//   - 写入一条状态为 cancelled 或 timeout 的备份记录
+//   - 收到信号后不再开始执行剩余的任务
+//
+// 收到第一个信号后恢复默认的信号处理，再次发送信号会立即终止进程，
+// 遗留的不完整文件在下次执行 run 命令时清理。
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/colorlib"
+)
+
+// errTaskCancelled 收到中断信号后未开始执行的任务
+var errTaskCancelled = errors.New("已取消, 任务未执行")
+
+// signalContext 创建收到 SIGINT/SIGTERM 信号时取消的上下文
+//
+// 参数：
+//   - cl：颜色库对象
+//
+// 返回值：
+//   - context.Context：收到信号时取消的上下文
+//   - context.CancelFunc：释放信号监听的函数，执行结束后调用
+func signalContext(cl *colorlib.ColorLib) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-sigCh:
+			signal.Stop(sigCh) // 恢复默认的信号处理，再次发送信号时立即终止进程
+			cl.Yellowf("\n收到 %v 信号, 正在取消备份并清理未完成的文件 (再次发送信号可强制退出)\n", sig)
+			cancel()
+		case <-ctx.Done():
+			signal.Stop(sigCh)
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// taskContext 创建单个任务的上下文，任务配置了超时时间时超时后自动取消
+//
+// 参数：
+//   - ctx：父上下文
+//   - task：要执行的备份任务
+//
+// 返回值：
+//   - context.Context：任务的上下文
+//   - context.CancelFunc：释放上下文的函数
+func taskContext(ctx context.Context, task types.BackupTask) (context.Context, context.CancelFunc) {
+	if task.Timeout > 0 {
+		return context.WithTimeout(ctx, time.Duration(task.Timeout)*time.Second)
+	}
+	return context.WithCancel(ctx)
+}
+
+// abortState 判断备份是否因收到中断信号或超时而中止
+//
+// 参数：
+//   - ctx：run 命令的上下文（收到信号时取消）
+//   - taskCtx：任务的上下文（超时时取消）
+//   - task：要执行的备份任务
+//
+// 返回值：
+//   - string：执行状态（cancelled/timeout，未中止时为空）
+//   - string：失败信息
+func abortState(ctx, taskCtx context.Context, task types.BackupTask) (string, string) {
+	switch {
+	case ctx.Err() != nil:
+		return types.BackupStateCancelled, "备份已取消 (收到中断信号)"
+	case errors.Is(taskCtx.Err(), context.DeadlineExceeded):
+		return types.BackupStateTimeout, fmt.Sprintf("备份超时 (超过 %d 秒), 已中止", task.Timeout)
+	}
+	return "", ""
+}
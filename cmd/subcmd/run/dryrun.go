@@ -0,0 +1,119 @@
+// Package run 实现了 bakctl 的备份预览功能。
+//
+// run --dry-run 按照任务的包含/排除规则和文件大小限制遍历备份源目录，
+// 列出将要备份的文件、被排除的条目及其原因，并给出文件数量和预计大小。
+// 预览不会执行钩子、不会生成备份文件，也不会写入备份记录。
+package run
+
+import (
+	"fmt"
+
+	"gitee.com/MM-Q/bakctl/internal/archive"
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/bakctl/internal/utils"
+	"gitee.com/MM-Q/colorlib"
+)
+
+// previewTasks 预览多个任务的文件选择结果
+//
+// 参数：
+//   - tasks：要预览的备份任务切片
+//   - cl：颜色库对象
+//
+// 返回值：
+//   - error：如果有任务预览失败，则返回非 nil 错误信息
+func previewTasks(tasks []types.BackupTask, cl *colorlib.ColorLib) error {
+	failureCount := 0
+	for i, task := range tasks {
+		fmt.Println()
+		cl.Bluef("[%d/%d] 预览任务: %s (ID: %d)\n", i+1, len(tasks), task.Name, task.ID)
+		if err := previewTask(task, cl); err != nil {
+			cl.Redf("预览失败: %v\n", err)
+			failureCount++
+		}
+	}
+
+	fmt.Println()
+	cl.Yellow("预览模式: 未执行钩子, 未生成备份文件和备份记录")
+	if failureCount > 0 {
+		return fmt.Errorf("有 %d 个任务预览失败", failureCount)
+	}
+	return nil
+}
+
+// previewTask 预览单个任务的文件选择结果
+//
+// 参数：
+//   - task：要预览的备份任务
+//   - cl：颜色库对象
+//
+// 返回值：
+//   - error：如果源目录无效或遍历失败，则返回非 nil 错误信息
+func previewTask(task types.BackupTask, cl *colorlib.ColorLib) error {
+	if err := validateSources(task.Sources()); err != nil {
+		return err
+	}
+
+	filters, err := buildFilters(task)
+	if err != nil {
+		return err
+	}
+
+	tol := archive.NewTolerance(task.ErrorPolicyName())
+	entries, skipped, err := archive.CollectSourcesWithSkipped(task.Sources(), filters, task.IgnoreFile(), task.SymlinkPolicy(), tol)
+	if err != nil {
+		return err
+	}
+
+	// 1. 将要备份的文件
+	var files, dirs int
+	var totalSize int64
+	cl.Green("将要备份的文件:")
+	for _, e := range entries {
+		if e.Info.IsDir() {
+			dirs++
+			continue
+		}
+		files++
+		totalSize += e.Info.Size()
+		cl.Whitef("  + %s (%s)\n", e.Name, utils.FormatBytes(e.Info.Size()))
+	}
+	if files == 0 {
+		cl.White("  (无)")
+	}
+
+	// 2. 被排除的条目
+	cl.Yellow("被排除的条目:")
+	for _, s := range skipped {
+		name := s.Name
+		if s.Info.IsDir() {
+			name += "/ (整个目录)"
+		}
+		if s.Warn {
+			cl.Yellowf("  ! %s: %s\n", name, s.Reason) // 无法备份的条目需要引起注意
+			continue
+		}
+		cl.Whitef("  - %s: %s\n", name, s.Reason)
+	}
+	for _, w := range tol.Warnings() {
+		cl.Yellowf("  ! %s: %v (错误处理策略: %s)\n", w.Name, w.Err, task.ErrorPolicyName())
+	}
+	if len(skipped) == 0 && len(tol.Warnings()) == 0 {
+		cl.White("  (无)")
+	}
+
+	// 3. 统计信息
+	cl.Bluef("统计: 文件 %d 个, 目录 %d 个, 排除 %d 个条目\n", files, dirs, len(skipped)+len(tol.Warnings()))
+	estimate := fmt.Sprintf("预计备份大小: %s", utils.FormatBytes(totalSize))
+	switch {
+	case task.StorageMode == types.StorageModeRepository:
+		estimate += " (未去重, 仓库中已存在的数据块不会重复占用空间)"
+	case task.BackupMode == types.BackupModeIncremental:
+		estimate += " (全量大小, 增量备份只打包新增或修改的文件)"
+	case task.Compress:
+		estimate += " (未压缩, 开启压缩后实际大小会更小)"
+	}
+	cl.Blue(estimate)
+
+	return nil
+}
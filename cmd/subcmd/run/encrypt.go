@@ -0,0 +1,56 @@
+// Package run 实现了 bakctl 的备份文件加密功能。
+//
+// 任务启用加密后，归档数据在写入存储目录前经过 AES-256-GCM 分块流式加密，
+// 存储目录中只保存加密后的 .enc 文件，未加密的归档数据不会落盘：
+//   - 配置了接收者公钥的任务加密给这些公钥，执行备份的机器不持有任何解密所需的秘密
+//   - 指定了密钥文件的任务使用密钥文件派生密钥
+//   - 否则使用密码派生密钥，密码通过 BAKCTL_PASSPHRASE 环境变量提供或在终端中输入
+//
+// 备份记录的校验值针对加密后的文件计算，恢复时先校验再解密。
+package run
+
+import (
+	"gitee.com/MM-Q/bakctl/internal/crypt"
+	"gitee.com/MM-Q/bakctl/internal/types"
+)
+
+// preparePassphrase 在执行任务前获取加密密码
+//
+// 并发执行任务时各任务的输出会交错，因此需要输入密码时在开始执行前统一提示一次。
+// 获取失败时不中止执行，未加密的任务照常执行，加密的任务在执行时报告错误。
+//
+// 参数：
+//   - tasks：要执行的备份任务
+func preparePassphrase(tasks []types.BackupTask) {
+	for _, task := range tasks {
+		if task.Encryption != "" && task.KeyFile == "" && len(task.RecipientList()) == 0 {
+			_, _ = crypt.Passphrase(true)
+			return
+		}
+	}
+}
+
+// encryptionKey 生成任务本次备份使用的加密密钥
+//
+// 参数：
+//   - task：要执行的备份任务
+//
+// 返回值：
+//   - *crypt.Key：加密密钥（任务未启用加密时为 nil）
+//   - error：如果无法获取密码或读取密钥文件失败，则返回非 nil 错误信息
+func encryptionKey(task types.BackupTask) (*crypt.Key, error) {
+	if task.Encryption == "" {
+		return nil, nil
+	}
+
+	secret := crypt.Secret{KeyFile: task.KeyFile, Recipients: task.RecipientList()}
+	if task.KeyFile == "" && len(secret.Recipients) == 0 {
+		passphrase, err := crypt.Passphrase(true)
+		if err != nil {
+			return nil, err
+		}
+		secret.Passphrase = passphrase
+	}
+
+	return crypt.NewKey(secret)
+}
@@ -3,6 +3,9 @@
 // 该文件定义了 run 命令支持的所有命令行标志和参数，包括：
 //   - 任务ID选择选项
 //   - 并发执行控制选项
+//   - 预览模式选项
+//   - 任务锁等待选项
+//   - 读取限速和低优先级选项
 //   - 输出详细程度选项
 //   - 强制执行选项
 //   - 跳过清理选项
@@ -25,6 +28,16 @@ var (
 	taskIDFlag   *qflag.Int64Flag      // -id: 指定任务ID
 	taskIDsFlag  *qflag.Int64SliceFlag // -ids: 指定多个任务ID
 	allTasksFlag *qflag.BoolFlag       // -all: 运行所有任务
+
+	// 执行控制参数
+	jobsFlag   *qflag.IntFlag  // -j/--jobs: 并发执行的任务数
+	dryRunFlag *qflag.BoolFlag // --dry-run: 只预览文件选择结果, 不执行备份
+	waitFlag   *qflag.BoolFlag // --wait: 任务正在被其他进程执行时等待其结束
+	noWaitFlag *qflag.BoolFlag // --no-wait: 任务正在被其他进程执行时直接报错（默认）
+
+	// 资源限制参数
+	readLimitFlag   *qflag.SizeFlag // -rl/--read-limit: 所有任务合计每秒读取的最大字节数
+	lowPriorityFlag *qflag.BoolFlag // -lp/--low-priority: 降低进程的 CPU 和 I/O 调度优先级
 )
 
 // InitRunCmd 初始化run子命令
@@ -38,5 +51,15 @@ func InitRunCmd() *qflag.Cmd {
 	taskIDsFlag = runCmd.Int64Slice("", "ids", []int64{}, "指定多个任务ID进行批量运行")
 	allTasksFlag = runCmd.Bool("", "all", false, "运行所有任务")
 
+	// 执行控制参数
+	jobsFlag = runCmd.Int("jobs", "j", 1, "并发执行的任务数 (默认1, 即按顺序逐个执行)")
+	dryRunFlag = runCmd.Bool("dry-run", "", false, "只预览将要备份和被排除的文件, 不生成备份文件和备份记录")
+	waitFlag = runCmd.Bool("wait", "", false, "任务正在被其他进程执行时, 等待其执行结束")
+	noWaitFlag = runCmd.Bool("no-wait", "", false, "任务正在被其他进程执行时, 跳过该任务并报错 (默认)")
+
+	// 资源限制参数
+	readLimitFlag = runCmd.Size("read-limit", "rl", 0, "全局读取限速, 如 50MB, 所有任务合计每秒读取的最大字节数 (0表示不限速, 与任务自身的限速同时生效)")
+	lowPriorityFlag = runCmd.Bool("low-priority", "lp", false, "降低进程的 CPU 和 I/O 调度优先级 (仅 Linux)")
+
 	return runCmd
 }
@@ -0,0 +1,151 @@
+// Package run 实现了 bakctl 的备份钩子功能。
+//
+// 每个任务可以配置三个钩子命令：
+//   - pre_hook: 打包前执行（如停止服务、导出数据库），执行失败时中止本次备份
+//   - post_hook: 备份成功后执行（如重新启动服务、上传备份文件）
+//   - on_failure_hook: 备份失败时执行（如发送告警）
+//
+// 钩子命令通过系统 shell 执行，超过任务配置的超时时间，或者备份被取消、超时后会被终止
+// （Unix 上终止整个进程组，包括钩子启动的子进程）。
+// 执行时通过环境变量向钩子传递任务和备份信息，详见 hookEnv。
+package run
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/colorlib"
+)
+
+// 钩子名称
+const (
+	hookPre       = "前置钩子" // 备份前执行
+	hookPost      = "后置钩子" // 备份成功后执行
+	hookOnFailure = "失败钩子" // 备份失败时执行
+)
+
+// 传递给钩子的备份状态
+const (
+	hookStatusPending = "pending" // 尚未开始打包（前置钩子）
+	hookStatusSuccess = "success" // 备份成功
+	hookStatusPartial = "partial" // 备份成功，但有条目按错误处理策略被跳过
+	hookStatusFailed  = "failed"  // 备份失败（失败钩子中为备份记录的执行状态: failed/cancelled/timeout）
+)
+
+// hookOutputLimit 错误信息中保留的钩子输出长度上限（字节）
+const hookOutputLimit = 512
+
+// runHook 执行钩子命令
+//
+// 参数：
+//   - ctx：上下文，被取消或超时后终止钩子命令
+//   - task：要执行的备份任务
+//   - name：钩子名称
+//   - command：钩子命令（为空时不执行）
+//   - result：备份执行结果
+//   - status：传递给钩子的备份状态
+//   - errMsg：传递给钩子的失败信息
+//   - cl：颜色库对象
+//
+// 返回值：
+//   - error：钩子执行失败、超时、被取消或退出码非0时返回错误信息
+func runHook(parent context.Context, task types.BackupTask, name, command string, result *types.BackupResult, status, errMsg string, cl *colorlib.ColorLib) error {
+	if strings.TrimSpace(command) == "" {
+		return nil
+	}
+
+	timeout := task.HookTimeout
+	if timeout <= 0 {
+		timeout = types.DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(parent, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	cmd := shellCommand(ctx, command)
+	killProcessGroupOnCancel(cmd)
+	cmd.Env = append(os.Environ(), hookEnv(task, result, status, errMsg)...)
+	cmd.WaitDelay = time.Second // 超时后不再等待仍占用输出管道的子进程
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	cl.Whitef("[%s] 执行%s: %s\n", task.Name, name, command)
+	err := cmd.Run()
+
+	out := strings.TrimSpace(output.String())
+	if out != "" {
+		cl.Whitef("[%s] %s输出:\n%s\n", task.Name, name, out)
+	}
+
+	if err == nil {
+		return nil
+	}
+	if parentErr := parent.Err(); parentErr != nil {
+		return fmt.Errorf("%s被终止: %w", name, parentErr)
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s执行超时 (%d秒)", name, timeout)
+	}
+	if out != "" {
+		return fmt.Errorf("%s执行失败: %w: %s", name, err, tailString(out, hookOutputLimit))
+	}
+	return fmt.Errorf("%s执行失败: %w", name, err)
+}
+
+// shellCommand 创建通过系统 shell 执行命令的 exec.Cmd
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// hookEnv 返回传递给钩子的环境变量
+//
+//   - BAKCTL_TASK_ID: 任务ID
+//   - BAKCTL_TASK_NAME: 任务名称
+//   - BAKCTL_BACKUP_DIR: 备份源目录（多个备份源路径时为第一个）
+//   - BAKCTL_BACKUP_SOURCES: 全部备份源路径（以系统路径列表分隔符分隔）
+//   - BAKCTL_STORAGE_DIR: 备份存储目录
+//   - BAKCTL_VERSION_ID: 本次备份的版本ID
+//   - BAKCTL_BACKUP_PATH: 本次备份的文件路径
+//   - BAKCTL_STATUS: 备份状态（pending/success/partial/failed/cancelled/timeout）
+//   - BAKCTL_ERROR: 失败信息（仅失败钩子）
+func hookEnv(task types.BackupTask, result *types.BackupResult, status, errMsg string) []string {
+	return []string{
+		"BAKCTL_TASK_ID=" + strconv.FormatInt(task.ID, 10),
+		"BAKCTL_TASK_NAME=" + task.Name,
+		"BAKCTL_BACKUP_DIR=" + task.BackupDir,
+		"BAKCTL_BACKUP_SOURCES=" + strings.Join(task.Sources(), string(os.PathListSeparator)),
+		"BAKCTL_STORAGE_DIR=" + task.StorageDir,
+		"BAKCTL_VERSION_ID=" + result.VersionID,
+		"BAKCTL_BACKUP_PATH=" + result.BackupPath,
+		"BAKCTL_STATUS=" + status,
+		"BAKCTL_ERROR=" + errMsg,
+	}
+}
+
+// tailString 返回字符串末尾最多 n 个字节（按完整字符截断）
+func tailString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[len(s)-n:]
+	// 跳过被截断的多字节字符
+	i := 0
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return "..." + s[i:]
+}
@@ -0,0 +1,8 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package run
+
+import "os/exec"
+
+// killProcessGroupOnCancel 当前平台不支持进程组，超时或被取消时只终止 shell 进程
+func killProcessGroupOnCancel(cmd *exec.Cmd) {}
@@ -0,0 +1,19 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package run
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroupOnCancel 使钩子命令在独立的进程组中运行，超时或被取消时终止整个进程组
+//
+// 默认只会终止 sh 进程本身，钩子启动的子进程（如 sleep、后台命令）会继续运行。
+func killProcessGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// 进程组ID与 sh 进程的PID相同，负数表示向整个进程组发送信号
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,230 @@
+// Package run 实现了 bakctl 的增量备份功能。
+//
+// 增量模式的任务在每次备份时都会生成一份文件清单（路径、大小、修改时间、内容哈希），
+// 并与上一个版本的清单对比：
+//   - 新增或修改（大小、修改时间、权限或状态变化时间变化）的文件被打包到本次归档中
+//   - 未变化的文件只记录在清单中，内容哈希沿用上一个版本
+//   - 目录和符号链接每次都写入归档，同样记录在清单中（权限中包含条目类型）
+//   - 上一个版本存在而本次不存在的文件、目录和符号链接被记录为已删除
+//   - 条目类型变化（如文件变为目录）时，上一个版本的条目记录为已删除，本次的条目按新增处理
+//
+// 没有可用的上一个版本时（首次备份、上一个版本的归档已丢失等）执行全量打包，
+// 作为后续增量备份链的起点。恢复时需要按顺序回放整条备份链。
+//
+// 备份链的长度达到任务的 full_every 后执行一次全量打包，开始新的备份链，
+// 旧的备份链不再被新的备份依赖，清理历史备份时按保留策略整条删除。
+package run
+
+import (
+	"context"
+	"io/fs"
+	"os"
+
+	"gitee.com/MM-Q/bakctl/internal/archive"
+	"gitee.com/MM-Q/bakctl/internal/cleanup"
+	"gitee.com/MM-Q/bakctl/internal/crypt"
+	DB "gitee.com/MM-Q/bakctl/internal/db"
+	"gitee.com/MM-Q/bakctl/internal/filter"
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/colorlib"
+	"gitee.com/MM-Q/comprx"
+	"github.com/jmoiron/sqlx"
+)
+
+// packIncremental 以增量模式打包源目录
+//
+// 参数：
+//   - ctx：上下文，被取消或超时后中止打包
+//   - db：数据库连接对象
+//   - task：要执行的备份任务
+//   - result：备份执行结果（写入上一个版本ID和文件清单）
+//   - filters：过滤器
+//   - level：压缩等级
+//   - key：加密密钥（为 nil 时不加密）
+//   - tol：错误处理策略（为 nil 时任何条目出错都中止打包）
+//   - cl：颜色库对象
+//
+// 返回值：
+//   - error：如果打包过程中发生错误，则返回非 nil 错误信息
+func packIncremental(ctx context.Context, db *sqlx.DB, task types.BackupTask, result *types.BackupResult, filters *filter.Filter, level comprx.CompressionLevel, key *crypt.Key, tol *archive.Tolerance, cl *colorlib.ColorLib) error {
+	// 1. 收集源目录中的条目
+	entries, err := collectEntries(task, filters, result, tol)
+	if err != nil {
+		return err
+	}
+
+	// 2. 查找上一个版本的文件清单（备份链已达到最大长度时开始新的备份链）
+	parentVersion, parentFiles := findParentBackup(db, task)
+	chainFull := false
+	if parentVersion != "" && backupChainLength(db, task, parentVersion) >= task.FullEveryCount() {
+		parentVersion, parentFiles = "", nil
+		chainFull = true
+	}
+
+	// 3. 对比文件清单，确定需要打包的条目和已删除的条目
+	packEntries, files := archive.DiffManifest(entries, parentFiles)
+
+	// 4. 写入归档，同时计算新增和修改文件的哈希（增量备份只从备份源读取新增和修改的文件）
+	read := &byteCounter{}
+	hashes, err := archive.Write(ctx, task.Format, result.TempPath, task.VolumeSize, packEntries, level, task.StoreExtList(), key, tol, read)
+	if err != nil {
+		return err
+	}
+	result.SourceBytes = read.n
+
+	// 写入时被跳过的文件不在本次归档中，从清单中移除，下次备份时按新增文件打包
+	kept := files[:0]
+	counts := make(map[string]int)
+	for _, f := range files {
+		if f.ChangeType != types.ChangeTypeDeleted {
+			if tol.Skipped(f.Path) {
+				continue
+			}
+			if sum, ok := hashes[f.Path]; ok {
+				f.Hash = sum
+			}
+		}
+		if fs.FileMode(f.Mode).IsRegular() {
+			counts[f.ChangeType]++
+		}
+		kept = append(kept, f)
+	}
+	files = kept
+
+	result.ParentVersionID = parentVersion
+	result.Files = files
+
+	switch {
+	case chainFull:
+		cl.Whitef("[%s] 增量备份链已达到 %d 个版本, 已执行全量打包并开始新的备份链: %d 个文件\n", task.Name, task.FullEveryCount(), counts[types.ChangeTypeAdded])
+	case parentVersion == "":
+		cl.Whitef("[%s] 没有可用的上一个版本, 已执行全量打包: %d 个文件\n", task.Name, counts[types.ChangeTypeAdded])
+	default:
+		cl.Whitef("[%s] 增量备份 (基于版本 %s): 新增 %d, 修改 %d, 删除 %d, 未变化 %d\n",
+			task.Name, parentVersion,
+			counts[types.ChangeTypeAdded], counts[types.ChangeTypeModified],
+			counts[types.ChangeTypeDeleted], counts[types.ChangeTypeUnchanged])
+	}
+
+	return nil
+}
+
+// findParentBackup 查找增量备份所依赖的上一个版本
+//
+// 上一个版本必须是该任务最新的成功备份，且归档文件存在、记录了文件清单；
+// 否则返回空版本ID，表示需要执行全量打包。
+//
+// 参数：
+//   - db：数据库连接对象
+//   - task：要执行的备份任务
+//
+// 返回值：
+//   - string：上一个版本的版本ID（没有可用版本时为空）
+//   - map[string]types.BackupFile：上一个版本中仍然存在的文件，键为归档内路径
+func findParentBackup(db *sqlx.DB, task types.BackupTask) (string, map[string]types.BackupFile) {
+	record, err := DB.GetLatestBackupRecordByTask(db, task.ID)
+	if err != nil {
+		return "", nil
+	}
+
+	// 仓库快照不是归档文件，不能作为增量备份链的一部分
+	if record.StorageMode == types.StorageModeRepository {
+		return "", nil
+	}
+
+	// 上一个版本的归档已丢失，无法作为增量备份的基础
+	for _, path := range record.VolumePaths() {
+		if _, err := os.Stat(path); err != nil {
+			return "", nil
+		}
+	}
+
+	files, err := DB.GetBackupFilesByVersion(db, record.VersionID)
+	if err != nil || len(files) == 0 {
+		return "", nil
+	}
+
+	parentFiles := make(map[string]types.BackupFile, len(files))
+	for _, f := range files {
+		if f.ChangeType == types.ChangeTypeDeleted {
+			continue
+		}
+		parentFiles[f.Path] = f
+	}
+
+	return record.VersionID, parentFiles
+}
+
+// backupChainLength 返回以指定版本结尾的备份链的长度（包括作为起点的全量备份）
+//
+// 参数：
+//   - db：数据库连接对象
+//   - task：要执行的备份任务
+//   - versionID：备份链中最新的版本ID
+//
+// 返回值：
+//   - int：备份链中的版本数（查询失败时为0）
+func backupChainLength(db *sqlx.DB, task types.BackupTask, versionID string) int {
+	records, err := DB.GetBackupRecordsByTaskID(db, task.ID)
+	if err != nil {
+		return 0
+	}
+
+	parentOf := make(map[string]string, len(records)) // 版本ID -> 上一个版本ID
+	for _, rec := range records {
+		if rec.Status {
+			parentOf[rec.VersionID] = rec.ParentVersionID
+		}
+	}
+
+	length := 0
+	for version := versionID; version != ""; version = parentOf[version] {
+		if _, ok := parentOf[version]; !ok || length > len(records) {
+			break // 上游版本的记录已被删除或记录中存在循环
+		}
+		length++
+	}
+	return length
+}
+
+// buildDependencyResolver 构建备份文件依赖解析函数，用于在清理历史备份时保留增量备份链
+//
+// 参数：
+//   - db：数据库连接对象
+//   - task：要执行的备份任务
+//   - result：本次备份的执行结果（尚未写入数据库）
+//
+// 返回值：
+//   - cleanup.DependencyResolver：依赖解析函数
+//   - error：如果查询备份记录失败，则返回非 nil 错误信息
+func buildDependencyResolver(db *sqlx.DB, task types.BackupTask, result *types.BackupResult) (cleanup.DependencyResolver, error) {
+	records, err := DB.GetBackupRecordsByTaskID(db, task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[string]types.BackupRecord, len(records)) // 版本ID -> 备份记录
+	parentOf := make(map[string]string, len(records)+1)            // 备份文件路径 -> 上一个版本ID
+	for _, rec := range records {
+		if !rec.Status {
+			continue
+		}
+		byVersion[rec.VersionID] = rec
+		parentOf[rec.StoragePath] = rec.ParentVersionID
+	}
+	parentOf[result.BackupPath] = result.ParentVersionID
+
+	return func(filePath string) []string {
+		var deps []string
+		parent := parentOf[filePath]
+		for parent != "" {
+			rec, ok := byVersion[parent]
+			if !ok {
+				break
+			}
+			deps = append(deps, rec.StoragePath)
+			parent = rec.ParentVersionID
+		}
+		return deps
+	}, nil
+}
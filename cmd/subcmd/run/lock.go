@@ -0,0 +1,48 @@
+// Package run 实现了 bakctl 备份任务的跨进程互斥。
+//
+// 执行任务前先获取任务锁，避免同一个任务被多个进程同时执行（例如定时任务触发的
+// run -all 与手动执行的 run -id 重叠）时，两个进程写入同一个存储目录，
+// 一方的清理操作删除另一方正在校验的备份文件。
+//
+// 任务正在被其他进程执行时：
+//   - 默认（--no-wait）跳过该任务并报错，错误信息中给出持有锁的进程
+//   - 指定 --wait 时等待其执行结束后再执行
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gitee.com/MM-Q/bakctl/internal/lock"
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/colorlib"
+)
+
+// acquireTaskLock 获取任务锁
+//
+// 参数：
+//   - ctx：上下文，等待锁时收到中断信号则停止等待
+//   - task：要执行的备份任务
+//   - cl：颜色库对象
+//
+// 返回值：
+//   - *lock.Lock：获取到的任务锁
+//   - error：任务正在被其他进程执行（未指定 --wait）或获取锁失败时返回错误信息
+func acquireTaskLock(ctx context.Context, task types.BackupTask, cl *colorlib.ColorLib) (*lock.Lock, error) {
+	path := lock.TaskPath(types.DataDirPath, task.ID)
+	what := fmt.Sprintf("任务 '%s' (ID: %d) ", task.Name, task.ID)
+
+	if waitFlag.Get() {
+		return lock.Acquire(ctx, path, what, func(h lock.Holder) {
+			cl.Yellowf("[%s] 任务正在被其他进程执行: %s, 等待其结束...\n", task.Name, h)
+		})
+	}
+
+	l, err := lock.TryAcquire(path, what)
+	var held *lock.HeldError
+	if errors.As(err, &held) {
+		return nil, fmt.Errorf("%w (可使用 --wait 等待其执行结束)", err)
+	}
+	return l, err
+}
@@ -0,0 +1,69 @@
+// Package run 实现了 bakctl 的文件清单功能。
+//
+// 每次成功的备份都会在 backup_files 表中记录一份文件清单（路径、大小、修改时间、权限、内容哈希），
+// 无需解压归档即可通过 files 命令查询某个文件包含在哪些版本中：
+//   - 全量备份和仓库备份的清单包含所有普通文件，变化类型均为新增
+//   - 增量备份的清单由增量备份功能生成，记录相对上一个版本的变化类型，
+//     同时包含目录和符号链接，回放备份链时据此删除已不存在的条目
+package run
+
+import (
+	"gitee.com/MM-Q/bakctl/internal/archive"
+	"gitee.com/MM-Q/bakctl/internal/repo"
+	"gitee.com/MM-Q/bakctl/internal/types"
+)
+
+// archiveManifest 根据写入归档的条目生成文件清单
+//
+// 参数：
+//   - entries：写入归档的条目
+//   - hashes：写入归档时计算的文件内容哈希，键为归档内路径
+//
+// 返回值：
+//   - []types.BackupFile：文件清单（只包含普通文件）
+func archiveManifest(entries []archive.Entry, hashes map[string]string) []types.BackupFile {
+	files := make([]types.BackupFile, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsRegular() {
+			continue
+		}
+		file := types.BackupFile{
+			Path:       e.Name,                       // 归档内路径
+			Size:       e.Info.Size(),                // 文件大小
+			ModTime:    e.Info.ModTime().UnixNano(),  // 修改时间
+			Mode:       uint32(e.Info.Mode().Perm()), // 文件权限
+			Hash:       hashes[e.Name],               // 内容哈希
+			ChangeType: types.ChangeTypeAdded,        // 全量备份中的文件均为新增
+		}
+		if ctime, ok := archive.ChangeTime(e.Info); ok {
+			file.ChangeTime = ctime.UnixNano() // 状态变化时间
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// snapshotManifest 根据仓库快照生成文件清单
+//
+// 参数：
+//   - snap：仓库快照
+//
+// 返回值：
+//   - []types.BackupFile：文件清单（只包含普通文件和硬链接）
+func snapshotManifest(snap *repo.Snapshot) []types.BackupFile {
+	files := make([]types.BackupFile, 0, len(snap.Entries))
+	for _, e := range snap.Entries {
+		if e.Type != repo.EntryTypeFile && e.Type != repo.EntryTypeHardlink {
+			continue
+		}
+		files = append(files, types.BackupFile{
+			Path:       e.Path,                // 快照内路径
+			Size:       e.Size,                // 文件大小
+			ModTime:    e.ModTime,             // 修改时间
+			Mode:       e.Mode,                // 文件权限
+			Hash:       e.Hash,                // 内容哈希
+			ChangeType: types.ChangeTypeAdded, // 快照中的文件均为新增
+		})
+	}
+	return files
+}
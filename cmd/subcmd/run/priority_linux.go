@@ -0,0 +1,53 @@
+//go:build linux
+
+package run
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// 低优先级模式使用的调度优先级
+const (
+	lowNice           = 19 // CPU 调度优先级（nice 值，最低）
+	ioprioWhoProcess  = 1  // ioprio_set 的 IOPRIO_WHO_PROCESS
+	ioprioClassShift  = 13 // I/O 调度类别在 ioprio 中的偏移
+	ioprioClassBE     = 2  // I/O 调度类别 best-effort
+	lowIOPrioLevel    = 7  // best-effort 类别中的最低优先级
+	lowPriorityDetail = "nice 19, I/O best-effort 7"
+)
+
+// lowerPriority 降低当前进程的 CPU 和 I/O 调度优先级
+//
+// Linux 的调度优先级按线程设置，因此逐个设置进程当前的所有线程，
+// 之后创建的线程和子进程（如钩子命令）继承降低后的优先级。
+//
+// 返回值：
+//   - error：如果设置失败，则返回错误信息
+func lowerPriority() error {
+	tids := []int{0} // 无法读取线程列表时只设置当前线程
+	if entries, err := os.ReadDir("/proc/self/task"); err == nil {
+		tids = tids[:0]
+		for _, e := range entries {
+			if tid, err := strconv.Atoi(e.Name()); err == nil {
+				tids = append(tids, tid)
+			}
+		}
+	}
+
+	ioprio := ioprioClassBE<<ioprioClassShift | lowIOPrioLevel
+	for _, tid := range tids {
+		// 线程可能在读取列表后已经退出，忽略 ESRCH
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, lowNice); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("设置 CPU 调度优先级失败: %w", err)
+		}
+		_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), uintptr(ioprio))
+		if errno != 0 && errno != syscall.ESRCH {
+			return fmt.Errorf("设置 I/O 调度优先级失败: %w", errno)
+		}
+	}
+	return nil
+}
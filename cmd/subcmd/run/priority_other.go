@@ -0,0 +1,13 @@
+//go:build !linux
+
+package run
+
+import "errors"
+
+// lowPriorityDetail 低优先级模式使用的调度优先级说明
+const lowPriorityDetail = ""
+
+// lowerPriority 当前平台不支持降低调度优先级
+func lowerPriority() error {
+	return errors.New("当前平台不支持低优先级模式 (仅支持 Linux)")
+}
@@ -0,0 +1,101 @@
+// Package run 实现了 bakctl 被中断备份的恢复功能。
+//
+// 备份文件先写入临时文件（name_YYYYMMDD_HHMMSS.partial.zip），收集文件大小和哈希成功后
+// 才重命名为最终文件名；同时在数据目录的 journal 目录中记录正在执行的备份。
+// 如果进程在备份过程中崩溃或被强制终止，下次执行 run 命令时会：
+//   - 删除遗留的临时文件
+//   - 删除已重命名但尚未记录到数据库的备份文件
+//   - 为被中断的备份写入一条失败记录
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+
+	DB "gitee.com/MM-Q/bakctl/internal/db"
+	"gitee.com/MM-Q/bakctl/internal/journal"
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/colorlib"
+	"github.com/jmoiron/sqlx"
+)
+
+// recoverAbandonedBackups 清理被中断的备份并记录为失败
+//
+// 参数：
+//   - db：数据库连接对象
+//   - cl：颜色库对象
+//
+// 返回值：
+//   - error：如果读取备份日志失败，则返回非 nil 错误信息
+func recoverAbandonedBackups(db *sqlx.DB, cl *colorlib.ColorLib) error {
+	jnl, err := journal.Open(types.DataDirPath)
+	if err != nil {
+		return err
+	}
+
+	entries, err := jnl.Abandoned()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := recoverAbandonedBackup(db, entry); err != nil {
+			cl.Redf("清理被中断的备份 %s (任务: %s) 失败: %v\n", entry.VersionID, entry.TaskName, err)
+			continue
+		}
+		if err := jnl.End(entry.VersionID); err != nil {
+			cl.Redf("%v\n", err)
+			continue
+		}
+		cl.Yellowf("已清理被中断的备份 %s (任务: %s, 开始于 %s)\n",
+			entry.VersionID, entry.TaskName, entry.StartedAt.Format("2006-01-02 15:04:05"))
+	}
+
+	return nil
+}
+
+// recoverAbandonedBackup 清理单个被中断的备份
+//
+// 参数：
+//   - db：数据库连接对象
+//   - entry：被中断的备份
+//
+// 返回值：
+//   - error：如果删除文件或写入记录失败，则返回非 nil 错误信息
+func recoverAbandonedBackup(db *sqlx.DB, entry journal.Entry) error {
+	// 1. 删除遗留的临时文件（包括分卷文件）
+	if err := removeBackupFiles(entry.TempPath); err != nil {
+		return err
+	}
+
+	// 2. 备份结果已经记录到数据库（中断发生在记录之后），无需其他处理
+	exists, err := DB.BackupRecordExists(db, entry.VersionID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	// 3. 未记录的备份文件无法确认其完整性，删除后记录为失败
+	if err := removeBackupFiles(entry.BackupPath); err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("备份被中断 (进程 %d 异常退出), 已清理未完成的备份文件", entry.PID)
+	rec := types.BackupRecord{
+		TaskID:         entry.TaskID,                    // 任务ID
+		TaskName:       entry.TaskName,                  // 任务名称
+		VersionID:      entry.VersionID,                 // 版本ID
+		BackupFilename: filepath.Base(entry.BackupPath), // 备份文件名
+		StoragePath:    entry.BackupPath,                // 存储路径
+		Status:         false,                           // 状态
+		State:          types.BackupStateFailed,         // 执行状态
+		StorageMode:    entry.StorageMode,               // 存储模式
+		FailureMessage: msg,                             // 失败原因
+	}
+
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	return DB.InsertBackupRecord(db, &rec)
+}
@@ -0,0 +1,165 @@
+// Package run 实现了 bakctl 的仓库存储模式备份功能。
+//
+// 仓库模式的任务不再为每次备份生成独立的归档文件，而是将源文件切分为数据块，
+// 按内容去重后写入任务存储目录下的数据块仓库，并为每次备份生成一个快照文件：
+//   - 多个版本之间未变化的数据只存储一份
+//   - 每个快照都是完整的，可以独立恢复，不依赖其他版本
+//   - 清理历史快照后，自动回收不再被任何快照引用的数据块
+package run
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+
+	"gitee.com/MM-Q/bakctl/internal/archive"
+	"gitee.com/MM-Q/bakctl/internal/cleanup"
+	"gitee.com/MM-Q/bakctl/internal/filter"
+	"gitee.com/MM-Q/bakctl/internal/lock"
+	"gitee.com/MM-Q/bakctl/internal/repo"
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/bakctl/internal/utils"
+	"gitee.com/MM-Q/colorlib"
+)
+
+// 数据块仓库的进程内互斥锁（按仓库锁文件路径区分，不同存储目录的仓库互不影响）
+// 多个任务可能共用同一个存储目录, 回收数据块时不能有其他任务正在写入尚未被快照引用的数据块
+// 其他进程之间通过仓库锁文件互斥, 见 lockRepository
+var (
+	repoMusMu sync.Mutex                     // 保护 repoMus
+	repoMus   = make(map[string]*sync.Mutex) // 仓库锁文件路径 -> 进程内互斥锁
+)
+
+// repoMutex 返回仓库锁文件对应的进程内互斥锁
+func repoMutex(lockPath string) *sync.Mutex {
+	if abs, err := filepath.Abs(lockPath); err == nil {
+		lockPath = abs
+	}
+
+	repoMusMu.Lock()
+	defer repoMusMu.Unlock()
+	mu, ok := repoMus[lockPath]
+	if !ok {
+		mu = &sync.Mutex{}
+		repoMus[lockPath] = mu
+	}
+	return mu
+}
+
+// lockRepository 获取数据块仓库的锁（进程内互斥锁和跨进程的仓库锁）
+//
+// 参数：
+//   - ctx：上下文，等待仓库锁时被取消则停止等待
+//   - task：要执行的备份任务
+//   - cl：颜色库对象
+//
+// 返回值：
+//   - func()：释放锁的函数
+//   - error：如果获取仓库锁失败，则返回非 nil 错误信息
+func lockRepository(ctx context.Context, task types.BackupTask, cl *colorlib.ColorLib) (func(), error) {
+	lockPath := repo.LockPath(task.StorageDir)
+	mu := repoMutex(lockPath)
+	mu.Lock()
+
+	l, err := lock.Acquire(ctx, lockPath, "数据块仓库 ", func(h lock.Holder) {
+		cl.Yellowf("[%s] 数据块仓库正在被其他进程使用: %s, 等待其结束...\n", task.Name, h)
+	})
+	if err != nil {
+		mu.Unlock()
+		return nil, err
+	}
+
+	return func() {
+		_ = l.Release()
+		mu.Unlock()
+	}, nil
+}
+
+// packRepository 以仓库模式备份源目录
+//
+// 参数：
+//   - ctx：上下文，被取消或超时后中止备份
+//   - task：要执行的备份任务
+//   - result：备份执行结果（快照写入 TempPath，收集文件信息后重命名为 BackupPath）
+//   - filters：过滤器
+//   - tol：错误处理策略（为 nil 时任何条目出错都中止备份）
+//   - cl：颜色库对象
+//
+// 返回值：
+//   - int64：本次备份新增占用的空间（新写入的数据块大小）
+//   - error：如果备份过程中发生错误，则返回非 nil 错误信息
+func packRepository(ctx context.Context, task types.BackupTask, result *types.BackupResult, filters *filter.Filter, tol *archive.Tolerance, cl *colorlib.ColorLib) (int64, error) {
+	// 1. 收集源目录中的条目
+	entries, err := collectEntries(task, filters, result, tol)
+	if err != nil {
+		return 0, err
+	}
+
+	unlock, err := lockRepository(ctx, task, cl)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	// 2. 打开仓库（不存在时自动创建）
+	r, err := repo.Open(repo.Root(task.StorageDir))
+	if err != nil {
+		return 0, err
+	}
+
+	// 3. 写入数据块并生成快照
+	snap, stats, err := r.Backup(ctx, entries, task.Name, result.VersionID, tol)
+	if err != nil {
+		return 0, err
+	}
+	if err := repo.SaveSnapshot(result.TempPath, snap); err != nil {
+		return 0, err
+	}
+	result.Files = snapshotManifest(snap)
+	result.SourceBytes = stats.TotalBytes // 实际读取并切分的字节数
+
+	cl.Whitef("[%s] 仓库备份: %d 个文件 (%s), 数据块 %d 个, 新增 %d 个 (%s)\n",
+		task.Name, stats.Files, utils.FormatBytes(stats.TotalBytes),
+		stats.TotalChunks, stats.NewChunks, utils.FormatBytes(stats.NewBytes))
+
+	return stats.NewBytes, nil
+}
+
+// cleanupRepository 按保留策略清理历史快照，并回收不再被引用的数据块
+//
+// 参数：
+//   - task：要执行的备份任务
+//   - cl：颜色库对象
+//
+// 返回值：
+//   - error：如果清理过程中发生错误，则返回非 nil 错误信息
+func cleanupRepository(task types.BackupTask, cl *colorlib.ColorLib) error {
+	// 快照之间没有依赖关系，按普通备份文件清理即可
+	taskAdapter := cleanup.NewBackupTaskAdapter(
+		task.ID, task.Name, repo.SnapshotDir(task.StorageDir),
+		task.RetainCount, task.RetainDays,
+	)
+	if err := cleanup.CleanupBackupFilesWithLogging(taskAdapter, []string{repo.SnapshotExt}, nil, cl); err != nil {
+		return err
+	}
+
+	unlock, err := lockRepository(context.Background(), task, cl)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	r, err := repo.Open(repo.Root(task.StorageDir))
+	if err != nil {
+		return err
+	}
+	removed, freed, err := r.GC()
+	if err != nil {
+		return err
+	}
+	if removed > 0 {
+		cl.Whitef("[%s] 已回收 %d 个未被引用的数据块, 释放 %s\n", task.Name, removed, utils.FormatBytes(freed))
+	}
+
+	return nil
+}
@@ -1,11 +1,14 @@
 // Package run 实现了 bakctl 的 run 子命令功能。
 //
 // 该包提供了执行备份任务的核心功能，支持：
-//   - 执行单个或多个备份任务
+//   - 执行单个或多个备份任务（支持通过 --jobs 并发执行）
 //   - 实时显示备份进度和状态
 //   - 自动清理过期的备份文件
+//   - 按分卷大小将备份文件拆分为多个分卷
+//   - 限制读取速度并可降低进程的调度优先级
 //   - 验证备份文件的完整性
 //   - 处理备份过程中的错误和异常
+//   - 收到中断信号或任务超时时中止备份并清理未完成的文件
 //
 // 主要功能包括：
 //   - 从数据库加载任务配置
@@ -19,22 +22,36 @@
 package run
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
+	"gitee.com/MM-Q/bakctl/internal/archive"
 	"gitee.com/MM-Q/bakctl/internal/cleanup"
+	"gitee.com/MM-Q/bakctl/internal/crypt"
 	DB "gitee.com/MM-Q/bakctl/internal/db"
+	"gitee.com/MM-Q/bakctl/internal/filter"
+	"gitee.com/MM-Q/bakctl/internal/journal"
+	"gitee.com/MM-Q/bakctl/internal/repo"
 	"gitee.com/MM-Q/bakctl/internal/types"
 	"gitee.com/MM-Q/bakctl/internal/utils"
 	"gitee.com/MM-Q/colorlib"
 	"gitee.com/MM-Q/comprx"
-	"gitee.com/MM-Q/go-kit/hash"
 	"gitee.com/MM-Q/go-kit/id"
 	"github.com/jmoiron/sqlx"
+	"github.com/schollz/progressbar/v3"
 )
 
+// dbMu 串行化备份过程中的数据库写入
+// 并发执行任务时多个协程共享同一个 SQLite 连接池, 写操作需要逐个进行以避免锁冲突
+var dbMu sync.Mutex
+
 // RunCmdMain run命令的主函数
 //
 // 参数:
@@ -49,6 +66,13 @@ func RunCmdMain(db *sqlx.DB, cl *colorlib.ColorLib) error {
 		return fmt.Errorf("参数错误: %w", err)
 	}
 
+	// 清理上次被中断的备份遗留的不完整文件（预览模式不写入备份记录，跳过）
+	if !dryRunFlag.Get() {
+		if err := recoverAbandonedBackups(db, cl); err != nil {
+			cl.Redf("清理被中断的备份失败: %v\n", err)
+		}
+	}
+
 	// 2. 选择要执行的任务
 	tasks, err := selectTasks(db)
 	if err != nil {
@@ -58,11 +82,20 @@ func RunCmdMain(db *sqlx.DB, cl *colorlib.ColorLib) error {
 	// 3. 显示选中的任务信息
 	cl.Bluef("找到 %d 个任务:\n", len(tasks))
 	for i, task := range tasks {
-		cl.Whitef("  %d. %s (ID: %d) - %s\n", i+1, task.Name, task.ID, task.BackupDir)
+		cl.Whitef("  %d. %s (ID: %d) - %s%s\n", i+1, task.Name, task.ID, strings.Join(task.Sources(), ", "), readLimitNote(task))
 	}
 
-	// 4. 执行选中的任务
-	if err := executeTasks(tasks, db, cl); err != nil {
+	// 4. 预览模式只显示文件选择结果，不执行备份
+	if dryRunFlag.Get() {
+		return previewTasks(tasks, cl)
+	}
+
+	// 5. 执行选中的任务（收到 SIGINT/SIGTERM 信号时取消）
+	preparePassphrase(tasks)
+	ctx, stop := signalContext(cl)
+	defer stop()
+	ctx = applyRunThrottle(ctx, cl)
+	if err := executeTasks(ctx, tasks, db, cl); err != nil {
 		return fmt.Errorf("任务执行失败: %w", err)
 	}
 
@@ -72,132 +105,454 @@ func RunCmdMain(db *sqlx.DB, cl *colorlib.ColorLib) error {
 // executeTask 执行单个备份任务
 //
 // 参数：
+//   - ctx：上下文，收到中断信号时取消
 //   - task：要执行的备份任务
 //   - db：数据库连接对象
 //   - cl: 颜色库对象
+//   - showProgress: 是否显示压缩和校验进度条（并发执行时关闭，避免多个进度条互相覆盖）
 //
 // 返回值：
 //   - error：如果执行过程中发生错误，则返回非 nil 错误信息；成功则返回 nil
-func executeTask(task types.BackupTask, db *sqlx.DB, cl *colorlib.ColorLib) error {
+func executeTask(ctx context.Context, task types.BackupTask, db *sqlx.DB, cl *colorlib.ColorLib, showProgress bool) (err error) {
+	// 获取任务锁，同一个任务同一时间只能被一个进程执行（等待锁的时间不计入任务超时时间）
+	taskLock, err := acquireTaskLock(ctx, task, cl)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if relErr := taskLock.Release(); relErr != nil {
+			cl.Redf("%v\n", relErr)
+		}
+	}()
+
+	// 任务配置了超时时间时，超时后中止备份
+	taskCtx, cancel := taskContext(ctx, task)
+	defer cancel()
+
+	// 任务配置了读取限速时，打包和哈希计算的读取速度不超过限速
+	taskCtx = throttleContext(taskCtx, task)
+
 	// 初始化结果结构体
 	result := &types.BackupResult{
 		Success:    false,                    // 备份是否成功
+		VersionID:  id.GenMaskedID(),         // 版本ID
 		BackupPath: generateBackupPath(task), // 备份文件路径
+		StartedAt:  time.Now(),               // 开始时间
+	}
+	result.TempPath = partialPath(result.BackupPath) // 写入中的临时文件路径
+
+	// 记录备份进度日志，进程中断后下次执行时据此清理不完整的备份文件
+	jnl, err := journal.Open(types.DataDirPath)
+	if err != nil {
+		return err
+	}
+	if err := jnl.Begin(journal.Entry{
+		TaskID:      task.ID,
+		TaskName:    task.Name,
+		VersionID:   result.VersionID,
+		TempPath:    result.TempPath,
+		BackupPath:  result.BackupPath,
+		StorageMode: task.StorageMode,
+	}); err != nil {
+		return err
 	}
 
 	// 使用defer确保无论成功失败都记录到数据库
 	defer func() {
+		// 删除未完成的临时文件
+		if rmErr := removeBackupFiles(result.TempPath); rmErr != nil {
+			cl.Redf("%v\n", rmErr)
+		}
+
+		// 因收到中断信号或超时而中止的备份单独记录执行状态
+		if err != nil && !result.Success {
+			if state, msg := abortState(ctx, taskCtx, task); state != "" {
+				result.State = state
+				result.ErrorMsg = msg
+				err = errors.New(msg)
+			}
+		}
+
+		// 执行失败时运行失败钩子，钩子自身的失败也记录到失败信息中
+		// 失败钩子不受取消和超时的影响，仍按钩子超时时间执行
+		if err != nil {
+			errMsg := result.ErrorMsg
+			if errMsg == "" {
+				errMsg = err.Error()
+			}
+			status := hookStatusFailed
+			if result.State != "" {
+				status = result.State
+			}
+			if hookErr := runHook(context.Background(), task, hookOnFailure, task.OnFailureHook, result, status, errMsg, cl); hookErr != nil {
+				result.ErrorMsg = joinErrorMsg(result.ErrorMsg, hookErr.Error())
+			}
+		}
+
 		if recordErr := recordBackupResult(db, task, result); recordErr != nil {
 			// 记录失败的处理（可以记录日志等）
 			cl.Redf("记录备份结果失败: %v\n", recordErr)
 		}
+
+		// 备份结果已记录，结束进度日志
+		if endErr := jnl.End(result.VersionID); endErr != nil {
+			cl.Redf("%v\n", endErr)
+		}
 	}()
 
-	// 1. 验证源目录
-	if err := validateSourceDir(task.BackupDir); err != nil {
+	// 0. 执行前置钩子（失败时中止备份）
+	if err := runHook(taskCtx, task, hookPre, task.PreHook, result, hookStatusPending, "", cl); err != nil {
 		result.ErrorMsg = err.Error()
 		return err
 	}
 
-	// 2. 解析过滤规则
-	include, exclude, err := parseFilterRules(task.IncludeRules, task.ExcludeRules)
+	// 1. 验证备份源路径
+	if err := validateSources(task.Sources()); err != nil {
+		result.ErrorMsg = err.Error()
+		return err
+	}
+
+	// 2. 解析过滤规则并构建过滤器
+	filters, err := buildFilters(task)
 	if err != nil {
 		result.ErrorMsg = err.Error()
 		return err
 	}
 
-	// 3. 构建过滤器
-	filters := comprx.FilterOptions{
-		Include: include,          // 包含规则
-		Exclude: exclude,          // 排除规则
-		MinSize: task.MinFileSize, // 最小文件大小
-		MaxSize: task.MaxFileSize, // 最大文件大小
+	// 3. 设置压缩等级和加密密钥
+	level := compressionLevel(task.CompressionName())
+	key, err := encryptionKey(task)
+	if err != nil {
+		result.ErrorMsg = fmt.Sprintf("获取加密密钥失败: %v", err)
+		return err
+	}
+	if key != nil {
+		result.Encryption = key.Scheme()
+	}
+
+	// 4. 执行备份操作（增量模式只打包变化的文件，仓库模式写入去重数据块）
+	// 错误处理策略不是 strict 时，无法读取的条目被跳过并记录为警告
+	tol := archive.NewTolerance(task.ErrorPolicyName())
+	var newBytes int64 // 仓库模式下新写入的数据块大小
+	switch {
+	case task.StorageMode == types.StorageModeRepository:
+		newBytes, err = packRepository(taskCtx, task, result, filters, tol, cl)
+	case task.BackupMode == types.BackupModeIncremental:
+		err = packIncremental(taskCtx, db, task, result, filters, level, key, tol, cl)
+	default:
+		err = packArchive(taskCtx, task, result, filters, level, key, tol, showProgress)
+	}
+	if err != nil {
+		result.ErrorMsg = fmt.Sprintf("备份操作失败: %v", err)
+		return err
+	}
+	for _, w := range tol.Warnings() {
+		result.FileWarnings = append(result.FileWarnings, types.BackupWarning{Path: w.Name, Message: w.Err.Error()})
+	}
+	for _, w := range result.FileWarnings {
+		cl.Yellowf("[%s] 警告: %s: %s\n", task.Name, w.Path, w.Message)
+	}
+
+	// 5. 收集备份文件信息，成功后再将临时文件重命名为最终的备份文件（分卷备份逐个处理每个分卷）
+	var size int64
+	var checksum string
+	if task.VolumeSize > 0 {
+		if size, err = finishVolumes(taskCtx, result, task.HashAlgorithmName(), showProgress); err != nil {
+			result.ErrorMsg = err.Error()
+			result.FileSize = size // 即使哈希失败也记录文件大小
+			return err
+		}
+	} else {
+		if size, checksum, err = collectBackupInfo(taskCtx, result.TempPath, task.HashAlgorithmName(), showProgress); err != nil {
+			result.ErrorMsg = err.Error()
+			result.FileSize = size // 即使哈希失败也记录文件大小
+			return err
+		}
+		if err := os.Rename(result.TempPath, result.BackupPath); err != nil {
+			result.ErrorMsg = fmt.Sprintf("重命名备份文件失败: %v", err)
+			return err
+		}
 	}
 
-	// 4. 设置压缩等级
-	level := comprx.CompressionLevelNone // 默认不压缩
-	if task.Compress {
-		level = comprx.CompressionLevelDefault // 使用默认压缩等级
+	// 6. 设置成功结果
+	result.Success = true             // 备份成功
+	result.FileSize = size + newBytes // 备份文件大小（仓库模式为快照大小加新增数据块大小）
+	result.Checksum = checksum        // 备份文件哈希值（仓库模式为快照文件的哈希值，分卷备份为空）
+	postStatus := hookStatusSuccess
+	if len(result.FileWarnings) > 0 {
+		result.State = types.BackupStatePartial // 有条目未能备份，但备份文件可用
+		postStatus = hookStatusPartial
 	}
 
-	// 5. 构建压缩配置
-	opts := comprx.Options{
-		CompressionLevel:      level,                     // 压缩等级
-		OverwriteExisting:     false,                     // 覆盖已存在的文件
-		ProgressEnabled:       true,                      // 显示进度条
-		ProgressStyle:         comprx.ProgressStyleASCII, // 进度条样式
-		DisablePathValidation: false,                     // 禁用路径验证
-		Filter:                filters,                   // 过滤器
+	// 之后的步骤失败时备份文件仍然可用，只记录为部分成功，不运行失败钩子
+	// 7. 执行后置钩子
+	if err := runHook(taskCtx, task, hookPost, task.PostHook, result, postStatus, "", cl); err != nil {
+		warnAfterBackup(task, result, err, cl)
 	}
 
-	// 6. 执行备份操作
-	if err := comprx.PackOptions(result.BackupPath, task.BackupDir, opts); err != nil {
-		result.ErrorMsg = fmt.Sprintf("备份操作失败: %v", err)
+	// 8. 清理历史备份（静默执行，保留增量备份链依赖的文件）
+	if task.StorageMode == types.StorageModeRepository {
+		if err := cleanupRepository(task, cl); err != nil {
+			warnAfterBackup(task, result, fmt.Errorf("清理历史备份失败: %w", err), cl)
+		}
+	} else if err := cleanupArchives(db, task, result, cl); err != nil {
+		warnAfterBackup(task, result, err, cl)
+	}
+
+	// 9. 清理孤儿记录（静默执行，但处理错误）
+	dbMu.Lock()
+	_, err = DB.CleanupOrphanRecords(db, task.ID)
+	dbMu.Unlock()
+	if err != nil {
+		warnAfterBackup(task, result, fmt.Errorf("清理孤儿记录失败: %w", err), cl)
+	}
+
+	return nil
+}
+
+// warnAfterBackup 记录备份文件生成之后的步骤（后置钩子、清理历史备份）的失败
+//
+// 备份文件已经可用，备份记录为部分成功并保存失败信息，任务仍按成功统计。
+//
+// 参数：
+//   - task：要执行的备份任务
+//   - result：备份执行结果
+//   - err：步骤的错误
+//   - cl：颜色库对象
+func warnAfterBackup(task types.BackupTask, result *types.BackupResult, err error, cl *colorlib.ColorLib) {
+	result.State = types.BackupStatePartial
+	result.ErrorMsg = joinErrorMsg(result.ErrorMsg, err.Error())
+	cl.Yellowf("[%s] 警告: %v\n", task.Name, err)
+}
+
+// packArchive 将源目录完整打包为归档文件
+//
+// 参数：
+//   - ctx：上下文，被取消或超时后中止打包
+//   - task：要执行的备份任务
+//   - result：备份执行结果（归档写入 TempPath，写入文件清单）
+//   - filters：过滤器
+//   - level：压缩等级
+//   - key：加密密钥（为 nil 时不加密）
+//   - tol：错误处理策略（为 nil 时任何条目出错都中止打包）
+//   - showProgress：是否显示压缩进度条
+//
+// 返回值：
+//   - error：如果打包过程中发生错误或被取消，则返回非 nil 错误信息
+func packArchive(ctx context.Context, task types.BackupTask, result *types.BackupResult, filters *filter.Filter, level comprx.CompressionLevel, key *crypt.Key, tol *archive.Tolerance, showProgress bool) error {
+	entries, err := collectEntries(task, filters, result, tol)
+	if err != nil {
 		return err
 	}
 
-	// 7. 收集备份文件信息
-	size, checksum, err := collectBackupInfo(result.BackupPath)
+	// 打包时统计实际从备份源读取的字节数（不包括被跳过的文件和读取过程中的大小变化）
+	read := &byteCounter{}
+	var progress io.Writer = read
+	if showProgress {
+		total := result.SourceBytes // 所有普通文件的大小
+		bar := progressbar.NewOptions64(
+			total,                             // 总进度
+			progressbar.OptionShowBytes(true), // 显示已处理的字节数
+			progressbar.OptionThrottle(100*time.Millisecond),                          // 限制刷新频率
+			progressbar.OptionClearOnFinish(),                                         // 完成后清除进度条
+			progressbar.OptionSetDescription(filepath.Base(result.BackupPath)+" 压缩中"), // 设置进度条描述
+		)
+		defer func() {
+			_ = bar.Finish()
+			_ = bar.Close()
+		}()
+		progress = io.MultiWriter(bar, read)
+	}
+
+	hashes, err := archive.Write(ctx, task.Format, result.TempPath, task.VolumeSize, entries, level, task.StoreExtList(), key, tol, progress)
 	if err != nil {
-		result.ErrorMsg = err.Error()
-		result.FileSize = size // 即使哈希失败也记录文件大小
 		return err
 	}
 
-	// 8. 设置成功结果
-	result.Success = true      // 备份成功
-	result.FileSize = size     // 备份文件大小
-	result.Checksum = checksum // 备份文件哈希值
+	result.Files = archiveManifest(tol.Filter(entries), hashes) // 写入时被跳过的条目不在归档中
+	result.SourceBytes = read.n
+	return nil
+}
 
-	// 9. 清理历史备份（静默执行）
+// cleanupArchives 按保留策略清理历史归档文件，保留增量备份链依赖的文件
+//
+// 参数：
+//   - db：数据库连接对象
+//   - task：要执行的备份任务
+//   - result：本次备份的执行结果
+//   - cl: 颜色库对象
+//
+// 返回值：
+//   - error：如果清理过程中发生错误，则返回非 nil 错误信息
+func cleanupArchives(db *sqlx.DB, task types.BackupTask, result *types.BackupResult, cl *colorlib.ColorLib) error {
+	resolver, err := buildDependencyResolver(db, task, result)
+	if err != nil {
+		return fmt.Errorf("解析备份依赖失败: %w", err)
+	}
 	taskAdapter := cleanup.NewBackupTaskAdapter(
 		task.ID, task.Name, task.StorageDir,
 		task.RetainCount, task.RetainDays,
 	)
-	if err := cleanup.CleanupBackupFilesWithLogging(taskAdapter, types.BackupFileExt, cl); err != nil {
+	if err := cleanup.CleanupBackupFilesWithLogging(taskAdapter, types.BackupExtList(), resolver, cl); err != nil {
 		return fmt.Errorf("清理历史备份失败: %w", err)
 	}
 
-	// 10. 清理孤儿记录（静默执行，但处理错误）
-	if _, err := DB.CleanupOrphanRecords(db, task.ID); err != nil {
-		return fmt.Errorf("清理孤儿记录失败: %w", err)
-	}
-
 	return nil
 }
 
+// taskOutcome 单个任务的执行结果，用于汇总输出
+type taskOutcome struct {
+	task     types.BackupTask // 执行的任务
+	err      error            // 执行错误（nil表示成功）
+	duration time.Duration    // 执行耗时
+}
+
 // executeTasks 批量执行备份任务
 //
 // 参数：
+//   - ctx：上下文，收到中断信号时取消
 //   - tasks：要执行的备份任务切片
 //   - db：数据库连接对象
 //   - cl: 颜色库对象
 //
 // 返回值：
 //   - error：如果执行过程中发生错误，则返回非 nil 错误信息；全部成功则返回 nil
-func executeTasks(tasks []types.BackupTask, db *sqlx.DB, cl *colorlib.ColorLib) error {
-	successCount := 0 // 成功数量
-	failureCount := 0 // 失败数量
+func executeTasks(ctx context.Context, tasks []types.BackupTask, db *sqlx.DB, cl *colorlib.ColorLib) error {
+	// 并发数不超过任务数
+	jobs := min(jobsFlag.Get(), len(tasks))
 
-	// 执行每个任务
+	var outcomes []taskOutcome
 	fmt.Println() // 换行
+	if jobs <= 1 {
+		outcomes = executeTasksSequential(ctx, tasks, db, cl)
+	} else {
+		cl.Bluef("使用 %d 个并发任务执行\n", jobs)
+		outcomes = executeTasksParallel(ctx, tasks, db, cl, jobs)
+	}
+
+	// 显示执行结果统计
+	return printSummary(outcomes, cl)
+}
+
+// executeTasksSequential 按顺序逐个执行备份任务
+//
+// 参数：
+//   - ctx：上下文，取消后不再开始执行剩余的任务
+//   - tasks：要执行的备份任务切片
+//   - db：数据库连接对象
+//   - cl: 颜色库对象
+//
+// 返回值：
+//   - []taskOutcome：按任务顺序排列的执行结果
+func executeTasksSequential(ctx context.Context, tasks []types.BackupTask, db *sqlx.DB, cl *colorlib.ColorLib) []taskOutcome {
+	outcomes := make([]taskOutcome, 0, len(tasks))
+
 	for i, task := range tasks {
+		if ctx.Err() != nil {
+			outcomes = append(outcomes, taskOutcome{task: task, err: errTaskCancelled})
+			continue
+		}
+
 		cl.Bluef("[%d/%d] 正在执行任务: %s (ID: %d)\n", i+1, len(tasks), task.Name, task.ID)
 
-		if err := executeTask(task, db, cl); err != nil {
+		start := time.Now()
+		err := executeTask(ctx, task, db, cl, true)
+		if err != nil {
 			cl.Redf("任务执行失败: %v\n", err)
-			failureCount++
 		} else {
 			cl.Greenf("任务执行成功 (ID: %d)\n", task.ID)
+		}
+
+		outcomes = append(outcomes, taskOutcome{task: task, err: err, duration: time.Since(start)})
+	}
+
+	return outcomes
+}
+
+// executeTasksParallel 使用固定大小的工作池并发执行备份任务
+//
+// 并发模式下关闭进度条，每个任务只在开始和结束时输出一行带任务名前缀的状态信息。
+//
+// 参数：
+//   - ctx：上下文，取消后不再开始执行剩余的任务
+//   - tasks：要执行的备份任务切片
+//   - db：数据库连接对象
+//   - cl: 颜色库对象
+//   - jobs：并发执行的任务数
+//
+// 返回值：
+//   - []taskOutcome：按任务顺序排列的执行结果
+func executeTasksParallel(ctx context.Context, tasks []types.BackupTask, db *sqlx.DB, cl *colorlib.ColorLib, jobs int) []taskOutcome {
+	outcomes := make([]taskOutcome, len(tasks))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				task := tasks[i]
+				if ctx.Err() != nil {
+					outcomes[i] = taskOutcome{task: task, err: errTaskCancelled}
+					continue
+				}
+
+				cl.Bluef("[%d/%d] [%s] 开始执行 (ID: %d)\n", i+1, len(tasks), task.Name, task.ID)
+
+				start := time.Now()
+				err := executeTask(ctx, task, db, cl, false)
+				duration := time.Since(start)
+				if err != nil {
+					cl.Redf("[%s] 执行失败 (耗时 %v): %v\n", task.Name, duration.Round(time.Millisecond), err)
+				} else {
+					cl.Greenf("[%s] 执行成功 (耗时 %v)\n", task.Name, duration.Round(time.Millisecond))
+				}
+
+				outcomes[i] = taskOutcome{task: task, err: err, duration: duration}
+			}
+		}()
+	}
+
+	// 分发任务
+	for i := range tasks {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return outcomes
+}
+
+// printSummary 打印执行结果统计
+//
+// 参数：
+//   - outcomes：各任务的执行结果
+//   - cl: 颜色库对象
+//
+// 返回值：
+//   - error：如果有任务执行失败，则返回非 nil 错误信息；全部成功则返回 nil
+func printSummary(outcomes []taskOutcome, cl *colorlib.ColorLib) error {
+	successCount := 0 // 成功数量
+	failureCount := 0 // 失败数量
+	for _, o := range outcomes {
+		if o.err != nil {
+			failureCount++
+		} else {
 			successCount++
 		}
 	}
 
-	// 显示执行结果统计
 	fmt.Println()
 	cl.Greenf("执行完成！成功: %d, 失败: %d\n", successCount, failureCount)
 
 	if failureCount > 0 {
+		cl.Red("失败详情:")
+		for _, o := range outcomes {
+			if o.err != nil {
+				cl.Redf("  任务ID %d (%s): %v\n", o.task.ID, o.task.Name, o.err)
+			}
+		}
 		return fmt.Errorf("有 %d 个任务执行失败", failureCount)
 	}
 
@@ -246,6 +601,16 @@ func validateFlags() error {
 		paramCount++
 	}
 
+	// 检查并发数
+	if jobsFlag.Get() < 1 {
+		return fmt.Errorf("并发数必须大于等于1, 当前值: %d", jobsFlag.Get())
+	}
+
+	// 检查任务锁等待方式
+	if waitFlag.Get() && noWaitFlag.Get() {
+		return fmt.Errorf("--wait 和 --no-wait 不能同时使用")
+	}
+
 	// 互斥性检查
 	if paramCount == 0 {
 		return fmt.Errorf("请指定要运行的任务: -id <任务ID> 或 -ids <任务ID列表> 或 -all")
@@ -258,16 +623,40 @@ func validateFlags() error {
 	return nil
 }
 
-// validateSourceDir 验证源目录是否存在
+// compressionLevel 返回压缩等级对应的 comprx 压缩等级
 //
 // 参数：
-//   - dir：源目录路径
+//   - compression：压缩等级（none/fast/default/best/huffman）
 //
 // 返回值：
-//   - error：如果源目录不存在，则返回错误信息；否则返回 nil
-func validateSourceDir(dir string) error {
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		return fmt.Errorf("源目录不存在: %s", dir)
+//   - comprx.CompressionLevel：comprx 压缩等级，未知的压缩等级视为不压缩
+func compressionLevel(compression string) comprx.CompressionLevel {
+	switch compression {
+	case types.CompressionFast:
+		return comprx.CompressionLevelFast
+	case types.CompressionDefault:
+		return comprx.CompressionLevelDefault
+	case types.CompressionBest:
+		return comprx.CompressionLevelBest
+	case types.CompressionHuffman:
+		return comprx.CompressionLevelHuffmanOnly
+	default:
+		return comprx.CompressionLevelNone
+	}
+}
+
+// validateSources 验证备份源路径是否存在
+//
+// 参数：
+//   - srcs：备份源路径列表
+//
+// 返回值：
+//   - error：如果任一备份源路径不存在，则返回错误信息；否则返回 nil
+func validateSources(srcs []string) error {
+	for _, src := range srcs {
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			return fmt.Errorf("源目录不存在: %s", src)
+		}
 	}
 	return nil
 }
@@ -296,6 +685,45 @@ func parseFilterRules(includeRules, excludeRules string) ([]string, []string, er
 	return include, exclude, nil
 }
 
+// buildFilters 根据任务配置构建过滤器
+//
+// 参数：
+//   - task：备份任务
+//
+// 返回值：
+//   - *filter.Filter：过滤器
+//   - error：如果过滤规则解析失败或规则无效，则返回错误信息
+func buildFilters(task types.BackupTask) (*filter.Filter, error) {
+	include, exclude, err := parseFilterRules(task.IncludeRules, task.ExcludeRules)
+	if err != nil {
+		return nil, err
+	}
+
+	filters, err := filter.New(include, exclude, task.MinFileSize, task.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// 文件属性过滤条件（时长相对于本次备份的开始时间）
+	now := time.Now()
+	if filters.NewerThan, err = filter.ParseTime(task.NewerThan, now); err != nil {
+		return nil, fmt.Errorf("解析修改时间下限失败: %w", err)
+	}
+	if filters.OlderThan, err = filter.ParseTime(task.OlderThan, now); err != nil {
+		return nil, fmt.Errorf("解析修改时间上限失败: %w", err)
+	}
+	if filters.UID, err = filter.LookupOwner(task.FileOwner); err != nil {
+		return nil, err
+	}
+	if filters.GID, err = filter.LookupGroup(task.FileGroup); err != nil {
+		return nil, err
+	}
+	filters.Types = task.FileTypeList()
+	filters.ExcludeHidden = task.ExcludeHidden
+
+	return filters, nil
+}
+
 // generateBackupPath 生成备份文件路径
 //
 // 参数：
@@ -306,20 +734,64 @@ func parseFilterRules(includeRules, excludeRules string) ([]string, []string, er
 func generateBackupPath(task types.BackupTask) string {
 	// 使用时间字符串格式：YYYYMMDD_HHMMSS
 	timeStr := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("%s_%s%s", task.Name, timeStr, types.BackupFileExt)
+
+	// 仓库模式的备份结果是仓库中的快照文件
+	if task.StorageMode == types.StorageModeRepository {
+		filename := fmt.Sprintf("%s_%s%s", task.Name, timeStr, repo.SnapshotExt)
+		return filepath.Join(repo.SnapshotDir(task.StorageDir), filename)
+	}
+
+	// 加密的备份文件追加 .enc 扩展名
+	ext := types.FormatExt(task.Format)
+	if task.Encryption != "" {
+		ext += types.EncryptedExt
+	}
+
+	filename := fmt.Sprintf("%s_%s%s", task.Name, timeStr, ext)
 	return filepath.Join(task.StorageDir, filename)
 }
 
+// joinErrorMsg 合并两条失败信息
+func joinErrorMsg(msg, extra string) string {
+	if msg == "" {
+		return extra
+	}
+	return msg + "; " + extra
+}
+
+// partialPath 返回备份文件写入过程中使用的临时文件路径
+//
+// 临时文件保留原扩展名（压缩库根据扩展名识别格式），并且不会匹配备份文件的命名规则，
+// 因此不会被清理策略当作有效的备份文件。
+//
+// 参数：
+//   - backupPath：最终的备份文件路径
+//
+// 返回值：
+//   - string：临时文件路径，如 name_20250903_143022.partial.tar.gz
+func partialPath(backupPath string) string {
+	ext := filepath.Ext(backupPath)
+	for _, backupExt := range types.BackupExtList() {
+		if strings.HasSuffix(backupPath, backupExt) && len(backupExt) > len(ext) {
+			ext = backupExt // .tar.gz、.zip.enc 等多级扩展名
+		}
+	}
+	return strings.TrimSuffix(backupPath, ext) + types.PartialFileSuffix + ext
+}
+
 // collectBackupInfo 收集备份文件信息（大小和哈希）
 //
 // 参数：
+//   - ctx：上下文，被取消或超时后中止哈希计算
 //   - filePath：备份文件路径
+//   - algorithm：哈希算法
+//   - showProgress：是否显示哈希计算进度条
 //
 // 返回值：
 //   - int64：文件大小
-//   - string：文件哈希值
+//   - string：带算法前缀的文件哈希值，如 "sha256:9f86d0..."
 //   - error：如果发生错误，则返回错误信息；否则返回 nil
-func collectBackupInfo(filePath string) (int64, string, error) {
+func collectBackupInfo(ctx context.Context, filePath, algorithm string, showProgress bool) (int64, string, error) {
 	// 获取文件大小
 	info, err := os.Stat(filePath)
 	if err != nil {
@@ -327,12 +799,12 @@ func collectBackupInfo(filePath string) (int64, string, error) {
 	}
 
 	// 计算哈希值
-	checksum, err := hash.ChecksumProgress(filePath, types.HashAlgorithm)
+	sum, err := utils.ChecksumContext(ctx, filePath, algorithm, showProgress)
 	if err != nil {
 		return info.Size(), "", fmt.Errorf("计算哈希失败: %w", err)
 	}
 
-	return info.Size(), checksum, nil
+	return info.Size(), utils.FormatChecksum(algorithm, sum), nil
 }
 
 // recordBackupResult 统一记录备份结果（成功或失败）
@@ -346,15 +818,32 @@ func collectBackupInfo(filePath string) (int64, string, error) {
 //   - error：如果记录失败，则返回错误信息；成功则返回 nil
 func recordBackupResult(db *sqlx.DB, task types.BackupTask, result *types.BackupResult) error {
 	rec := types.BackupRecord{
-		TaskID:         task.ID,                          // 任务ID
-		TaskName:       task.Name,                        // 任务名称
-		VersionID:      id.GenMaskedID(),                 // 版本ID
-		BackupFilename: filepath.Base(result.BackupPath), // 存储路径
-		BackupSize:     result.FileSize,                  // 文件大小
-		StoragePath:    result.BackupPath,                // 存储路径
-		Status:         result.Success,                   // 状态
-		FailureMessage: result.ErrorMsg,                  // 失败原因
-		Checksum:       result.Checksum,                  // 校验码
+		TaskID:          task.ID,                          // 任务ID
+		TaskName:        task.Name,                        // 任务名称
+		VersionID:       result.VersionID,                 // 版本ID
+		BackupFilename:  filepath.Base(result.BackupPath), // 存储路径
+		BackupSize:      result.FileSize,                  // 文件大小
+		StoragePath:     result.BackupPath,                // 存储路径
+		Status:          result.Success,                   // 状态
+		FailureMessage:  result.ErrorMsg,                  // 失败原因
+		Checksum:        result.Checksum,                  // 校验码
+		ParentVersionID: result.ParentVersionID,           // 上一个版本ID
+		Encryption:      result.Encryption,                // 加密方案
+		StorageMode:     task.StorageMode,                 // 存储模式
+		State:           result.State,                     // 执行状态
+		VolumeCount:     len(result.Volumes),              // 分卷数量
+	}
+	fillRunStats(&rec, result)
+	if rec.State == "" {
+		rec.State = rec.ResultState()
+	}
+
+	dbMu.Lock()
+	defer dbMu.Unlock()
+
+	// 成功的备份同时记录文件清单、分卷信息和未能备份的条目，在同一个事务中写入
+	if result.Success {
+		return DB.InsertBackupResult(db, &rec, result.Files, result.Volumes, result.FileWarnings)
 	}
 
 	return DB.InsertBackupRecord(db, &rec)
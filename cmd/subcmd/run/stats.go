@@ -0,0 +1,97 @@
+// Package run 实现了 bakctl 备份运行统计的收集功能。
+//
+// 每次备份都会在备份记录中保存运行统计，便于事后分析备份的耗时和数据量：
+//   - 开始和结束时间、执行耗时
+//   - 备份源中的文件数、目录数和被过滤器跳过的条目数
+//   - 从备份源实际读取的字节数（增量备份只计算打包的文件，被跳过的文件不计算）
+//   - 执行备份的主机名和 bakctl 版本
+package run
+
+import (
+	"os"
+	"time"
+
+	"gitee.com/MM-Q/bakctl/internal/archive"
+	"gitee.com/MM-Q/bakctl/internal/filter"
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/verman"
+)
+
+// recordTimeLayout 备份记录中时间字段的格式（与 created_at 一致，UTC 时间）
+const recordTimeLayout = "2006-01-02 15:04:05"
+
+// collectEntries 收集备份源中的条目，同时统计文件数、目录数和被跳过的条目数
+//
+// 参数：
+//   - task：要执行的备份任务
+//   - filters：过滤器
+//   - result：备份执行结果（写入统计信息和需要警告的被跳过条目，读取量先按遍历时所有普通文件的大小估计，打包后更新为实际读取的字节数）
+//   - tol：错误处理策略（为 nil 时无法读取的条目中止遍历）
+//
+// 返回值：
+//   - []archive.Entry：未被过滤器跳过的条目列表
+//   - error：如果遍历失败，则返回非 nil 错误信息
+func collectEntries(task types.BackupTask, filters *filter.Filter, result *types.BackupResult, tol *archive.Tolerance) ([]archive.Entry, error) {
+	entries, skipped, err := archive.CollectSourcesWithSkipped(task.Sources(), filters, task.IgnoreFile(), task.SymlinkPolicy(), tol)
+	if err != nil {
+		return nil, err
+	}
+
+	result.FileCount, result.DirCount, result.SourceBytes = 0, 0, 0
+	for _, e := range entries {
+		switch {
+		case e.Info.IsDir():
+			result.DirCount++
+		case e.IsRegular():
+			result.FileCount++
+			result.SourceBytes += e.Info.Size()
+		default:
+			result.FileCount++ // 符号链接等其他类型的条目按文件计数
+		}
+	}
+	result.SkippedCount = int64(len(skipped))
+	result.FileWarnings = nil
+	for _, s := range skipped {
+		if s.Warn {
+			result.FileWarnings = append(result.FileWarnings, types.BackupWarning{Path: s.Name, Message: "已跳过: " + s.Reason})
+		}
+	}
+
+	return entries, nil
+}
+
+// byteCounter 统计写入的字节数，与打包进度一起接收从备份源读取的文件内容
+type byteCounter struct {
+	n int64 // 已写入的字节数
+}
+
+// Write 实现 io.Writer 接口
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// fillRunStats 将备份执行结果中的运行统计写入备份记录
+//
+// 参数：
+//   - rec：备份记录
+//   - result：备份执行结果
+func fillRunStats(rec *types.BackupRecord, result *types.BackupResult) {
+	finished := time.Now()
+	started := result.StartedAt
+	if started.IsZero() {
+		started = finished
+	}
+
+	rec.StartedAt = started.UTC().Format(recordTimeLayout)
+	rec.FinishedAt = finished.UTC().Format(recordTimeLayout)
+	rec.DurationMs = finished.Sub(started).Milliseconds()
+	rec.FileCount = result.FileCount
+	rec.DirCount = result.DirCount
+	rec.SourceBytes = result.SourceBytes
+	rec.SkippedCount = result.SkippedCount
+	rec.Version = verman.V.GitVersion
+	if host, err := os.Hostname(); err == nil {
+		rec.HostName = host
+	}
+}
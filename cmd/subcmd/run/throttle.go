@@ -0,0 +1,74 @@
+// Package run 实现了 bakctl 备份的限速和低优先级功能。
+//
+// 为避免备份占满磁盘 I/O 而影响同一台机器上的其他服务，可以限制备份读取数据的速度：
+//   - 任务限速（read_limit）：限制单个任务每秒读取的字节数
+//   - 全局限速（run --read-limit）：限制本次 run 命令中所有任务合计每秒读取的字节数
+//   - 两者同时设置时，读取速度同时受两者的限制
+//
+// 限速作用于读取备份源文件（打包或写入仓库）和计算备份文件哈希值的过程。
+// 指定 --low-priority 时还会降低进程的 CPU 和 I/O 调度优先级（仅 Linux）。
+package run
+
+import (
+	"context"
+	"fmt"
+
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/bakctl/internal/utils"
+	"gitee.com/MM-Q/colorlib"
+)
+
+// applyRunThrottle 按 run 命令的参数降低调度优先级并设置全局限速，同时显示生效的设置
+//
+// 参数：
+//   - ctx：run 命令的上下文
+//   - cl：颜色库对象
+//
+// 返回值：
+//   - context.Context：附带全局限速器的上下文（未设置全局限速时为原上下文）
+func applyRunThrottle(ctx context.Context, cl *colorlib.ColorLib) context.Context {
+	if lowPriorityFlag.Get() {
+		if err := lowerPriority(); err != nil {
+			cl.Yellowf("无法启用低优先级模式: %v\n", err)
+		} else {
+			cl.Bluef("低优先级模式: 已降低 CPU 和 I/O 调度优先级 (%s)\n", lowPriorityDetail)
+		}
+	}
+
+	limit := readLimitFlag.Get()
+	if limit > 0 {
+		cl.Bluef("全局读取限速: %s\n", formatRate(limit))
+	}
+	return utils.WithRateLimiter(ctx, utils.NewRateLimiter(limit))
+}
+
+// throttleContext 返回附带任务读取限速器的上下文
+//
+// 参数：
+//   - ctx：任务的上下文
+//   - task：要执行的备份任务
+//
+// 返回值：
+//   - context.Context：附带任务限速器的上下文（任务未设置限速时为原上下文）
+func throttleContext(ctx context.Context, task types.BackupTask) context.Context {
+	return utils.WithRateLimiter(ctx, utils.NewRateLimiter(task.ReadLimit))
+}
+
+// readLimitNote 返回任务读取限速的说明，用于显示选中的任务列表
+//
+// 参数：
+//   - task：备份任务
+//
+// 返回值：
+//   - string：任务设置了限速时返回如 " [限速 10 MB/s]" 的说明，否则返回空字符串
+func readLimitNote(task types.BackupTask) string {
+	if task.ReadLimit <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" [限速 %s]", formatRate(task.ReadLimit))
+}
+
+// formatRate 格式化读取速度
+func formatRate(rate int64) string {
+	return utils.FormatBytes(rate) + "/s"
+}
@@ -0,0 +1,90 @@
+// Package run 实现了 bakctl 的分卷备份功能。
+//
+// 任务配置了分卷大小时，备份文件按分卷大小拆分为 name_YYYYMMDD_HHMMSS.zip.001、.002... 等分卷文件，
+// 便于复制到有单个文件大小限制的存储介质（如 FAT32 格式的 U 盘、对象存储）：
+//   - 每个分卷单独计算校验码并记录到数据库
+//   - 备份记录的存储路径为不带分卷序号的备份文件路径，清理和恢复时将所有分卷视为一个备份
+//   - 恢复时逐个校验分卷后按顺序合并，再解密和解压
+package run
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"gitee.com/MM-Q/bakctl/internal/archive"
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/bakctl/internal/utils"
+)
+
+// finishVolumes 收集分卷文件信息，成功后将临时分卷文件重命名为最终的分卷文件
+//
+// 参数：
+//   - ctx：上下文，被取消或超时后中止哈希计算
+//   - result：备份执行结果（写入分卷信息）
+//   - algorithm：哈希算法
+//   - showProgress：是否显示哈希计算进度条
+//
+// 返回值：
+//   - int64：所有分卷的总大小
+//   - error：如果收集信息或重命名失败，则返回错误信息（已重命名的分卷会被删除）
+func finishVolumes(ctx context.Context, result *types.BackupResult, algorithm string, showProgress bool) (total int64, err error) {
+	paths, err := archive.VolumeFiles(result.TempPath)
+	if err != nil {
+		return 0, err
+	}
+	if len(paths) == 0 {
+		return 0, fmt.Errorf("没有找到分卷文件: %s", result.TempPath)
+	}
+
+	// 1. 计算每个分卷的大小和校验码
+	volumes := make([]types.BackupVolume, 0, len(paths))
+	for i, path := range paths {
+		size, checksum, err := collectBackupInfo(ctx, path, algorithm, showProgress)
+		total += size
+		if err != nil {
+			return total, err
+		}
+		volumes = append(volumes, types.BackupVolume{Seq: i + 1, Size: size, Checksum: checksum})
+	}
+
+	// 2. 依次重命名分卷，失败时删除已重命名的分卷，避免留下不完整的分卷备份
+	for i, path := range paths {
+		if err := os.Rename(path, types.VolumePath(result.BackupPath, i+1)); err != nil {
+			for seq := 1; seq <= i; seq++ {
+				_ = os.Remove(types.VolumePath(result.BackupPath, seq))
+			}
+			return total, fmt.Errorf("重命名分卷文件失败: %w", err)
+		}
+	}
+
+	result.Volumes = volumes
+	return total, nil
+}
+
+// removeBackupFiles 删除备份文件及其所有分卷文件，文件不存在时忽略
+//
+// 参数：
+//   - path：备份文件路径（或写入中的临时文件路径）
+//
+// 返回值：
+//   - error：如果删除失败，则返回错误信息
+func removeBackupFiles(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := utils.RemoveIfExists(path); err != nil {
+		return err
+	}
+
+	volumes, err := archive.VolumeFiles(path)
+	if err != nil {
+		return err
+	}
+	for _, v := range volumes {
+		if err := utils.RemoveIfExists(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
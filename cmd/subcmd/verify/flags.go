@@ -0,0 +1,56 @@
+// Package verify 实现了 bakctl 的 verify 子命令的命令行参数解析功能。
+//
+// 该文件定义了 verify 命令支持的所有命令行标志和参数，包括：
+//   - 任务选择选项（单个任务、多个任务或所有任务）
+//   - 归档条目深度检查选项（包括解密加密备份使用的密钥文件和身份文件）
+//   - 输出格式选项
+package verify
+
+import (
+	"flag"
+
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/qflag"
+	"gitee.com/MM-Q/qflag/cmd"
+)
+
+var (
+	verifyCmd *qflag.Cmd // verify命令
+
+	// 任务选择
+	taskIDFlag   *qflag.Int64Flag      // 单个任务ID
+	taskIDsFlag  *qflag.Int64SliceFlag // 多个任务ID
+	allTasksFlag *qflag.BoolFlag       // 所有任务
+
+	// 校验选项
+	deepFlag     *qflag.BoolFlag   // 打开归档检查每个条目
+	keyFileFlag  *qflag.StringFlag // 密钥文件路径
+	identityFlag *qflag.StringFlag // 身份文件路径
+
+	// 输出选项
+	jsonFlag       *qflag.BoolFlag // 以JSON格式输出
+	tableStyleFlag *qflag.EnumFlag // 表格样式
+)
+
+// InitVerifyCmd 初始化verify子命令
+func InitVerifyCmd() *qflag.Cmd {
+	verifyCmd = cmd.NewCmd("verify", "vf", flag.ExitOnError)
+	verifyCmd.SetDesc("校验备份文件的完整性")
+	verifyCmd.SetChinese(true)
+
+	// 任务选择（互斥）
+	taskIDFlag = verifyCmd.Int64("", "id", 0, "校验指定任务的所有成功备份")
+	taskIDsFlag = verifyCmd.Int64Slice("", "ids", []int64{}, "校验多个任务的所有成功备份 (逗号分隔)")
+	allTasksFlag = verifyCmd.Bool("", "all", false, "校验所有任务的所有成功备份")
+
+	// 校验选项
+	deepFlag = verifyCmd.Bool("deep", "d", false, "打开归档读取每个条目, 检查内容校验并与文件清单中记录的哈希对比 (加密备份使用任务的密码或密钥文件解密)")
+	keyFileFlag = verifyCmd.String("key-file", "kf", "", "解密备份使用的密钥文件 (默认使用任务配置的密钥文件)")
+	identityFlag = verifyCmd.String("identity", "", "", "解密公钥加密的备份使用的身份文件 (由 keygen 命令生成)")
+
+	// 输出选项
+	jsonFlag = verifyCmd.Bool("json", "j", false, "以JSON格式输出校验结果")
+	tableStyleFlag = verifyCmd.Enum("table-style", "ts", "ro", "表格样式 (df, bd, cb, cd, de, lt, ro, none)", types.TableStyleList)
+
+	return verifyCmd
+}
@@ -0,0 +1,474 @@
+// Package verify 实现了 bakctl 的 verify 子命令功能。
+//
+// 该包提供了对备份目录的完整性检查，支持：
+//   - 校验单个任务、多个任务或所有任务的所有成功备份
+//   - 检查备份文件（包括分卷和仓库数据块）是否存在
+//   - 使用备份记录中的哈希算法重新计算校验码并与记录对比
+//   - 打开归档读取每个条目，检查归档内容的校验并与文件清单中记录的哈希对比（--deep），
+//     加密备份使用任务的密码或密钥文件解密，无法解密时结果记录为 skipped
+//
+// 每个备份版本的校验时间和结果记录在数据库中，发现问题时命令以非零状态退出，便于在定时任务中使用。
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gitee.com/MM-Q/bakctl/internal/archive"
+	"gitee.com/MM-Q/bakctl/internal/crypt"
+	DB "gitee.com/MM-Q/bakctl/internal/db"
+	"gitee.com/MM-Q/bakctl/internal/repo"
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/bakctl/internal/utils"
+	"gitee.com/MM-Q/colorlib"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/jmoiron/sqlx"
+)
+
+// VerifyResult 单个备份版本的校验结果
+type VerifyResult struct {
+	TaskID         int64  `json:"task_id"`           // 任务ID
+	TaskName       string `json:"task_name"`         // 任务名称
+	VersionID      string `json:"version_id"`        // 备份版本ID
+	BackupFilename string `json:"backup_filename"`   // 备份文件名
+	CreatedAt      string `json:"created_at"`        // 备份时间（UTC）
+	Result         string `json:"result"`            // 校验结果（ok/missing/mismatch/corrupt/skipped）
+	Message        string `json:"message,omitempty"` // 校验发现的问题或说明
+}
+
+// VerifyCmdMain verify命令的主函数
+//
+// 参数:
+//   - db: 数据库连接
+//   - cl: 颜色库
+//
+// 返回值:
+//   - error: 参数错误、查询失败或发现有问题的备份版本时返回错误信息
+func VerifyCmdMain(db *sqlx.DB, cl *colorlib.ColorLib) error {
+	// 1. 验证参数
+	if err := validateVerifyFlags(); err != nil {
+		return err
+	}
+
+	// 2. 选择要校验的任务
+	tasks, err := selectTasks(db)
+	if err != nil {
+		return err
+	}
+
+	// 3. 逐个校验任务的成功备份，并记录校验结果
+	ctx := context.Background()
+	results := make([]VerifyResult, 0)
+	for _, task := range tasks {
+		records, err := DB.GetSuccessfulBackupRecordsByTask(db, task.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, rec := range records {
+			result, message := verifyRecord(ctx, db, &task, rec, deepFlag.Get())
+			if err := DB.UpdateBackupRecordVerification(db, rec.ID, result, message); err != nil {
+				return err
+			}
+
+			results = append(results, VerifyResult{
+				TaskID:         rec.TaskID,         // 任务ID
+				TaskName:       rec.TaskName,       // 任务名称
+				VersionID:      rec.VersionID,      // 备份版本ID
+				BackupFilename: rec.BackupFilename, // 备份文件名
+				CreatedAt:      rec.CreatedAt,      // 备份时间
+				Result:         result,             // 校验结果
+				Message:        message,            // 校验发现的问题
+			})
+		}
+	}
+
+	// 4. 输出校验结果
+	if jsonFlag.Get() {
+		if err := printResultsJSON(results); err != nil {
+			return err
+		}
+	} else {
+		if len(results) == 0 {
+			cl.Yellow("没有需要校验的备份")
+			return nil
+		}
+		if err := renderResults(results); err != nil {
+			return err
+		}
+	}
+
+	// 5. 发现问题或未能检查归档条目时返回错误，使命令以非零状态退出
+	problems, skipped := 0, 0
+	for _, r := range results {
+		switch r.Result {
+		case types.VerifyResultOK:
+		case types.VerifyResultSkipped:
+			skipped++
+		default:
+			problems++
+		}
+	}
+	switch {
+	case problems > 0 && skipped > 0:
+		return fmt.Errorf("共校验 %d 个备份版本, 发现 %d 个有问题的备份版本, %d 个备份版本未能检查归档条目", len(results), problems, skipped)
+	case problems > 0:
+		return fmt.Errorf("共校验 %d 个备份版本, 发现 %d 个有问题的备份版本", len(results), problems)
+	case skipped > 0:
+		return fmt.Errorf("共校验 %d 个备份版本, %d 个备份版本未能检查归档条目", len(results), skipped)
+	}
+	if !jsonFlag.Get() {
+		cl.Greenf("共校验 %d 个备份版本, 全部完整\n", len(results))
+	}
+
+	return nil
+}
+
+// validateVerifyFlags 验证verify命令的标志参数
+func validateVerifyFlags() error {
+	paramCount := 0
+	if taskIDFlag.Get() != 0 {
+		if taskIDFlag.Get() < 0 {
+			return fmt.Errorf("任务ID必须为正数, 当前值: %d", taskIDFlag.Get())
+		}
+		paramCount++
+	}
+	if len(taskIDsFlag.Get()) > 0 {
+		for i, id := range taskIDsFlag.Get() {
+			if id <= 0 {
+				return fmt.Errorf("第%d个任务ID必须为正数: %d", i+1, id)
+			}
+		}
+		paramCount++
+	}
+	if allTasksFlag.Get() {
+		paramCount++
+	}
+
+	if paramCount == 0 {
+		return fmt.Errorf("必须指定 -id、-ids 或 --all 中的一个")
+	}
+	if paramCount > 1 {
+		return fmt.Errorf("-id、-ids 和 --all 不能同时使用")
+	}
+
+	return nil
+}
+
+// selectTasks 根据标志选择要校验的任务
+//
+// 参数:
+//   - db: 数据库连接
+//
+// 返回值:
+//   - []types.BackupTask: 选中的任务列表
+//   - error: 查询失败或没有找到任务时返回错误信息
+func selectTasks(db *sqlx.DB) ([]types.BackupTask, error) {
+	switch {
+	case taskIDFlag.Get() > 0:
+		task, err := DB.GetTaskByID(db, taskIDFlag.Get())
+		if err != nil {
+			return nil, fmt.Errorf("获取任务ID %d 失败: %w", taskIDFlag.Get(), err)
+		}
+		return []types.BackupTask{*task}, nil
+
+	case len(taskIDsFlag.Get()) > 0:
+		tasks, err := DB.GetTasksByIDs(db, taskIDsFlag.Get())
+		if err != nil {
+			return nil, fmt.Errorf("批量获取任务失败: %w", err)
+		}
+		if len(tasks) == 0 {
+			return nil, fmt.Errorf("没有找到指定的任务ID")
+		}
+		return tasks, nil
+
+	default:
+		tasks, err := DB.GetAllTasks(db)
+		if err != nil {
+			return nil, fmt.Errorf("获取所有任务失败: %w", err)
+		}
+		if len(tasks) == 0 {
+			return nil, fmt.Errorf("系统中没有配置任何备份任务")
+		}
+		return tasks, nil
+	}
+}
+
+// verifyRecord 校验单个备份版本
+//
+// 参数:
+//   - ctx: 上下文
+//   - db: 数据库连接
+//   - task: 备份所属的任务（解密加密备份时使用任务配置的密钥文件）
+//   - rec: 备份记录
+//   - deep: 是否打开归档检查每个条目
+//
+// 返回值:
+//   - string: 校验结果（ok/missing/mismatch/corrupt/skipped）
+//   - string: 校验发现的问题或说明
+func verifyRecord(ctx context.Context, db *sqlx.DB, task *types.BackupTask, rec types.BackupRecord, deep bool) (string, string) {
+	// 1. 确定需要校验的备份文件及其校验码
+	paths := []string{rec.StoragePath}
+	checksums := []string{rec.Checksum}
+	if rec.VolumeCount > 0 {
+		volumes, err := DB.GetBackupVolumesByVersion(db, rec.VersionID)
+		if err != nil {
+			return types.VerifyResultCorrupt, err.Error()
+		}
+		if len(volumes) != rec.VolumeCount {
+			return types.VerifyResultCorrupt, fmt.Sprintf("分卷记录不完整: 应有 %d 个分卷, 实际记录 %d 个", rec.VolumeCount, len(volumes))
+		}
+		paths, checksums = paths[:0], checksums[:0]
+		for _, v := range volumes {
+			paths = append(paths, types.VolumePath(rec.StoragePath, v.Seq))
+			checksums = append(checksums, v.Checksum)
+		}
+	}
+
+	// 2. 检查备份文件是否存在
+	var missing []string
+	for _, path := range paths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			missing = append(missing, path)
+		}
+	}
+	if len(missing) > 0 {
+		return types.VerifyResultMissing, "备份文件不存在: " + strings.Join(missing, ", ")
+	}
+
+	// 3. 重新计算校验码并与记录对比
+	for i, path := range paths {
+		if checksums[i] == "" {
+			continue
+		}
+		ok, actual, err := utils.VerifyChecksum(ctx, path, checksums[i], false)
+		if err != nil {
+			return types.VerifyResultCorrupt, fmt.Sprintf("计算校验码失败: %v", err)
+		}
+		if !ok {
+			return types.VerifyResultMismatch, fmt.Sprintf("%s 校验码不一致: 期望 %s, 实际 %s", path, checksums[i], actual)
+		}
+	}
+
+	// 4. 仓库快照检查引用的数据块，归档按需检查每个条目
+	if rec.IsRepository() {
+		return verifySnapshot(ctx, rec.StoragePath, deep)
+	}
+	if !deep {
+		return types.VerifyResultOK, ""
+	}
+	return verifyArchive(ctx, db, task, rec, paths)
+}
+
+// verifyArchive 打开归档读取每个条目，检查内容校验并与文件清单中记录的哈希对比
+//
+// 加密备份使用任务的密码或密钥文件解密；无法获取密码、密钥错误或无法识别归档格式时
+// 备份文件本身已通过校验，但归档条目未被检查，结果为 skipped。
+//
+// 参数:
+//   - ctx: 上下文
+//   - db: 数据库连接
+//   - task: 备份所属的任务
+//   - rec: 备份记录
+//   - paths: 备份文件（或所有分卷）的路径
+//
+// 返回值:
+//   - string: 校验结果（ok/corrupt/skipped）
+//   - string: 校验发现的问题或说明
+func verifyArchive(ctx context.Context, db *sqlx.DB, task *types.BackupTask, rec types.BackupRecord, paths []string) (string, string) {
+	// 加密备份的文件名带有加密扩展名，根据之前的扩展名识别归档格式
+	name := strings.TrimSuffix(rec.BackupFilename, types.EncryptedExt)
+	src := archive.Source{Paths: paths, Format: archive.DetectFormat(name)}
+	if src.Format == "" {
+		return types.VerifyResultSkipped, "无法识别归档格式, 未检查归档条目"
+	}
+
+	if name != rec.BackupFilename || rec.Encryption != "" {
+		if rec.Encryption == "" {
+			return types.VerifyResultSkipped, "备份记录中没有加密方案, 未检查归档条目"
+		}
+		secret, err := decryptSecret(rec.Encryption, task)
+		if err == nil {
+			err = checkSecret(paths[0], secret)
+		}
+		if err != nil {
+			return types.VerifyResultSkipped, "无法解密, 未检查归档条目: " + err.Error()
+		}
+		src.Secret = &secret
+	}
+
+	// 文件清单中记录了本次归档中的文件（新增和修改的文件）的内容哈希
+	files, err := DB.GetBackupFilesByVersion(db, rec.VersionID)
+	if err != nil {
+		return types.VerifyResultCorrupt, err.Error()
+	}
+	hashes := make(map[string]string, len(files))
+	for _, f := range files {
+		if f.Hash != "" && (f.ChangeType == types.ChangeTypeAdded || f.ChangeType == types.ChangeTypeModified) {
+			hashes[f.Path] = f.Hash
+		}
+	}
+
+	if _, err := archive.Test(ctx, src, hashes); err != nil {
+		return types.VerifyResultCorrupt, err.Error()
+	}
+
+	return types.VerifyResultOK, ""
+}
+
+// checkSecret 读取加密备份文件（或第一个分卷）的文件头，验证密码、密钥文件或身份文件
+//
+// 参数:
+//   - path: 加密的备份文件路径
+//   - secret: 密码、密钥文件或身份文件
+//
+// 返回值:
+//   - error: 密钥错误或无法读取密钥文件时返回错误信息
+func checkSecret(path string, secret crypt.Secret) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("打开加密文件失败: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	_, err = crypt.NewReader(f, secret)
+	return err
+}
+
+// decryptSecret 根据备份记录的加密方案获取解密使用的密码、密钥文件或身份文件
+//
+// 参数:
+//   - scheme: 备份记录的加密方案
+//   - task: 备份任务
+//
+// 返回值:
+//   - crypt.Secret: 密码、密钥文件或身份文件
+//   - error: 加密方案不受支持或无法获取密码时返回错误信息
+func decryptSecret(scheme string, task *types.BackupTask) (crypt.Secret, error) {
+	switch scheme {
+	case crypt.SchemeKeyFile:
+		keyFile := keyFileFlag.Get()
+		if keyFile == "" {
+			keyFile = task.KeyFile
+		}
+		if keyFile == "" {
+			return crypt.Secret{}, fmt.Errorf("备份文件使用密钥文件加密, 请使用 --key-file 指定密钥文件")
+		}
+		return crypt.Secret{KeyFile: keyFile}, nil
+	case crypt.SchemeRecipients:
+		if identityFlag.Get() == "" {
+			return crypt.Secret{}, fmt.Errorf("备份文件使用公钥加密, 请使用 --identity 指定身份文件")
+		}
+		return crypt.Secret{Identity: identityFlag.Get()}, nil
+	case crypt.SchemePassphrase:
+		passphrase, err := crypt.Passphrase(false)
+		if err != nil {
+			return crypt.Secret{}, err
+		}
+		return crypt.Secret{Passphrase: passphrase}, nil
+	default:
+		return crypt.Secret{}, fmt.Errorf("不支持的加密方案: %s", scheme)
+	}
+}
+
+// verifySnapshot 检查仓库快照引用的所有数据块
+//
+// 参数:
+//   - ctx: 上下文
+//   - snapshotPath: 快照文件路径
+//   - deep: 是否同时重新计算每个文件的内容哈希
+//
+// 返回值:
+//   - string: 校验结果（ok/missing/corrupt）
+//   - string: 校验发现的问题
+func verifySnapshot(ctx context.Context, snapshotPath string, deep bool) (string, string) {
+	snap, err := repo.LoadSnapshot(snapshotPath)
+	if err != nil {
+		return types.VerifyResultCorrupt, err.Error()
+	}
+	r, err := repo.OpenBySnapshot(snapshotPath)
+	if err != nil {
+		return types.VerifyResultCorrupt, err.Error()
+	}
+
+	check, err := r.Check(ctx, snap, deep)
+	if err != nil {
+		return types.VerifyResultCorrupt, err.Error()
+	}
+	if len(check.Missing) > 0 {
+		return types.VerifyResultMissing, fmt.Sprintf("%d 个数据块不存在: %s", len(check.Missing), summarize(check.Missing))
+	}
+	if len(check.Corrupt) > 0 {
+		return types.VerifyResultCorrupt, fmt.Sprintf("%d 个数据块或文件校验失败: %s", len(check.Corrupt), summarize(check.Corrupt))
+	}
+
+	return types.VerifyResultOK, ""
+}
+
+// summarize 返回列表的简短描述（最多列出3项）
+func summarize(items []string) string {
+	const maxItems = 3
+	if len(items) <= maxItems {
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprintf("%s 等", strings.Join(items[:maxItems], ", "))
+}
+
+// printResultsJSON 以JSON格式输出校验结果
+func printResultsJSON(results []VerifyResult) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(results); err != nil {
+		return fmt.Errorf("输出JSON失败: %w", err)
+	}
+	return nil
+}
+
+// renderResults 以表格形式显示校验结果
+//
+// 参数:
+//   - results: 校验结果
+//
+// 返回值:
+//   - error: 表格样式不存在时返回错误信息
+func renderResults(results []VerifyResult) error {
+	t := table.NewWriter()
+	if style, ok := types.TableStyle[tableStyleFlag.Get()]; ok {
+		t.SetStyle(style)
+	} else {
+		return fmt.Errorf("表格样式不存在: %s, 可选样式: %v", tableStyleFlag.Get(), types.TableStyleList)
+	}
+	t.SetOutputMirror(os.Stdout)
+
+	t.AppendHeader(table.Row{"任务ID", "任务名称", "版本ID", "备份时间", "结果", "说明"})
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Name: "任务ID", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
+		{Name: "任务名称", Align: text.AlignLeft, WidthMaxEnforcer: text.WrapHard},
+		{Name: "版本ID", Align: text.AlignLeft, WidthMaxEnforcer: text.WrapHard},
+		{Name: "备份时间", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
+		{Name: "结果", Align: text.AlignCenter, WidthMaxEnforcer: text.WrapHard},
+		{Name: "说明", Align: text.AlignLeft, WidthMax: 60, WidthMaxEnforcer: text.WrapHard},
+	})
+
+	for _, r := range results {
+		message := r.Message
+		if message == "" {
+			message = "---"
+		}
+		t.AppendRow(table.Row{
+			r.TaskID,                             // 任务ID
+			r.TaskName,                           // 任务名称
+			r.VersionID,                          // 版本ID
+			utils.ConvertUTCToLocal(r.CreatedAt), // 备份时间
+			r.Result,                             // 结果
+			message,                              // 说明
+		})
+	}
+
+	t.Render()
+	return nil
+}
@@ -0,0 +1,472 @@
+// Package archive 实现了 bakctl 自有的归档功能。
+//
+// comprx 只能一次性打包整个源目录，无法只打包其中的部分文件，
+// 该包补充了按文件列表打包的能力，用于增量备份等场景：
+//   - Collect: 按照任务的过滤规则遍历源目录，收集待归档的条目
+//   - CollectWithSkipped: 同时收集被过滤器跳过的条目及跳过原因，用于预览文件选择
+//   - CollectSources: 遍历多个源路径，每个源路径位于归档内各自的顶层目录下
+//   - CollectSourcesWithSkipped: 遍历多个源路径并收集被跳过的条目，可按 .gitignore 语义应用源目录中的忽略文件，
+//     按错误处理策略跳过无法读取的条目
+//   - WriteZip: 将指定的条目写入 ZIP 文件，并在写入的同时计算文件内容哈希
+//   - WriteTar: 将指定的条目写入 tar/tar.gz/tar.bz2 文件，保留文件的权限、属主和修改时间
+//   - Write: 创建备份文件，按任务的归档格式选择 WriteZip 或 WriteTar，可选加密和分卷写入
+//   - Extract: 将备份文件解压到目标目录，按原样重建符号链接和硬链接
+//
+// 归档内的路径规则与 comprx 保持一致（保留源目录的顶层目录名），
+// 因此生成的归档可以直接使用 comprx 解压。多个源路径的顶层名称相同时，
+// 通过 SourcePrefixes 添加序号区分。
+package archive
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gitee.com/MM-Q/bakctl/internal/filter"
+	"gitee.com/MM-Q/bakctl/internal/types"
+)
+
+// Entry 待归档的条目
+type Entry struct {
+	Path string      // 源文件的绝对路径
+	Name string      // 归档内的相对路径（使用正斜杠分隔，保留顶层目录名）
+	Info fs.FileInfo // 文件信息
+}
+
+// IsRegular 判断条目是否为普通文件
+func (e Entry) IsRegular() bool {
+	return e.Info.Mode().IsRegular()
+}
+
+// Skipped 被过滤器跳过的条目
+type Skipped struct {
+	Entry         // 条目信息（被跳过的目录不再继续遍历其子条目）
+	Reason string // 跳过原因
+	Warn   bool   // 是否需要警告（如无法备份的特殊文件和形成循环的符号链接）
+}
+
+// Collect 遍历源路径，收集所有未被过滤器跳过的条目
+//
+// 过滤语义：
+//   - 不带前缀的规则使用绝对路径进行匹配（与 comprx.PackOptions 一致），带前缀的规则使用相对于源路径的路径进行匹配
+//   - 被跳过的目录不再继续遍历
+//   - 存在带前缀的包含规则时，只保留匹配包含规则或其中有被包含条目的目录
+//
+// 参数:
+//   - src: 源路径（目录或单个文件）
+//   - flt: 过滤器（可为 nil）
+//
+// 返回值:
+//   - []Entry: 条目列表（按遍历顺序排列，目录在其子条目之前）
+//   - error: 遍历失败时返回错误信息
+func Collect(src string, flt *filter.Filter) ([]Entry, error) {
+	entries, _, err := scan(src, "", flt, "", types.SymlinkStore, nil, false)
+	return entries, err
+}
+
+// CollectWithSkipped 遍历源路径，同时收集被过滤器跳过的条目及其跳过原因
+//
+// 参数:
+//   - src: 源路径（目录或单个文件）
+//   - flt: 过滤器（可为 nil）
+//
+// 返回值:
+//   - []Entry: 未被跳过的条目列表
+//   - []Skipped: 被跳过的条目列表
+//   - error: 遍历失败时返回错误信息
+func CollectWithSkipped(src string, flt *filter.Filter) ([]Entry, []Skipped, error) {
+	return scan(src, "", flt, "", types.SymlinkStore, nil, true)
+}
+
+// CollectSources 遍历多个源路径，收集所有未被过滤器跳过的条目
+//
+// 每个源路径的条目位于归档内各自的顶层名称下，顶层名称由 SourcePrefixes 确定。
+//
+// 参数:
+//   - srcs: 源路径列表（目录或单个文件）
+//   - flt: 过滤器（可为 nil）
+//
+// 返回值:
+//   - []Entry: 条目列表（按源路径顺序排列）
+//   - error: 遍历失败时返回错误信息
+func CollectSources(srcs []string, flt *filter.Filter) ([]Entry, error) {
+	entries, _, err := scanSources(srcs, flt, "", types.SymlinkStore, nil, false)
+	return entries, err
+}
+
+// CollectSourcesWithSkipped 遍历多个源路径，同时收集被过滤器跳过的条目及其跳过原因
+//
+// 指定了忽略文件名时，源目录及其子目录中的同名文件按 .gitignore 的语义生效
+// （支持 ! 否定规则、以 / 开头或包含 / 的锚定规则、以 / 结尾的目录规则和 **），
+// 只作用于所在目录内的条目，在过滤器之后判断。
+//
+// 符号链接按处理策略处理：store 存储链接本身，follow 跟随链接备份其指向的文件或目录
+// （跳过指向当前遍历路径上的目录、会形成循环的链接），skip 跳过链接。
+//
+// 遍历过程中无法读取的条目（权限不足、被删除）按错误处理策略处理：tol 为 nil 时中止遍历，
+// 否则跳过该条目（无法读取的目录跳过其中的内容）并记录到 tol 中。
+//
+// 参数:
+//   - srcs: 源路径列表（目录或单个文件）
+//   - flt: 过滤器（可为 nil）
+//   - ignoreFile: 忽略文件名，如 .bakignore（为空时不使用忽略文件）
+//   - symlinks: 符号链接处理策略（store/follow/skip）
+//   - tol: 错误处理策略（为 nil 时任何错误都中止遍历）
+//
+// 返回值:
+//   - []Entry: 未被跳过的条目列表
+//   - []Skipped: 被跳过的条目列表
+//   - error: 遍历失败或忽略文件无效时返回错误信息
+func CollectSourcesWithSkipped(srcs []string, flt *filter.Filter, ignoreFile, symlinks string, tol *Tolerance) ([]Entry, []Skipped, error) {
+	return scanSources(srcs, flt, ignoreFile, symlinks, tol, true)
+}
+
+// scanSources 依次遍历多个源路径，每个源路径使用各自的顶层名称
+func scanSources(srcs []string, flt *filter.Filter, ignoreFile, symlinks string, tol *Tolerance, explain bool) ([]Entry, []Skipped, error) {
+	var entries []Entry
+	var skipped []Skipped
+	for i, prefix := range SourcePrefixes(srcs) {
+		e, s, err := scan(srcs[i], prefix, flt, ignoreFile, symlinks, tol, explain)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, e...)
+		skipped = append(skipped, s...)
+	}
+	return entries, skipped, nil
+}
+
+// SourcePrefixes 返回各源路径在归档内的顶层名称
+//
+// 顶层名称为源路径的最后一级名称（与 comprx 一致）；名称重复时依次添加序号，
+// 如 /a/config 和 /b/config 分别为 config 和 config_2。
+//
+// 参数:
+//   - srcs: 源路径列表
+//
+// 返回值:
+//   - []string: 与源路径一一对应的顶层名称
+func SourcePrefixes(srcs []string) []string {
+	prefixes := make([]string, len(srcs))
+	used := make(map[string]bool, len(srcs))
+	for i, src := range srcs {
+		base := filepath.Base(filepath.Clean(src))
+		if base == string(filepath.Separator) || base == "." || strings.HasSuffix(base, ":") {
+			base = "root" // 根目录或盘符没有名称
+		}
+
+		prefix := base
+		for n := 2; used[strings.ToLower(prefix)]; n++ {
+			prefix = fmt.Sprintf("%s_%d", base, n)
+		}
+		used[strings.ToLower(prefix)] = true // 忽略大小写，避免在不区分大小写的文件系统上冲突
+		prefixes[i] = prefix
+	}
+	return prefixes
+}
+
+// scan 遍历源路径，按过滤器对条目进行分类
+//
+// 符号链接按处理策略存储、跟随或跳过；设备文件、套接字和管道等特殊文件无法备份，
+// 未被过滤器跳过时记录为需要警告的跳过条目。
+//
+// 参数:
+//   - src: 源路径（目录或单个文件）
+//   - prefix: 归档内的顶层名称（为空时使用源路径的最后一级名称）
+//   - flt: 过滤器（可为 nil）
+//   - ignoreFile: 忽略文件名（为空时不使用忽略文件）
+//   - symlinks: 符号链接处理策略（store/follow/skip，为空时存储链接本身）
+//   - tol: 错误处理策略（为 nil 时任何错误都中止遍历）
+//   - explain: 是否收集被跳过的条目及原因
+//
+// 返回值:
+//   - []Entry: 未被跳过的条目列表
+//   - []Skipped: 被跳过的条目列表（explain 为 false 时为 nil）
+//   - error: 遍历失败时返回错误信息
+func scan(src, prefix string, flt *filter.Filter, ignoreFile, symlinks string, tol *Tolerance, explain bool) ([]Entry, []Skipped, error) {
+	src, err := filepath.Abs(src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取源路径的绝对路径失败: %w", err)
+	}
+	if prefix == "" {
+		prefix = filepath.Base(src)
+	}
+
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取源路径信息失败: %w", err)
+	}
+
+	s := &scanner{
+		src:      src,
+		prefix:   prefix,
+		flt:      flt,
+		symlinks: symlinks,
+		tol:      tol,
+		explain:  explain,
+		pending:  make(map[string]bool),
+	}
+
+	// 单文件直接判断
+	if !srcInfo.IsDir() {
+		err := s.visit(Entry{Path: src, Name: prefix, Info: srcInfo}, src, filepath.Base(src))
+		return s.entries, s.skipped, err
+	}
+
+	if ignoreFile != "" {
+		s.ignores = newIgnoreMatcher(src, ignoreFile)
+	}
+
+	// 源路径本身是符号链接时遍历其指向的目录
+	root, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析源路径失败: %w", err)
+	}
+	if err := s.walk(root, "", nil); err != nil {
+		return nil, nil, err
+	}
+
+	entries, skipped := s.entries, s.skipped
+	if len(s.pending) > 0 {
+		var dropped []Skipped
+		entries, dropped = dropPending(entries, s.pending)
+		if explain {
+			skipped = append(skipped, dropped...)
+		}
+	}
+
+	return entries, skipped, nil
+}
+
+// scanner 遍历单个源路径时的状态
+type scanner struct {
+	src      string          // 源路径的绝对路径
+	prefix   string          // 归档内的顶层名称
+	flt      *filter.Filter  // 过滤器（可为 nil）
+	ignores  *ignoreMatcher  // 忽略文件匹配器（可为 nil）
+	symlinks string          // 符号链接处理策略
+	tol      *Tolerance      // 错误处理策略（可为 nil）
+	explain  bool            // 是否收集被跳过的条目及原因
+	entries  []Entry         // 未被跳过的条目
+	skipped  []Skipped       // 被跳过的条目
+	pending  map[string]bool // 只在其中有被包含的条目时才保留的目录
+}
+
+// walk 遍历目录中的条目
+//
+// 跟随指向目录的符号链接时，链接指向的目录按链接所在的位置遍历：
+// 条目使用链接路径匹配过滤规则和忽略文件，归档内也使用链接路径，只有读取时使用实际路径。
+//
+// 参数:
+//   - dir: 实际遍历的目录
+//   - rel: 目录相对于源路径的路径（使用正斜杠分隔，源路径本身为空）
+//   - parents: 当前遍历路径上已跟随的符号链接所在目录的实际路径（用于检测循环链接）
+//
+// 返回值:
+//   - error: 遍历失败时返回错误信息
+func (s *scanner) walk(dir, rel string, parents []string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, walkErr error) error {
+		// 获取相对路径，以顶层名称作为前缀
+		sub, err := filepath.Rel(dir, path)
+		if err != nil {
+			return fmt.Errorf("获取 '%s' 的相对路径失败: %w", path, err)
+		}
+		relPath := rel
+		if sub != "." {
+			relPath = strings.TrimPrefix(rel+"/"+filepath.ToSlash(sub), "/")
+		}
+		absPath, name := s.src, s.prefix
+		if relPath != "" {
+			absPath = filepath.Join(s.src, filepath.FromSlash(relPath))
+			name = s.prefix + "/" + relPath
+		}
+
+		if walkErr != nil {
+			// 遍历过程中被删除的文件直接忽略
+			if os.IsNotExist(walkErr) {
+				return nil
+			}
+			// 无法列出内容的目录仍然保留目录本身，跳过其中的内容
+			if d != nil && d.IsDir() {
+				if s.tol.warn(name, walkErr) {
+					return nil
+				}
+			} else if s.tol.Skip(name, walkErr) {
+				return nil
+			}
+			return fmt.Errorf("遍历路径 '%s' 时出错: %w", path, walkErr)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if s.tol.Skip(name, err) {
+				return nil
+			}
+			return fmt.Errorf("获取 '%s' 的文件信息失败: %w", path, err)
+		}
+		entry := Entry{Path: path, Name: name, Info: info}
+
+		// 按处理策略处理符号链接
+		if info.Mode()&fs.ModeSymlink != 0 {
+			switch s.symlinks {
+			case types.SymlinkSkip:
+				s.skip(entry, "符号链接 (处理策略为 skip)", false)
+				return nil
+			case types.SymlinkFollow:
+				target, err := os.Stat(path)
+				if err != nil {
+					break // 链接目标不存在或无法访问时存储链接本身
+				}
+				if target.IsDir() {
+					return s.follow(entry, relPath, parents)
+				}
+				entry.Info = target
+			}
+		}
+
+		return s.visit(entry, absPath, relPath)
+	})
+}
+
+// follow 跟随指向目录的符号链接
+//
+// 链接指向当前遍历路径上的目录（包括链接所在的目录及其上级目录）时，跟随会形成循环，
+// 跳过该链接并记录为需要警告的跳过条目。
+//
+// 参数:
+//   - entry: 符号链接条目
+//   - rel: 符号链接相对于源路径的路径
+//   - parents: 当前遍历路径上已跟随的符号链接所在目录的实际路径
+//
+// 返回值:
+//   - error: 解析链接或遍历失败时返回错误信息
+func (s *scanner) follow(entry Entry, rel string, parents []string) error {
+	target, err := filepath.EvalSymlinks(entry.Path)
+	if err != nil {
+		if s.tol.Skip(entry.Name, err) {
+			return nil
+		}
+		return fmt.Errorf("解析符号链接 '%s' 失败: %w", entry.Path, err)
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(entry.Path))
+	if err != nil {
+		return fmt.Errorf("解析 '%s' 的实际路径失败: %w", filepath.Dir(entry.Path), err)
+	}
+
+	parents = append(parents[:len(parents):len(parents)], parent)
+	for _, dir := range parents {
+		if within(dir, target) {
+			s.skip(entry, fmt.Sprintf("符号链接指向上级目录 '%s', 跟随会形成循环", target), true)
+			return nil
+		}
+	}
+
+	return s.walk(target, rel, parents)
+}
+
+// visit 按过滤器和忽略文件判断条目是否被跳过，未被跳过的条目加入条目列表
+//
+// 参数:
+//   - entry: 条目
+//   - absPath: 条目的绝对路径（跟随符号链接时为链接所在的路径，用于匹配规则）
+//   - rel: 条目相对于源路径的路径
+//
+// 返回值:
+//   - error: 目录被跳过时返回 filepath.SkipDir，加载忽略文件失败时返回错误信息
+func (s *scanner) visit(entry Entry, absPath, rel string) error {
+	isDir := entry.Info.IsDir()
+
+	// 应用过滤器
+	if s.flt != nil {
+		if reason := s.flt.Reason(absPath, rel, entry.Info); reason != "" {
+			s.skip(entry, reason, false)
+			return skipDir(isDir)
+		}
+		if isDir && s.flt.Pending(absPath, rel) {
+			s.pending[entry.Name] = true
+		}
+	}
+
+	// 应用忽略文件中的规则
+	if s.ignores != nil {
+		if rule := s.ignores.match(absPath, isDir); rule != nil && !rule.negate {
+			s.skip(entry, rule.reason(), false)
+			return skipDir(isDir)
+		}
+		if isDir {
+			if err := s.ignores.load(absPath, entry.Path, entry.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 特殊文件没有可以备份的内容，也无法可靠地恢复
+	mode := entry.Info.Mode()
+	if !isDir && !mode.IsRegular() && mode&fs.ModeSymlink == 0 {
+		kind := filter.FileType(mode)
+		if kind == "" {
+			kind = "其他"
+		}
+		s.skip(entry, fmt.Sprintf("不支持备份的特殊文件 (%s)", kind), true)
+		return nil
+	}
+
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+// skip 记录被跳过的条目
+func (s *scanner) skip(entry Entry, reason string, warn bool) {
+	if s.explain {
+		s.skipped = append(s.skipped, Skipped{Entry: entry, Reason: reason, Warn: warn})
+	}
+}
+
+// skipDir 被跳过的条目为目录时返回 filepath.SkipDir，不再遍历其子条目
+func skipDir(isDir bool) error {
+	if isDir {
+		return filepath.SkipDir
+	}
+	return nil
+}
+
+// within 判断路径是否为目录本身或位于目录之中
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// dropPending 移除其中没有任何被包含条目的待定目录
+//
+// 参数:
+//   - entries: 条目列表（目录在其子条目之前）
+//   - pending: 待定目录在归档内的路径
+//
+// 返回值:
+//   - []Entry: 保留的条目列表
+//   - []Skipped: 被移除的目录
+func dropPending(entries []Entry, pending map[string]bool) ([]Entry, []Skipped) {
+	used := make(map[string]bool)
+	for _, e := range entries {
+		if pending[e.Name] {
+			continue
+		}
+		for dir := path.Dir(e.Name); dir != "." && !used[dir]; dir = path.Dir(dir) {
+			used[dir] = true
+		}
+	}
+
+	kept := entries[:0]
+	var dropped []Skipped
+	for _, e := range entries {
+		if pending[e.Name] && !used[e.Name] {
+			dropped = append(dropped, Skipped{Entry: e, Reason: "未匹配任何包含规则"})
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept, dropped
+}
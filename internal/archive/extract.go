@@ -0,0 +1,537 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/bakctl/internal/utils"
+)
+
+// maxSymlinkTarget zip 归档中符号链接目标的最大长度（字节）
+const maxSymlinkTarget = 4096
+
+// Extract 将备份文件解压到目标目录
+//
+// 按条目类型恢复目录、普通文件、符号链接和硬链接：符号链接按原样重建（不检查链接目标是否存在），
+// 硬链接重建为指向归档内同一文件的链接。写入前逐级检查条目路径中的目录，
+// 拒绝指向目标目录之外的路径和经过符号链接的路径，避免归档中的符号链接将之后的条目写到目标目录之外。
+// 设备文件、管道等特殊文件（早期版本的 tar 备份中可能包含）无法恢复，直接跳过。
+// 指定了需要恢复的元数据时，每个条目恢复后重新应用归档中记录的属主、权限、扩展属性和时间，
+// 元数据恢复失败不会中止解压，失败的条目及原因通过返回值报告。
+// 需要依次解压多个归档（如回放增量备份链）时使用 Extractor。
+//
+// 参数:
+//   - ctx: 上下文，被取消后立即停止解压
+//   - src: 备份文件（分卷备份按顺序传入所有分卷，直接在原位置读取；加密的 tar 备份边读取边解密，
+//     加密的 zip 备份解密到目标目录中的临时文件，解压完成后删除）
+//   - targetDir: 目标目录（不存在时自动创建）
+//   - overwrite: 是否覆盖已存在的文件（不覆盖时遇到已存在的文件返回错误）
+//   - preserve: 需要恢复的元数据
+//   - progress: 解压进度（接收写入的文件内容，为 nil 时不显示）
+//
+// 返回值:
+//   - int: 恢复的文件数（普通文件、符号链接和硬链接）
+//   - []MetadataError: 元数据未能恢复的条目
+//   - error: 归档格式无法识别、归档损坏或写入失败时返回错误信息
+func Extract(ctx context.Context, src Source, targetDir string, overwrite bool, preserve Preserve, progress io.Writer) (int, []MetadataError, error) {
+	x, err := NewExtractor(ctx, targetDir, preserve)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := x.Extract(src, overwrite, progress); err != nil {
+		return x.files, x.metaErrs, err
+	}
+
+	files, metaErrs := x.Finish()
+	return files, metaErrs, nil
+}
+
+// Extractor 将一个或多个归档依次解压到同一目标目录
+//
+// 目录的权限和修改时间在所有归档解压完成后（调用 Finish 时）才设置，
+// 只读目录在回放之后的增量版本时仍然可以写入，每个目录只设置一次最后一个版本中记录的元数据。
+type Extractor struct {
+	ctx       context.Context         // 上下文
+	root      string                  // 目标目录的绝对路径
+	overwrite bool                    // 是否覆盖已存在的文件
+	preserve  Preserve                // 需要恢复的元数据
+	progress  io.Writer               // 解压进度（可为 nil）
+	files     int                     // 已恢复的文件数
+	dirs      map[string]extractedDir // 已创建的目录（所有归档解压完成后再设置权限和修改时间），键为目录路径
+	metaErrs  []MetadataError         // 元数据未能恢复的条目
+}
+
+// extractedDir 解压时创建的目录
+type extractedDir struct {
+	name string   // 归档内路径
+	path string   // 目录路径
+	meta Metadata // 归档中记录的元数据
+}
+
+// NewExtractor 创建解压到目标目录的解压器
+//
+// 参数:
+//   - ctx: 上下文，被取消后立即停止解压
+//   - targetDir: 目标目录（不存在时自动创建）
+//   - preserve: 需要恢复的元数据
+//
+// 返回值:
+//   - *Extractor: 解压器
+//   - error: 创建目标目录失败时返回错误信息
+func NewExtractor(ctx context.Context, targetDir string, preserve Preserve) (*Extractor, error) {
+	root, err := filepath.Abs(targetDir)
+	if err != nil {
+		return nil, fmt.Errorf("获取目标目录的绝对路径失败: %w", err)
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("创建目标目录失败: %w", err)
+	}
+
+	return &Extractor{ctx: ctx, root: root, preserve: preserve, dirs: make(map[string]extractedDir)}, nil
+}
+
+// Extract 将备份文件解压到目标目录
+//
+// 参数:
+//   - src: 备份文件（分卷备份按顺序传入所有分卷，直接在原位置读取；加密的 tar 备份边读取边解密，
+//     加密的 zip 备份解密到目标目录中的临时文件，解压完成后删除）
+//   - overwrite: 是否覆盖已存在的文件（不覆盖时遇到已存在的文件返回错误）
+//   - progress: 解压进度（接收写入的文件内容，为 nil 时不显示）
+//
+// 返回值:
+//   - error: 归档格式无法识别、归档损坏或写入失败时返回错误信息
+func (x *Extractor) Extract(src Source, overwrite bool, progress io.Writer) error {
+	mr, closeAll, err := src.open()
+	if err != nil {
+		return err
+	}
+	defer closeAll()
+
+	x.overwrite, x.progress = overwrite, progress
+	switch src.Format {
+	case types.FormatZip:
+		r, size, cleanup, err := src.zipReader(x.ctx, mr, x.root)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		return x.extractZip(r, size)
+	case types.FormatTar, types.FormatTarGz, types.FormatTarBz2:
+		r, err := src.stream(mr)
+		if err != nil {
+			return err
+		}
+		return x.extractTar(r, src.Format)
+	default:
+		return fmt.Errorf("不支持的归档格式: %s", src.Format)
+	}
+}
+
+// Finish 在所有归档解压完成后设置目录的权限和修改时间（从最深的目录开始）
+//
+// 之后的版本中已被删除或替换为其他类型的目录不再处理。
+//
+// 返回值:
+//   - int: 恢复的文件数（普通文件、符号链接和硬链接）
+//   - []MetadataError: 元数据未能恢复的条目
+func (x *Extractor) Finish() (int, []MetadataError) {
+	dirs := make([]extractedDir, 0, len(x.dirs))
+	for _, d := range x.dirs {
+		dirs = append(dirs, d)
+	}
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].path > dirs[j].path })
+
+	for _, d := range dirs {
+		if info, err := os.Lstat(d.path); err != nil || !info.IsDir() {
+			continue
+		}
+		_ = os.Chmod(d.path, d.meta.Mode.Perm())
+		_ = os.Chtimes(d.path, d.meta.ModTime, d.meta.ModTime)
+		x.applyMetadata(d.name, d.path, d.meta, false)
+	}
+	x.dirs = make(map[string]extractedDir)
+
+	return x.files, x.metaErrs
+}
+
+// extractTar 解压 tar、tar.gz 或 tar.bz2 归档
+func (x *Extractor) extractTar(src io.Reader, format string) error {
+	r, release, err := newTarReader(src, format)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	tr := tar.NewReader(r)
+	for {
+		if err := x.ctx.Err(); err != nil {
+			return err
+		}
+
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return drainTar(r, src)
+		}
+		if err != nil {
+			return fmt.Errorf("读取 tar 归档失败: %w", err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = x.mkdir(hdr.Name, tarMetadata(hdr))
+		case tar.TypeReg:
+			err = x.writeFile(hdr.Name, tr, tarMetadata(hdr))
+		case tar.TypeSymlink:
+			err = x.symlink(hdr.Name, hdr.Linkname, tarMetadata(hdr))
+		case tar.TypeLink:
+			err = x.hardlink(hdr.Name, hdr.Linkname)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// drainTar 读取 tar 归档结尾之后的剩余数据
+//
+// 使 gzip 校验整个数据流的 CRC32，加密的备份验证最后一个加密块，
+// 避免被截断或篡改的备份在读取到归档结尾后被当作完整的备份。
+//
+// 参数:
+//   - r: 解压后的数据流
+//   - src: 原始数据流（加密的备份为解密后的数据流）
+//
+// 返回值:
+//   - error: 数据流损坏或不完整时返回错误信息
+func drainTar(r, src io.Reader) error {
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return fmt.Errorf("归档数据校验失败: %w", err)
+	}
+	if _, err := io.Copy(io.Discard, src); err != nil {
+		return fmt.Errorf("归档数据校验失败: %w", err)
+	}
+	return nil
+}
+
+// extractZip 解压 zip 归档
+func (x *Extractor) extractZip(ra io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return fmt.Errorf("读取 zip 归档失败: %w", err)
+	}
+
+	for _, f := range zr.File {
+		if err := x.ctx.Err(); err != nil {
+			return err
+		}
+
+		mode := f.Mode()
+		meta := zipMetadata(mode, f.FileInfo().ModTime(), f.Extra)
+		switch {
+		case mode.IsDir():
+			err = x.mkdir(f.Name, meta)
+		case mode.IsRegular():
+			if link := zipHardlink(f.Extra); link != "" {
+				err = x.hardlink(f.Name, link)
+			} else {
+				err = x.extractZipFile(f, meta)
+			}
+		case mode&fs.ModeSymlink != 0:
+			var target string
+			if target, err = readZipSymlink(f); err == nil {
+				err = x.symlink(f.Name, target, meta)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractZipFile 解压 zip 归档中的普通文件
+func (x *Extractor) extractZipFile(f *zip.File, meta Metadata) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("打开归档条目 %s 失败: %w", f.Name, err)
+	}
+	defer func() { _ = rc.Close() }()
+	return x.writeFile(f.Name, rc, meta)
+}
+
+// readZipSymlink 读取 zip 归档中符号链接的目标（存储为条目内容）
+func readZipSymlink(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", fmt.Errorf("打开归档条目 %s 失败: %w", f.Name, err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	target, err := io.ReadAll(io.LimitReader(rc, maxSymlinkTarget+1))
+	if err != nil {
+		return "", fmt.Errorf("读取符号链接 %s 失败: %w", f.Name, err)
+	}
+	if len(target) == 0 || len(target) > maxSymlinkTarget {
+		return "", fmt.Errorf("符号链接 %s 的目标无效", f.Name)
+	}
+	return string(target), nil
+}
+
+// mkdir 创建目录条目，已存在的目录直接使用
+//
+// 覆盖模式下已存在的同名文件或符号链接（之前版本中的条目类型已变化）先删除再创建目录。
+func (x *Extractor) mkdir(name string, meta Metadata) error {
+	p, err := x.resolve(name)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(p)
+	switch {
+	case err != nil && !os.IsNotExist(err):
+		return fmt.Errorf("获取 %s 的文件信息失败: %w", p, err)
+	case err == nil && !info.IsDir():
+		if !x.overwrite {
+			return fmt.Errorf("无法创建目录 %s: 已存在同名的非目录条目", p)
+		}
+		if err := os.Remove(p); err != nil {
+			return fmt.Errorf("删除已存在的文件 %s 失败: %w", p, err)
+		}
+		fallthrough
+	case os.IsNotExist(err):
+		if err := os.Mkdir(p, 0755); err != nil {
+			return fmt.Errorf("创建目录 %s 失败: %w", p, err)
+		}
+	}
+
+	x.dirs[p] = extractedDir{name: name, path: p, meta: meta}
+	return nil
+}
+
+// writeFile 写入普通文件条目
+func (x *Extractor) writeFile(name string, r io.Reader, meta Metadata) (err error) {
+	p, err := x.prepare(name)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, meta.Mode.Perm())
+	if err != nil {
+		return fmt.Errorf("创建文件 %s 失败: %w", p, err)
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("关闭文件 %s 失败: %w", p, closeErr)
+		}
+		if err == nil {
+			_ = os.Chtimes(p, meta.ModTime, meta.ModTime)
+			x.applyMetadata(name, p, meta, false)
+		}
+	}()
+
+	var w io.Writer = f
+	if x.progress != nil {
+		w = io.MultiWriter(f, x.progress)
+	}
+	if _, err := io.Copy(w, utils.NewContextReader(x.ctx, r)); err != nil {
+		return fmt.Errorf("写入文件 %s 失败: %w", p, err)
+	}
+
+	x.files++
+	return nil
+}
+
+// symlink 按原样重建符号链接条目
+func (x *Extractor) symlink(name, target string, meta Metadata) error {
+	p, err := x.prepare(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Symlink(target, p); err != nil {
+		return fmt.Errorf("创建符号链接 %s 失败: %w", p, err)
+	}
+	x.applyMetadata(name, p, meta, true)
+
+	x.files++
+	return nil
+}
+
+// hardlink 重建指向归档内已解压文件的硬链接条目
+func (x *Extractor) hardlink(name, target string) error {
+	old, err := x.resolve(target)
+	if err != nil {
+		return err
+	}
+	p, err := x.prepare(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Link(old, p); err != nil {
+		return fmt.Errorf("创建硬链接 %s 失败: %w", p, err)
+	}
+
+	x.files++
+	return nil
+}
+
+// prepare 返回条目的写入路径，覆盖模式下删除已存在的文件
+//
+// 已存在的文件先删除再重新创建（而不是直接覆盖内容），
+// 避免写入已存在的符号链接指向的文件，或改变与之共享内容的其他硬链接。
+// 覆盖模式下已存在的同名空目录（之前版本中的条目类型已变化）同样被删除，非空目录不会被删除。
+func (x *Extractor) prepare(name string) (string, error) {
+	p, err := x.resolve(name)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Lstat(p)
+	switch {
+	case os.IsNotExist(err):
+		return p, nil
+	case err != nil:
+		return "", fmt.Errorf("获取 %s 的文件信息失败: %w", p, err)
+	case info.IsDir() && (!x.overwrite || isDirNotEmpty(p)):
+		return "", fmt.Errorf("无法恢复 %s: 已存在同名的目录", p)
+	case !x.overwrite:
+		return "", fmt.Errorf("目标文件已存在且不允许覆盖: %s", p)
+	}
+
+	if err := os.Remove(p); err != nil {
+		return "", fmt.Errorf("删除已存在的文件 %s 失败: %w", p, err)
+	}
+	delete(x.dirs, p)
+	return p, nil
+}
+
+// resolve 返回条目在目标目录中的路径，并创建其所在的各级目录
+func (x *Extractor) resolve(name string) (string, error) {
+	return ResolvePath(x.root, name)
+}
+
+// ResolvePath 返回相对路径在目标目录中的路径，并创建其所在的各级目录
+//
+// 路径必须位于目标目录之中，路径中的各级目录不能是符号链接（包括之前恢复出的符号链接），
+// 避免经过符号链接将之后的条目写到目标目录之外。
+//
+// 参数:
+//   - root: 目标目录的绝对路径
+//   - name: 归档或快照中的相对路径（使用 / 分隔）
+//
+// 返回值:
+//   - string: 在目标目录中的路径
+//   - error: 路径非法、经过符号链接或创建目录失败时返回错误信息
+func ResolvePath(root, name string) (string, error) {
+	clean := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if clean == "." || clean == ".." || path.IsAbs(clean) || strings.HasPrefix(clean, "../") || filepath.VolumeName(clean) != "" {
+		return "", fmt.Errorf("非法路径: %s", name)
+	}
+
+	dir := root
+	parts := strings.Split(clean, "/")
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		info, err := os.Lstat(dir)
+		switch {
+		case os.IsNotExist(err):
+			if err := os.Mkdir(dir, 0755); err != nil {
+				return "", fmt.Errorf("创建目录 %s 失败: %w", dir, err)
+			}
+		case err != nil:
+			return "", fmt.Errorf("获取 %s 的文件信息失败: %w", dir, err)
+		case info.Mode()&fs.ModeSymlink != 0:
+			return "", fmt.Errorf("条目 %s 的路径经过符号链接 %s, 拒绝写入", name, dir)
+		case !info.IsDir():
+			return "", fmt.Errorf("无法恢复 %s: %s 不是目录", name, dir)
+		}
+	}
+
+	return filepath.Join(dir, parts[len(parts)-1]), nil
+}
+
+// Remove 删除增量版本中记录为已删除的条目
+//
+// 按路径倒序删除，目录中的内容先于目录本身被删除；目标目录中不属于备份的文件不会被删除，
+// 包含这些文件的目录予以保留。已经不存在的条目和路径经过符号链接的条目直接跳过，
+// 不会删除目标目录之外的文件。
+//
+// 参数:
+//   - files: 增量版本的文件清单（只处理记录为已删除的条目）
+//
+// 返回值:
+//   - error: 路径非法或删除失败时返回错误信息
+func (x *Extractor) Remove(files []types.BackupFile) error {
+	var deleted []string
+	for _, f := range files {
+		if f.ChangeType == types.ChangeTypeDeleted {
+			deleted = append(deleted, f.Path)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(deleted)))
+
+	for _, name := range deleted {
+		p, ok, err := x.lookup(name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		err = os.Remove(p)
+		switch {
+		case err == nil:
+			delete(x.dirs, p)
+		case os.IsNotExist(err), isDirNotEmpty(p):
+		default:
+			return fmt.Errorf("删除 %s 失败: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// lookup 返回条目在目标目录中的路径，不创建任何目录
+//
+// 路径中的某一级目录不存在或不是目录（包括符号链接）时，条目不可能位于目标目录之中，返回 false。
+func (x *Extractor) lookup(name string) (string, bool, error) {
+	clean := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if clean == "." || clean == ".." || path.IsAbs(clean) || strings.HasPrefix(clean, "../") || filepath.VolumeName(clean) != "" {
+		return "", false, fmt.Errorf("非法路径: %s", name)
+	}
+
+	dir := x.root
+	parts := strings.Split(clean, "/")
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		if info, err := os.Lstat(dir); err != nil || !info.IsDir() {
+			return "", false, nil
+		}
+	}
+	return filepath.Join(dir, parts[len(parts)-1]), true, nil
+}
+
+// isDirNotEmpty 判断路径是否为非空目录
+func isDirNotEmpty(path string) bool {
+	entries, err := os.ReadDir(path)
+	return err == nil && len(entries) > 0
+}
+
+// applyMetadata 按需要恢复的元数据处理已恢复的条目，记录恢复失败的条目
+func (x *Extractor) applyMetadata(name, path string, meta Metadata, symlink bool) {
+	if x.preserve == (Preserve{}) {
+		return
+	}
+	if err := meta.Apply(path, symlink, x.preserve); err != nil {
+		x.metaErrs = append(x.metaErrs, MetadataError{Name: name, Err: err})
+	}
+}
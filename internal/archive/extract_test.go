@@ -0,0 +1,221 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitee.com/MM-Q/bakctl/internal/crypt"
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/comprx"
+)
+
+// writeTree 在目录中写入文件，键为相对路径（以 / 结尾的为目录）
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if name[len(name)-1] == '/' {
+			if err := os.MkdirAll(p, 0755); err != nil {
+				t.Fatalf("创建目录失败: %v", err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("创建目录失败: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("写入文件失败: %v", err)
+		}
+	}
+}
+
+// writeBackup 将源目录写入备份文件，返回备份文件（或所有分卷）的路径
+func writeBackup(t *testing.T, src, dst, format string, volumeSize int64, key *crypt.Key) []string {
+	t.Helper()
+	entries, err := Collect(src, nil)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if _, err := Write(context.Background(), format, dst, volumeSize, entries, comprx.CompressionLevelDefault, nil, key, nil, nil); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if volumeSize == 0 {
+		return []string{dst}
+	}
+
+	var paths []string
+	for seq := 1; ; seq++ {
+		p := types.VolumePath(dst, seq)
+		if _, err := os.Stat(p); err != nil {
+			break
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+func TestExtractEncrypted(t *testing.T) {
+	files := map[string]string{
+		"data/a.txt":     "hello",
+		"data/sub/b.txt": string(make([]byte, 64*1024)),
+		"data/empty/":    "",
+	}
+	secret := crypt.Secret{Passphrase: "correct horse"}
+	key, err := crypt.NewKey(secret)
+	if err != nil {
+		t.Fatalf("NewKey() error = %v", err)
+	}
+
+	tests := []struct {
+		format     string
+		volumeSize int64
+	}{
+		{types.FormatTar, 0},
+		{types.FormatTarGz, 0},
+		{types.FormatTarBz2, 4096},
+		{types.FormatZip, 0},
+		{types.FormatZip, 4096},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "data")
+			writeTree(t, dir, files)
+			paths := writeBackup(t, src, filepath.Join(dir, "backup"+types.FormatExt(tt.format)), tt.format, tt.volumeSize, key)
+
+			// 密钥错误时不解压任何条目
+			wrong := crypt.Secret{Passphrase: "wrong"}
+			target := filepath.Join(dir, "restore")
+			_, _, err := Extract(context.Background(), Source{Paths: paths, Format: tt.format, Secret: &wrong}, target, false, Preserve{}, nil)
+			if !errors.Is(err, crypt.ErrWrongKey) {
+				t.Fatalf("Extract() 使用错误的密钥 error = %v, want ErrWrongKey", err)
+			}
+
+			n, _, err := Extract(context.Background(), Source{Paths: paths, Format: tt.format, Secret: &secret}, target, false, Preserve{}, nil)
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+			if n != 2 {
+				t.Errorf("Extract() 恢复的文件数 = %d, want 2", n)
+			}
+			for name, want := range files {
+				p := filepath.Join(target, filepath.FromSlash(name))
+				if name[len(name)-1] == '/' {
+					if info, err := os.Stat(p); err != nil || !info.IsDir() {
+						t.Errorf("目录 %s 未恢复: %v", name, err)
+					}
+					continue
+				}
+				got, err := os.ReadFile(p)
+				if err != nil || string(got) != want {
+					t.Errorf("文件 %s 内容不一致: %v", name, err)
+				}
+			}
+
+			// 解密的临时文件不会留在目标目录中
+			leftovers, _ := filepath.Glob(filepath.Join(target, decryptTempPattern))
+			if len(leftovers) > 0 {
+				t.Errorf("目标目录中残留解密临时文件: %v", leftovers)
+			}
+		})
+	}
+}
+
+func TestExtractEncryptedTruncated(t *testing.T) {
+	secret := crypt.Secret{Passphrase: "correct horse"}
+	key, err := crypt.NewKey(secret)
+	if err != nil {
+		t.Fatalf("NewKey() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"data/a.txt": "hello"})
+	paths := writeBackup(t, filepath.Join(dir, "data"), filepath.Join(dir, "backup.tar"), types.FormatTar, 0, key)
+
+	// 截去最后一个加密块的认证标签，备份不完整
+	info, err := os.Stat(paths[0])
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if err := os.Truncate(paths[0], info.Size()-16); err != nil {
+		t.Fatalf("Truncate() error = %v", err)
+	}
+
+	src := Source{Paths: paths, Format: types.FormatTar, Secret: &secret}
+	if _, _, err := Extract(context.Background(), src, filepath.Join(dir, "restore"), false, Preserve{}, nil); !errors.Is(err, crypt.ErrCorrupted) {
+		t.Errorf("Extract() error = %v, want ErrCorrupted", err)
+	}
+	if _, err := Test(context.Background(), src, nil); !errors.Is(err, crypt.ErrCorrupted) {
+		t.Errorf("Test() error = %v, want ErrCorrupted", err)
+	}
+}
+
+func TestExtractorDefersDirModes(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "data")
+	writeTree(t, dir, map[string]string{"data/ro/a.txt": "a", "data/ro/b.txt": "b"})
+	ro := filepath.Join(src, "ro")
+	if err := os.Chmod(ro, 0555); err != nil {
+		t.Fatalf("Chmod() error = %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(ro, 0755) })
+
+	entries, err := Collect(src, nil)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	// 第一个版本不包含 b.txt，第二个版本只包含只读目录和 b.txt
+	var v1, v2 []Entry
+	for _, e := range entries {
+		switch e.Name {
+		case "data/ro/b.txt":
+			v2 = append(v2, e)
+		case "data/ro":
+			v1, v2 = append(v1, e), append(v2, e)
+		default:
+			v1 = append(v1, e)
+		}
+	}
+	var paths []string
+	for i, part := range [][]Entry{v1, v2} {
+		dst := filepath.Join(dir, fmt.Sprintf("v%d.tar", i+1))
+		if _, err := Write(context.Background(), types.FormatTar, dst, 0, part, comprx.CompressionLevelDefault, nil, nil, nil, nil); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		paths = append(paths, dst)
+	}
+
+	target := filepath.Join(dir, "restore")
+	t.Cleanup(func() { _ = os.Chmod(filepath.Join(target, "data", "ro"), 0755) })
+	x, err := NewExtractor(context.Background(), target, Preserve{})
+	if err != nil {
+		t.Fatalf("NewExtractor() error = %v", err)
+	}
+	for i, p := range paths {
+		if err := x.Extract(Source{Paths: []string{p}, Format: types.FormatTar}, i > 0, nil); err != nil {
+			t.Fatalf("Extract(v%d) error = %v", i+1, err)
+		}
+	}
+	if files, _ := x.Finish(); files != 2 {
+		t.Errorf("Finish() 恢复的文件数 = %d, want 2", files)
+	}
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if _, err := os.Stat(filepath.Join(target, "data", "ro", name)); err != nil {
+			t.Errorf("文件 %s 未恢复: %v", name, err)
+		}
+	}
+	info, err := os.Stat(filepath.Join(target, "data", "ro"))
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Mode().Perm() != 0555 {
+		t.Errorf("目录权限 = %v, want 0555", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,136 @@
+package archive
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gitee.com/MM-Q/bakctl/internal/filter"
+	"gitee.com/MM-Q/comprx"
+)
+
+// ignoreRule 忽略文件中的一条规则
+type ignoreRule struct {
+	text     string // 规则原文
+	source   string // 规则所在的忽略文件（归档内路径）
+	pattern  string // 匹配模式（已去除 ! 前缀和首尾的 /）
+	negate   bool   // 是否为否定规则（以 ! 开头，重新包含之前被忽略的条目）
+	dirOnly  bool   // 是否只匹配目录（以 / 结尾）
+	anchored bool   // 是否相对于忽略文件所在目录匹配（包含 /），否则匹配任意层级的名称
+}
+
+// ignoreMatcher 按照 .gitignore 的语义匹配源目录中的忽略文件
+//
+// 每个目录下的忽略文件只作用于该目录内的条目；更深层目录的规则优先，
+// 同一个忽略文件中靠后的规则优先。被忽略的目录不再遍历，其中的条目无法被否定规则重新包含。
+type ignoreMatcher struct {
+	root     string                  // 源目录的绝对路径
+	fileName string                  // 忽略文件名
+	rules    map[string][]ignoreRule // 目录的绝对路径 -> 该目录下忽略文件中的规则
+}
+
+// newIgnoreMatcher 创建忽略文件匹配器
+func newIgnoreMatcher(root, fileName string) *ignoreMatcher {
+	return &ignoreMatcher{root: root, fileName: fileName, rules: make(map[string][]ignoreRule)}
+}
+
+// load 加载目录中的忽略文件（文件不存在时忽略）
+//
+// 参数:
+//   - dir: 目录的绝对路径（规则按此路径匹配）
+//   - realDir: 目录的实际路径（跟随符号链接时与 dir 不同，从此路径读取忽略文件）
+//   - name: 目录在归档内的路径
+//
+// 返回值:
+//   - error: 读取忽略文件失败或其中包含无效的模式时返回错误信息
+func (m *ignoreMatcher) load(dir, realDir, name string) error {
+	source := name + "/" + m.fileName
+	lines, err := comprx.LoadExcludeFromFileOrEmpty(filepath.Join(realDir, m.fileName))
+	if err != nil {
+		return fmt.Errorf("加载忽略文件 '%s' 失败: %w", source, err)
+	}
+
+	var rules []ignoreRule
+	for _, line := range lines {
+		rule := ignoreRule{text: line, source: source}
+		p := line
+		if strings.HasPrefix(p, "!") {
+			rule.negate = true
+			p = p[1:]
+		} else if strings.HasPrefix(p, `\!`) || strings.HasPrefix(p, `\#`) {
+			p = p[1:] // 转义的 ! 和 # 按普通字符匹配
+		}
+		if strings.HasSuffix(p, "/") {
+			rule.dirOnly = true
+			p = strings.TrimRight(p, "/")
+		}
+		if strings.HasPrefix(p, "/") {
+			rule.anchored = true
+			p = strings.TrimLeft(p, "/")
+		}
+		if strings.Contains(p, "/") {
+			rule.anchored = true
+		}
+		if p == "" {
+			continue
+		}
+		rule.pattern = p
+		rules = append(rules, rule)
+	}
+
+	if len(rules) > 0 {
+		m.rules[dir] = rules
+	}
+	return nil
+}
+
+// match 返回决定条目是否被忽略的规则
+//
+// 参数:
+//   - absPath: 条目的绝对路径
+//   - isDir: 是否为目录
+//
+// 返回值:
+//   - *ignoreRule: 最终匹配的规则（未匹配任何规则时为 nil；否定规则表示条目被重新包含）
+func (m *ignoreMatcher) match(absPath string, isDir bool) *ignoreRule {
+	if absPath == m.root {
+		return nil
+	}
+
+	for dir := filepath.Dir(absPath); ; dir = filepath.Dir(dir) {
+		rules := m.rules[dir]
+		if len(rules) > 0 {
+			rel, err := filepath.Rel(dir, absPath)
+			if err == nil {
+				rel = filepath.ToSlash(rel)
+				for i := len(rules) - 1; i >= 0; i-- {
+					if rules[i].matches(rel, isDir) {
+						return &rules[i]
+					}
+				}
+			}
+		}
+
+		if dir == m.root || dir == filepath.Dir(dir) {
+			return nil
+		}
+	}
+}
+
+// matches 判断规则是否匹配相对于忽略文件所在目录的路径
+func (r *ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if !r.anchored {
+		ok, _ := path.Match(r.pattern, path.Base(rel))
+		return ok
+	}
+	return filter.MatchSegments(strings.Split(r.pattern, "/"), strings.Split(rel, "/"))
+}
+
+// reason 返回条目被该规则忽略的原因
+func (r *ignoreRule) reason() string {
+	return fmt.Sprintf("匹配忽略文件 '%s' 中的规则 '%s'", r.source, r.text)
+}
@@ -0,0 +1,113 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".bakignore": "# 注释行\n" +
+			"*.log\n" +
+			"!keep.log\n" +
+			"build/\n" +
+			"/only-root.txt\n" +
+			"docs/*.tmp\n" +
+			"assets/**/*.psd\n" +
+			`\!bang` + "\n" +
+			`\#hash` + "\n",
+		"sub/.bakignore": "!*.log\n" +
+			"secret\n",
+		"sub/deep/.bakignore": "secret\n" +
+			"!secret\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := newIgnoreMatcher(root, ".bakignore")
+	for _, dir := range []string{"", "sub", "sub/deep"} {
+		abs := filepath.Join(root, filepath.FromSlash(dir))
+		if err := m.load(abs, abs, "top/"+dir); err != nil {
+			t.Fatalf("load(%q) error = %v", dir, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"通配符匹配文件名", "a.log", false, true},
+		{"通配符匹配任意层级", "x/y/a.log", false, true},
+		{"否定规则重新包含", "keep.log", false, false},
+		{"否定规则作用于任意层级", "x/keep.log", false, false},
+		{"未匹配任何规则", "a.txt", false, false},
+		{"目录规则匹配目录", "build", true, true},
+		{"目录规则匹配深层目录", "x/build", true, true},
+		{"目录规则不匹配文件", "build", false, false},
+		{"以 / 开头的规则匹配根目录", "only-root.txt", false, true},
+		{"以 / 开头的规则不匹配子目录", "x/only-root.txt", false, false},
+		{"包含 / 的规则相对于所在目录", "docs/a.tmp", false, true},
+		{"包含 / 的规则不匹配其他目录", "x/docs/a.tmp", false, false},
+		{"* 不跨越目录", "docs/deep/a.tmp", false, false},
+		{"** 匹配零层目录", "assets/a.psd", false, true},
+		{"** 匹配多层目录", "assets/x/y/a.psd", false, true},
+		{`\! 按普通字符匹配`, "!bang", false, true},
+		{`\! 不是否定规则`, "bang", false, false},
+		{`\# 按普通字符匹配`, "#hash", false, true},
+		{"注释行不是规则", "# 注释行", false, false},
+		{"子目录的否定规则优先", "sub/a.log", false, false},
+		{"子目录的规则只作用于子目录", "secret", false, false},
+		{"子目录的规则", "sub/secret", false, true},
+		{"更深层目录的规则优先", "sub/deep/secret", false, false},
+		{"同一文件中靠后的规则优先", "sub/deep/x/secret", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := m.match(filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir)
+			if got := rule != nil && !rule.negate; got != tt.ignored {
+				t.Errorf("match(%q, %v) 是否忽略 = %v, want %v (规则: %+v)", tt.path, tt.isDir, got, tt.ignored, rule)
+			}
+		})
+	}
+}
+
+func TestIgnoreMatcherRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".bakignore"), []byte("*\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := newIgnoreMatcher(root, ".bakignore")
+	if err := m.load(root, root, "top"); err != nil {
+		t.Fatal(err)
+	}
+
+	if rule := m.match(root, true); rule != nil {
+		t.Errorf("源目录本身不应被忽略文件中的规则匹配, 匹配了 %+v", rule)
+	}
+	if rule := m.match(filepath.Join(root, "a"), false); rule == nil || rule.negate {
+		t.Errorf("* 应忽略源目录中的所有条目")
+	}
+}
+
+func TestIgnoreMatcherMissingFile(t *testing.T) {
+	root := t.TempDir()
+	m := newIgnoreMatcher(root, ".bakignore")
+	if err := m.load(root, root, "top"); err != nil {
+		t.Fatalf("忽略文件不存在时 load() error = %v", err)
+	}
+	if rule := m.match(filepath.Join(root, "a.log"), false); rule != nil {
+		t.Errorf("没有忽略文件时不应匹配任何规则, 匹配了 %+v", rule)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package archive
+
+import "io/fs"
+
+// HardlinkKey 当前平台无法识别硬链接，每个文件都单独存储
+func HardlinkKey(info fs.FileInfo) (string, bool) {
+	return "", false
+}
@@ -0,0 +1,25 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package archive
+
+import (
+	"fmt"
+	"io/fs"
+	"syscall"
+)
+
+// HardlinkKey 返回有多个硬链接的普通文件的唯一标识（设备号和 inode 号）
+//
+// 参数:
+//   - info: 文件信息
+//
+// 返回值:
+//   - string: 文件的唯一标识
+//   - bool: 文件是否有多个硬链接（只有一个链接或无法获取时为 false）
+func HardlinkKey(info fs.FileInfo) (string, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || !info.Mode().IsRegular() || st.Nlink < 2 {
+		return "", false
+	}
+	return fmt.Sprintf("%d:%d", st.Dev, st.Ino), true
+}
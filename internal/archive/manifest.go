@@ -0,0 +1,114 @@
+package archive
+
+import (
+	"io/fs"
+	"sort"
+
+	"gitee.com/MM-Q/bakctl/internal/types"
+)
+
+// DiffManifest 将条目与上一个版本的文件清单对比，确定增量备份需要打包的条目和本次的文件清单
+//
+// 对比规则：
+//   - 新增或修改（大小、修改时间、权限或状态变化时间变化）的普通文件需要打包，未变化的文件沿用上一个版本的哈希
+//   - 目录和符号链接每次都需要打包，同样记录在清单中（权限中包含条目类型）
+//   - 上一个版本存在而本次不存在的条目记录为已删除
+//   - 条目类型发生变化（如文件变为目录、目录变为符号链接）时，先将上一个版本的条目记录为已删除，
+//     再将本次的条目记录为新增，回放时先删除旧条目（目录中的内容先于目录本身被删除）再解压新条目
+//
+// 参数:
+//   - entries: 本次收集的条目
+//   - parent: 上一个版本中仍然存在的条目，键为归档内路径（为 nil 时所有条目都是新增的）
+//
+// 返回值:
+//   - []Entry: 需要打包的条目
+//   - []types.BackupFile: 本次的文件清单（新增和修改的文件尚未计算哈希）
+func DiffManifest(entries []Entry, parent map[string]types.BackupFile) ([]Entry, []types.BackupFile) {
+	var pack []Entry
+	var files []types.BackupFile
+	seen := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		file := types.BackupFile{
+			Path:    e.Name,                      // 归档内路径
+			ModTime: e.Info.ModTime().UnixNano(), // 修改时间
+			Mode:    ManifestMode(e.Info.Mode()), // 条目类型和权限
+		}
+		if ctime, ok := ChangeTime(e.Info); ok {
+			file.ChangeTime = ctime.UnixNano() // 状态变化时间
+		}
+
+		// 条目类型变化时旧条目记录为已删除，本次的条目按新增处理
+		prev, ok := parent[e.Name]
+		if ok && fs.FileMode(prev.Mode)&fs.ModeType != fs.FileMode(file.Mode)&fs.ModeType {
+			ok = false
+		} else {
+			seen[e.Name] = true
+		}
+
+		// 目录、符号链接等非普通文件每次都写入归档，只在清单中记录以便回放时删除
+		if !e.IsRegular() {
+			file.ChangeType = types.ChangeTypeAdded
+			if ok {
+				file.ChangeType = types.ChangeTypeUnchanged
+			}
+			pack = append(pack, e)
+			files = append(files, file)
+			continue
+		}
+
+		file.Size = e.Info.Size() // 文件大小
+		switch {
+		case !ok:
+			file.ChangeType = types.ChangeTypeAdded
+			pack = append(pack, e)
+		case fileChanged(prev, file):
+			file.ChangeType = types.ChangeTypeModified
+			pack = append(pack, e)
+		default:
+			file.ChangeType = types.ChangeTypeUnchanged
+			file.Hash = prev.Hash // 未变化的文件沿用上一个版本的哈希
+		}
+		files = append(files, file)
+	}
+
+	// 记录已删除（或类型已变化）的条目，保留上一个版本的条目类型
+	var deleted []string
+	for path := range parent {
+		if !seen[path] {
+			deleted = append(deleted, path)
+		}
+	}
+	sort.Strings(deleted)
+	for _, path := range deleted {
+		files = append(files, types.BackupFile{Path: path, Mode: parent[path].Mode, ChangeType: types.ChangeTypeDeleted})
+	}
+
+	return pack, files
+}
+
+// fileChanged 判断文件相对上一个版本是否发生了变化
+//
+// 修改权限、属主或扩展属性不会改变修改时间，但会改变状态变化时间（ctime），
+// 上一个版本没有记录权限或状态变化时间时（早于记录这些信息的清单）只比较大小和修改时间。
+func fileChanged(prev, cur types.BackupFile) bool {
+	if prev.Size != cur.Size || prev.ModTime != cur.ModTime {
+		return true
+	}
+	if prev.Mode != 0 && prev.Mode != cur.Mode {
+		return true
+	}
+	return prev.ChangeTime != 0 && cur.ChangeTime != 0 && prev.ChangeTime != cur.ChangeTime
+}
+
+// ManifestMode 返回记录在清单中的条目类型和权限位
+//
+// 普通文件只有权限位，与早于记录条目类型的清单兼容。
+//
+// 参数:
+//   - mode: 文件模式
+//
+// 返回值:
+//   - uint32: 清单中记录的条目类型和权限位
+func ManifestMode(mode fs.FileMode) uint32 {
+	return uint32(mode & (fs.ModeType | fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky))
+}
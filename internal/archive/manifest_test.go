@@ -0,0 +1,162 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/comprx"
+)
+
+// snapshotTree 返回目录中每个条目的类型和内容，键为相对路径
+func snapshotTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	tree := make(map[string]string)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == root {
+			return err
+		}
+		rel, _ := filepath.Rel(root, p)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			tree[rel] = fmt.Sprintf("dir %v", info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			tree[rel] = "link " + target
+		default:
+			content, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			tree[rel] = "file " + string(content)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("遍历目录 %s 失败: %v", root, err)
+	}
+	return tree
+}
+
+// backupVersion 对比上一个版本的清单打包源目录，返回本次的文件清单
+func backupVersion(t *testing.T, src, dst string, parent map[string]types.BackupFile) []types.BackupFile {
+	t.Helper()
+	entries, err := Collect(src, nil)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	pack, files := DiffManifest(entries, parent)
+	if _, err := Write(context.Background(), types.FormatTarGz, dst, 0, pack, comprx.CompressionLevelDefault, nil, nil, nil, nil); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	return files
+}
+
+func TestReplayTypeChanges(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("需要符号链接")
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "data")
+	writeTree(t, dir, map[string]string{
+		"data/keep.txt":   "keep",
+		"data/f2d":        "file to dir",
+		"data/d2f/c.txt":  "c",
+		"data/d2l/e.txt":  "e",
+		"data/f2l":        "file to symlink",
+		"data/ro/r.txt":   "r",
+		"data/l2f-target": "t",
+	})
+	if err := os.Symlink("l2f-target", filepath.Join(src, "l2f")); err != nil {
+		t.Fatalf("Symlink() error = %v", err)
+	}
+	ro := filepath.Join(src, "ro")
+	if err := os.Chmod(ro, 0555); err != nil {
+		t.Fatalf("Chmod() error = %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(ro, 0755) })
+
+	// 第一个版本（全量）
+	v1 := filepath.Join(dir, "v1.tar.gz")
+	files1 := backupVersion(t, src, v1, nil)
+	parent := make(map[string]types.BackupFile, len(files1))
+	for _, f := range files1 {
+		parent[f.Path] = f
+	}
+
+	// 改变条目类型：文件变为目录、目录变为文件、目录变为符号链接、文件变为符号链接、符号链接变为文件，
+	// 并在只读目录中新增文件
+	steps := []func() error{
+		func() error { return os.Remove(filepath.Join(src, "f2d")) },
+		func() error { return os.MkdirAll(filepath.Join(src, "f2d"), 0750) },
+		func() error { return os.WriteFile(filepath.Join(src, "f2d", "a.txt"), []byte("a"), 0644) },
+		func() error { return os.RemoveAll(filepath.Join(src, "d2f")) },
+		func() error { return os.WriteFile(filepath.Join(src, "d2f"), []byte("dir to file"), 0644) },
+		func() error { return os.RemoveAll(filepath.Join(src, "d2l")) },
+		func() error { return os.Symlink("f2d", filepath.Join(src, "d2l")) },
+		func() error { return os.Remove(filepath.Join(src, "f2l")) },
+		func() error { return os.Symlink("f2d/a.txt", filepath.Join(src, "f2l")) },
+		func() error { return os.Remove(filepath.Join(src, "l2f")) },
+		func() error { return os.WriteFile(filepath.Join(src, "l2f"), []byte("link to file"), 0644) },
+		func() error { return os.Chmod(ro, 0755) },
+		func() error { return os.WriteFile(filepath.Join(ro, "new.txt"), []byte("new"), 0644) },
+		func() error { return os.Chmod(ro, 0555) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("修改源目录第 %d 步失败: %v", i+1, err)
+		}
+	}
+
+	// 第二个版本（增量）：类型变化的条目先记录为已删除
+	v2 := filepath.Join(dir, "v2.tar.gz")
+	files2 := backupVersion(t, src, v2, parent)
+	var deleted []string
+	for _, f := range files2 {
+		if f.ChangeType == types.ChangeTypeDeleted {
+			deleted = append(deleted, f.Path)
+		}
+	}
+	wantDeleted := []string{"data/d2f", "data/d2f/c.txt", "data/d2l", "data/d2l/e.txt", "data/f2d", "data/f2l", "data/l2f"}
+	if !reflect.DeepEqual(deleted, wantDeleted) {
+		t.Errorf("DiffManifest() 已删除的条目 = %v, want %v", deleted, wantDeleted)
+	}
+
+	// 回放备份链
+	target := filepath.Join(dir, "restore")
+	t.Cleanup(func() { _ = os.Chmod(filepath.Join(target, "data", "ro"), 0755) })
+	x, err := NewExtractor(context.Background(), target, Preserve{})
+	if err != nil {
+		t.Fatalf("NewExtractor() error = %v", err)
+	}
+	if err := x.Extract(Source{Paths: []string{v1}, Format: types.FormatTarGz}, false, nil); err != nil {
+		t.Fatalf("Extract(v1) error = %v", err)
+	}
+	if err := x.Remove(files2); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := x.Extract(Source{Paths: []string{v2}, Format: types.FormatTarGz}, true, nil); err != nil {
+		t.Fatalf("Extract(v2) error = %v", err)
+	}
+	x.Finish()
+
+	want := snapshotTree(t, src)
+	got := snapshotTree(t, filepath.Join(target, "data"))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("回放后的目录与源目录不一致:\n got: %v\nwant: %v", got, want)
+	}
+}
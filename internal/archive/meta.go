@@ -0,0 +1,274 @@
+package archive
+
+import (
+	"archive/tar"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ZIP 扩展字段
+const (
+	zipExtraUnix   = 0x7875 // Info-ZIP New Unix Extra Field（属主和属组的 UID/GID）
+	zipExtraBakctl = 0x4b42 // bakctl 元数据（访问时间、扩展属性和硬链接目标，内容为 JSON）
+	maxZipExtra    = 60000  // 扩展字段的最大总长度（ZIP 格式限制为 65535 字节，预留 Go 写入的时间戳字段）
+)
+
+// paxXattrPrefix tar 归档中扩展属性的 PAX 记录前缀（与 GNU tar 和 bsdtar 兼容）
+const paxXattrPrefix = "SCHILY.xattr."
+
+// specialModeBits 权限位之外需要保留的 setuid、setgid 和粘滞位
+const specialModeBits = fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky
+
+// Metadata 条目的 Unix 元数据
+type Metadata struct {
+	Mode    fs.FileMode       // 权限位（包括 setuid、setgid 和粘滞位）
+	UID     int               // 属主 UID（-1 表示未知）
+	GID     int               // 属组 GID（-1 表示未知）
+	ModTime time.Time         // 修改时间
+	ATime   time.Time         // 访问时间（零值表示未知）
+	Xattrs  map[string][]byte // 扩展属性（名称 -> 值）
+}
+
+// Preserve 恢复时重新应用的元数据
+//
+// 未指定任何选项时与之前的行为一致：按归档中的权限创建文件（受 umask 影响），并恢复修改时间。
+type Preserve struct {
+	Owner bool // 恢复属主和属组（需要 root 权限）
+	Perms bool // 恢复完整的权限位（不受 umask 影响，包括 setuid、setgid 和粘滞位）和扩展属性
+	Times bool // 恢复访问时间和修改时间（包括符号链接本身的时间）
+}
+
+// MetadataError 元数据未能恢复的条目
+type MetadataError struct {
+	Name string // 条目在归档内的路径
+	Err  error  // 失败原因
+}
+
+// Error 返回错误信息
+func (e MetadataError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Name, e.Err)
+}
+
+// ChangeTime 返回文件的状态变化时间（ctime）
+//
+// 修改内容、权限、属主或扩展属性时都会更新状态变化时间，且无法通过 touch 等方式回退，
+// 增量备份据此发现修改时间没有变化的元数据修改。
+//
+// 参数:
+//   - info: 文件信息
+//
+// 返回值:
+//   - time.Time: 状态变化时间
+//   - bool: 当前平台能否获取状态变化时间
+func ChangeTime(info fs.FileInfo) (time.Time, bool) {
+	return statChangeTime(info)
+}
+
+// ReadMetadata 读取条目的元数据
+//
+// 属主、访问时间和扩展属性只能在 Unix 平台获取，其他平台上为未知。
+// 读取扩展属性失败（如文件系统不支持扩展属性）时忽略扩展属性。
+//
+// 参数:
+//   - e: 条目（跟随符号链接的条目读取链接目标的扩展属性）
+//
+// 返回值:
+//   - Metadata: 条目的元数据
+func ReadMetadata(e Entry) Metadata {
+	m := Metadata{
+		Mode:    e.Info.Mode() & (fs.ModePerm | specialModeBits),
+		UID:     -1,
+		GID:     -1,
+		ModTime: e.Info.ModTime(),
+	}
+	if uid, gid, atime, ok := statMetadata(e.Info); ok {
+		m.UID, m.GID, m.ATime = uid, gid, atime
+	}
+	m.Xattrs, _ = readXattrs(e.Path, e.Info.Mode()&fs.ModeSymlink == 0)
+	return m
+}
+
+// Apply 将元数据应用到已恢复的条目
+//
+// 先恢复属主（修改属主会清除 setuid 和 setgid 位），再恢复扩展属性（只读文件无法设置扩展属性）和权限，最后恢复时间。
+// 某一项失败时继续恢复其余各项，返回所有失败的原因。
+//
+// 参数:
+//   - path: 已恢复条目的路径
+//   - symlink: 条目是否为符号链接（符号链接没有独立的权限，只恢复属主、扩展属性和时间）
+//   - p: 需要恢复的元数据
+//
+// 返回值:
+//   - error: 任一项元数据恢复失败时返回错误信息
+func (m Metadata) Apply(path string, symlink bool, p Preserve) error {
+	var errs []error
+	if p.Owner && m.UID >= 0 && m.GID >= 0 {
+		if err := os.Lchown(path, m.UID, m.GID); err != nil {
+			errs = append(errs, fmt.Errorf("恢复属主失败: %w", err))
+		}
+	}
+
+	if p.Perms {
+		names := make([]string, 0, len(m.Xattrs))
+		for name := range m.Xattrs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := writeXattr(path, name, m.Xattrs[name]); err != nil {
+				errs = append(errs, fmt.Errorf("恢复扩展属性 %s 失败: %w", name, err))
+			}
+		}
+		if !symlink {
+			if err := os.Chmod(path, m.Mode); err != nil {
+				errs = append(errs, fmt.Errorf("恢复权限失败: %w", err))
+			}
+		}
+	}
+
+	if p.Times && !m.ModTime.IsZero() {
+		atime := m.ATime
+		if atime.IsZero() {
+			atime = m.ModTime
+		}
+		var err error
+		if symlink {
+			err = lchtimes(path, atime, m.ModTime)
+		} else {
+			err = os.Chtimes(path, atime, m.ModTime)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("恢复时间失败: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// setTarMetadata 在 tar 文件头中记录访问时间和扩展属性
+//
+// 只有 PAX 格式能够记录访问时间、纳秒精度的修改时间和扩展属性。
+func setTarMetadata(header *tar.Header, e Entry) {
+	m := ReadMetadata(e)
+	header.Format = tar.FormatPAX
+	header.AccessTime = m.ATime
+	header.ChangeTime = time.Time{}
+	for name, value := range m.Xattrs {
+		if header.PAXRecords == nil {
+			header.PAXRecords = make(map[string]string)
+		}
+		header.PAXRecords[paxXattrPrefix+name] = string(value)
+	}
+}
+
+// tarMetadata 返回 tar 文件头中记录的元数据
+func tarMetadata(hdr *tar.Header) Metadata {
+	m := Metadata{
+		Mode:    hdr.FileInfo().Mode() & (fs.ModePerm | specialModeBits),
+		UID:     hdr.Uid,
+		GID:     hdr.Gid,
+		ModTime: hdr.ModTime,
+		ATime:   hdr.AccessTime,
+	}
+	for key, value := range hdr.PAXRecords {
+		if name, ok := strings.CutPrefix(key, paxXattrPrefix); ok && name != "" {
+			if m.Xattrs == nil {
+				m.Xattrs = make(map[string][]byte)
+			}
+			m.Xattrs[name] = []byte(value)
+		}
+	}
+	return m
+}
+
+// zipMeta bakctl 扩展字段中记录的元数据
+type zipMeta struct {
+	ATime  int64             `json:"atime,omitempty"`  // 访问时间（Unix纳秒时间戳）
+	Xattrs map[string][]byte `json:"xattrs,omitempty"` // 扩展属性
+	Link   string            `json:"link,omitempty"`   // 硬链接指向的归档内路径（仅硬链接条目）
+}
+
+// zipExtra 生成记录属主、访问时间、扩展属性和硬链接目标的 ZIP 扩展字段
+//
+// 扩展属性过大、超出 ZIP 扩展字段的长度限制时不记录扩展属性。
+func zipExtra(m Metadata, link string) []byte {
+	var extra []byte
+	if m.UID >= 0 && m.GID >= 0 {
+		field := []byte{1, 4, 0, 0, 0, 0, 4, 0, 0, 0, 0} // 版本 1，UID 和 GID 各 4 字节
+		binary.LittleEndian.PutUint32(field[2:], uint32(m.UID))
+		binary.LittleEndian.PutUint32(field[7:], uint32(m.GID))
+		extra = appendZipExtra(extra, zipExtraUnix, field)
+	}
+
+	meta := zipMeta{Xattrs: m.Xattrs, Link: link}
+	if !m.ATime.IsZero() {
+		meta.ATime = m.ATime.UnixNano()
+	}
+	if meta.ATime == 0 && len(meta.Xattrs) == 0 && meta.Link == "" {
+		return extra
+	}
+	data, err := json.Marshal(meta)
+	if err != nil || len(extra)+4+len(data) > maxZipExtra {
+		meta.Xattrs = nil
+		if data, err = json.Marshal(meta); err != nil || (meta.ATime == 0 && meta.Link == "") {
+			return extra
+		}
+	}
+	return appendZipExtra(extra, zipExtraBakctl, data)
+}
+
+// appendZipExtra 追加一个 ZIP 扩展字段（2 字节标识 + 2 字节长度 + 内容）
+func appendZipExtra(extra []byte, id uint16, data []byte) []byte {
+	extra = binary.LittleEndian.AppendUint16(extra, id)
+	extra = binary.LittleEndian.AppendUint16(extra, uint16(len(data)))
+	return append(extra, data...)
+}
+
+// zipMetadata 返回 ZIP 条目中记录的元数据（没有对应扩展字段时属主和访问时间为未知）
+func zipMetadata(mode fs.FileMode, modTime time.Time, extra []byte) Metadata {
+	m := Metadata{Mode: mode & (fs.ModePerm | specialModeBits), UID: -1, GID: -1, ModTime: modTime}
+	if data := zipExtraField(extra, zipExtraUnix); len(data) == 11 && data[0] == 1 && data[1] == 4 && data[6] == 4 {
+		m.UID = int(binary.LittleEndian.Uint32(data[2:]))
+		m.GID = int(binary.LittleEndian.Uint32(data[7:]))
+	}
+	var meta zipMeta
+	if data := zipExtraField(extra, zipExtraBakctl); data != nil && json.Unmarshal(data, &meta) == nil {
+		if meta.ATime != 0 {
+			m.ATime = time.Unix(0, meta.ATime)
+		}
+		m.Xattrs = meta.Xattrs
+	}
+	return m
+}
+
+// zipHardlink 返回 ZIP 条目扩展字段中记录的硬链接目标（不是硬链接条目时为空）
+func zipHardlink(extra []byte) string {
+	var meta zipMeta
+	if data := zipExtraField(extra, zipExtraBakctl); data != nil && json.Unmarshal(data, &meta) == nil {
+		return meta.Link
+	}
+	return ""
+}
+
+// zipExtraField 返回 ZIP 扩展字段中指定标识的内容（不存在时为 nil）
+func zipExtraField(extra []byte, id uint16) []byte {
+	for len(extra) >= 4 {
+		fieldID := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			break
+		}
+		if fieldID == id {
+			return extra[4 : 4+size]
+		}
+		extra = extra[4+size:]
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build dragonfly || linux || openbsd
+
+package archive
+
+import (
+	"syscall"
+	"time"
+)
+
+// statAtime 返回文件的访问时间
+func statAtime(st *syscall.Stat_t) time.Time {
+	return time.Unix(st.Atim.Unix())
+}
+
+// statCtime 返回文件的状态变化时间
+func statCtime(st *syscall.Stat_t) time.Time {
+	return time.Unix(st.Ctim.Unix())
+}
@@ -0,0 +1,24 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package archive
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+)
+
+// statMetadata 当前平台无法获取文件的属主和访问时间
+func statMetadata(info fs.FileInfo) (uid, gid int, atime time.Time, ok bool) {
+	return -1, -1, time.Time{}, false
+}
+
+// statChangeTime 当前平台无法获取文件的状态变化时间
+func statChangeTime(info fs.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// lchtimes 当前平台不支持修改符号链接本身的时间
+func lchtimes(path string, atime, mtime time.Time) error {
+	return errors.New("当前平台不支持修改符号链接的时间")
+}
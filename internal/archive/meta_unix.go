@@ -0,0 +1,35 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package archive
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// statMetadata 返回文件的属主、属组和访问时间
+func statMetadata(info fs.FileInfo) (uid, gid int, atime time.Time, ok bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return -1, -1, time.Time{}, false
+	}
+	return int(st.Uid), int(st.Gid), statAtime(st), true
+}
+
+// statChangeTime 返回文件的状态变化时间（ctime）
+func statChangeTime(info fs.FileInfo) (time.Time, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	return statCtime(st), true
+}
+
+// lchtimes 修改符号链接本身的访问时间和修改时间（不跟随链接）
+func lchtimes(path string, atime, mtime time.Time) error {
+	ts := []unix.Timespec{unix.NsecToTimespec(atime.UnixNano()), unix.NsecToTimespec(mtime.UnixNano())}
+	return unix.UtimesNanoAt(unix.AT_FDCWD, path, ts, unix.AT_SYMLINK_NOFOLLOW)
+}
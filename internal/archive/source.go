@@ -0,0 +1,170 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"gitee.com/MM-Q/bakctl/internal/crypt"
+	"gitee.com/MM-Q/bakctl/internal/utils"
+)
+
+// decryptTempPattern 加密的 zip 备份解密时使用的临时文件名
+const decryptTempPattern = ".bakctl-decrypt-*.tmp"
+
+// Source 要解压或检查的备份文件
+type Source struct {
+	Paths  []string      // 备份文件路径（分卷备份按顺序传入所有分卷）
+	Format string        // 归档格式（zip/tar/tar.gz/tar.bz2）
+	Secret *crypt.Secret // 解密使用的密码、密钥文件或身份文件（未加密的备份为 nil）
+}
+
+// open 打开所有备份文件，返回按顺序拼接所有分卷的读取器，分卷直接在原位置读取，不需要合并
+//
+// 返回值:
+//   - *multiReaderAt: 拼接所有分卷的读取器
+//   - func(): 关闭所有文件的函数
+//   - error: 打开文件失败时返回错误信息
+func (s Source) open() (*multiReaderAt, func(), error) {
+	files := make([]*os.File, 0, len(s.Paths))
+	closeAll := func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}
+
+	mr := &multiReaderAt{}
+	for _, path := range s.Paths {
+		f, err := os.Open(path)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("打开备份文件失败: %w", err)
+		}
+		files = append(files, f)
+
+		info, err := f.Stat()
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("获取备份文件信息失败: %w", err)
+		}
+		mr.parts = append(mr.parts, readerPart{r: f, off: mr.size, size: info.Size()})
+		mr.size += info.Size()
+	}
+
+	return mr, closeAll, nil
+}
+
+// stream 返回按顺序读取归档数据的读取器，加密的备份边读取边解密
+//
+// 参数:
+//   - mr: 拼接所有分卷的读取器
+//
+// 返回值:
+//   - io.Reader: 归档数据的读取器（读取到被篡改或不完整的加密数据时返回错误）
+//   - error: 密码、密钥文件或身份文件错误时返回错误信息
+func (s Source) stream(mr *multiReaderAt) (io.Reader, error) {
+	var r io.Reader = io.NewSectionReader(mr, 0, mr.size)
+	if s.Secret == nil {
+		return r, nil
+	}
+
+	dr, err := crypt.NewReader(r, *s.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("解密备份文件失败: %w", err)
+	}
+	return dr, nil
+}
+
+// zipReader 返回 zip 归档的随机读取器
+//
+// zip 归档需要随机读取，加密的 zip 备份先解密到 dir 中的临时文件，
+// 临时文件由返回的函数删除（调用方在解压或检查结束后调用，包括被取消时），不会写入系统临时目录。
+//
+// 参数:
+//   - ctx: 上下文，被取消后立即停止解密
+//   - mr: 拼接所有分卷的读取器
+//   - dir: 临时文件所在的目录
+//
+// 返回值:
+//   - io.ReaderAt: zip 归档的随机读取器
+//   - int64: zip 归档的大小
+//   - func(): 删除临时文件的函数
+//   - error: 解密失败时返回错误信息
+func (s Source) zipReader(ctx context.Context, mr *multiReaderAt, dir string) (io.ReaderAt, int64, func(), error) {
+	if s.Secret == nil {
+		return mr, mr.size, func() {}, nil
+	}
+
+	r, err := s.stream(mr)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	f, err := os.CreateTemp(dir, decryptTempPattern)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("创建解密临时文件失败: %w", err)
+	}
+	cleanup := func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}
+
+	size, err := io.Copy(f, utils.NewContextReader(ctx, r))
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("解密备份文件失败: %w", err)
+	}
+	return f, size, cleanup, nil
+}
+
+// readerPart 多文件读取器中的一个文件
+type readerPart struct {
+	r    io.ReaderAt // 文件
+	off  int64       // 文件在整体数据中的起始偏移
+	size int64       // 文件大小
+}
+
+// multiReaderAt 将多个分卷文件按顺序拼接为一个整体读取
+type multiReaderAt struct {
+	parts []readerPart // 分卷文件（按顺序）
+	size  int64        // 总大小
+}
+
+// ReadAt 从整体数据的指定偏移读取数据
+func (m *multiReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= m.size {
+		return 0, io.EOF
+	}
+
+	n := 0
+	for _, part := range m.parts {
+		if len(p) == 0 {
+			break
+		}
+		if off >= part.off+part.size {
+			continue
+		}
+
+		rel := off - part.off
+		chunk := p
+		if remain := part.size - rel; int64(len(chunk)) > remain {
+			chunk = chunk[:remain]
+		}
+		read, err := part.r.ReadAt(chunk, rel)
+		n += read
+		off += int64(read)
+		p = p[read:]
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+		if read < len(chunk) {
+			return n, io.ErrUnexpectedEOF
+		}
+	}
+
+	if len(p) > 0 {
+		return n, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,313 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gitee.com/MM-Q/bakctl/internal/bz2"
+	"gitee.com/MM-Q/bakctl/internal/crypt"
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/bakctl/internal/utils"
+	"gitee.com/MM-Q/comprx"
+)
+
+// Write 按归档格式将条目写入备份文件
+//
+// 指定了加密密钥时，归档数据在写入文件前经过加密，未加密的归档数据不会落盘；
+// 指定了分卷大小时，归档数据按分卷大小依次写入 dst.001、dst.002... 等分卷文件；
+// 无法读取的条目按错误处理策略跳过或中止写入，被跳过的条目不会写入归档，也没有内容哈希；
+// 写入失败或被取消时会删除未完成的文件。
+//
+// 参数:
+//   - ctx: 上下文，被取消或超时后立即停止写入
+//   - format: 归档格式（zip/tar/tar.gz/tar.bz2，为空时使用 zip）
+//   - dst: 目标文件路径（不允许已存在）
+//   - volumeSize: 分卷大小（为 0 时不分卷）
+//   - entries: 待写入的条目
+//   - level: 压缩等级
+//   - storeExts: 始终仅存储的文件扩展名（仅 zip 格式有效，tar.gz 和 tar.bz2 格式整体压缩）
+//   - key: 加密密钥（为 nil 时不加密）
+//   - tol: 错误处理策略（为 nil 时任何条目出错都中止写入）
+//   - progress: 写入进度（接收已读取的文件内容，为 nil 时不显示）
+//
+// 返回值:
+//   - map[string]string: 普通文件的内容哈希，键为归档内路径
+//   - error: 写入失败或格式不受支持时返回错误信息
+func Write(ctx context.Context, format, dst string, volumeSize int64, entries []Entry, level comprx.CompressionLevel, storeExts []string, key *crypt.Key, tol *Tolerance, progress io.Writer) (hashes map[string]string, err error) {
+	if format != "" && format != types.FormatZip && !isTarFormat(format) {
+		return nil, fmt.Errorf("不支持的归档格式: %s", format)
+	}
+
+	// 确保目标目录存在
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return nil, fmt.Errorf("创建目标目录失败: %w", err)
+	}
+
+	var f io.WriteCloser
+	var remove func()
+	if volumeSize > 0 {
+		vw := &volumeWriter{base: dst, size: volumeSize}
+		f, remove = vw, vw.remove
+	} else {
+		if f, err = os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644); err != nil {
+			return nil, fmt.Errorf("创建备份文件失败: %w", err)
+		}
+		remove = func() { _ = os.Remove(dst) }
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("关闭备份文件失败: %w", closeErr)
+		}
+		if err != nil {
+			remove()
+		}
+	}()
+
+	var w io.Writer = f
+	var ew io.WriteCloser
+	if key != nil {
+		if ew, err = crypt.NewWriter(f, key); err != nil {
+			return nil, err
+		}
+		w = ew
+	}
+
+	if isTarFormat(format) {
+		hashes, err = WriteTar(ctx, w, entries, format, level, tol, progress)
+	} else {
+		hashes, err = WriteZip(ctx, w, entries, level, storeExts, tol, progress)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 写入最后一个加密块
+	if ew != nil {
+		if err = ew.Close(); err != nil {
+			return nil, fmt.Errorf("写入加密数据失败: %w", err)
+		}
+	}
+
+	return hashes, nil
+}
+
+// WriteTar 将条目以 tar 格式写入 w（可选 gzip 或 bzip2 压缩）
+//
+// 文件头通过 tar.FileInfoHeader 生成，保留文件的权限、属主和修改时间，
+// 并以 PAX 格式记录访问时间和扩展属性；
+// 普通文件在写入的同时计算内容的 sha256 哈希。
+// 同一文件的多个硬链接只存储一次内容，之后的硬链接写入指向第一次写入路径的链接条目。
+// 容忍读取过程中被修改的文件时，变小的文件以零字节补齐文件头记录的大小。
+//
+// 参数:
+//   - ctx: 上下文，被取消或超时后立即停止写入
+//   - w: 写入目标（不会被关闭）
+//   - entries: 待写入的条目
+//   - format: 归档格式（tar/tar.gz/tar.bz2）
+//   - level: 压缩等级（CompressionLevelNone 时使用默认等级，tar.gz 和 tar.bz2 格式始终压缩）
+//   - tol: 错误处理策略（为 nil 时任何条目出错都中止写入）
+//   - progress: 写入进度（接收已读取的文件内容，为 nil 时不显示）
+//
+// 返回值:
+//   - map[string]string: 普通文件的内容哈希，键为归档内路径
+//   - error: 写入失败时返回错误信息
+func WriteTar(ctx context.Context, w io.Writer, entries []Entry, format string, level comprx.CompressionLevel, tol *Tolerance, progress io.Writer) (hashes map[string]string, err error) {
+	var cw io.WriteCloser
+	switch format {
+	case types.FormatTarGz:
+		if level == comprx.CompressionLevelNone {
+			level = comprx.CompressionLevelDefault
+		}
+		if cw, err = gzip.NewWriterLevel(w, int(level)); err != nil {
+			return nil, fmt.Errorf("创建 gzip 压缩器失败: %w", err)
+		}
+		w = cw
+	case types.FormatTarBz2:
+		// bzip2 的压缩等级即块大小（1-9），默认、不压缩和仅 Huffman 编码均使用最大的块
+		bzLevel := bz2.DefaultCompression
+		if level >= comprx.CompressionLevelFast && level <= comprx.CompressionLevelBest {
+			bzLevel = int(level)
+		}
+		if cw, err = bz2.NewWriterLevel(w, bzLevel); err != nil {
+			return nil, fmt.Errorf("创建 bzip2 压缩器失败: %w", err)
+		}
+		w = cw
+	}
+
+	tw := tar.NewWriter(w)
+	hashes = make(map[string]string)
+	links := make(map[string]string) // 硬链接文件的唯一标识 -> 第一次写入的归档内路径
+	for _, e := range entries {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		key, linked := HardlinkKey(e.Info)
+		if first, ok := links[key]; linked && ok {
+			if err = writeTarHardlink(tw, e, first); err != nil {
+				return nil, err
+			}
+			hashes[e.Name] = hashes[first]
+			continue
+		}
+
+		var sum string
+		sum, err = writeTarEntry(ctx, tw, e, tol, progress)
+		if err != nil {
+			return nil, err
+		}
+		if tol.Skipped(e.Name) {
+			continue
+		}
+		if linked {
+			links[key] = e.Name // 只有成功写入的文件才能作为之后硬链接的目标
+		}
+		if e.IsRegular() {
+			hashes[e.Name] = sum
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("写入 tar 结束标记失败: %w", err)
+	}
+	if cw != nil {
+		if err := cw.Close(); err != nil {
+			return nil, fmt.Errorf("写入 %s 压缩数据失败: %w", format, err)
+		}
+	}
+
+	return hashes, nil
+}
+
+// writeTarHardlink 写入指向归档内已写入文件的硬链接条目
+func writeTarHardlink(tw *tar.Writer, e Entry, target string) error {
+	header, err := tar.FileInfoHeader(e.Info, "")
+	if err != nil {
+		return fmt.Errorf("创建 '%s' 的文件头失败: %w", e.Name, err)
+	}
+	header.Name = e.Name
+	header.Typeflag = tar.TypeLink
+	header.Linkname = target
+	header.Size = 0
+
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("写入 '%s' 的文件头失败: %w", e.Name, err)
+	}
+	return nil
+}
+
+// writeTarEntry 写入单个条目，普通文件返回内容哈希
+//
+// 无法读取的条目在写入文件头之前按错误处理策略跳过，不会在归档中留下不完整的条目。
+func writeTarEntry(ctx context.Context, tw *tar.Writer, e Entry, tol *Tolerance, progress io.Writer) (string, error) {
+	var link string
+	if e.Info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(e.Path)
+		if err != nil {
+			if tol.Skip(e.Name, err) {
+				return "", nil
+			}
+			return "", fmt.Errorf("读取符号链接 '%s' 失败: %w", e.Path, err)
+		}
+		link = target
+	}
+
+	// 普通文件在写入文件头之前打开，无法读取时还可以跳过
+	var src *os.File
+	if e.IsRegular() {
+		f, err := os.Open(e.Path)
+		if err != nil {
+			if tol.Skip(e.Name, err) {
+				return "", nil
+			}
+			return "", fmt.Errorf("打开文件 '%s' 失败: %w", e.Path, err)
+		}
+		defer func() { _ = f.Close() }()
+		src = f
+	}
+
+	header, err := tar.FileInfoHeader(e.Info, link)
+	if err != nil {
+		return "", fmt.Errorf("创建 '%s' 的文件头失败: %w", e.Name, err)
+	}
+	header.Name = e.Name
+	if e.Info.IsDir() {
+		header.Name += "/"
+	}
+	setTarMetadata(header, e)
+
+	if err := tw.WriteHeader(header); err != nil {
+		return "", fmt.Errorf("写入 '%s' 的文件头失败: %w", e.Name, err)
+	}
+	if src == nil {
+		return "", nil
+	}
+
+	// 写入的内容必须与文件头中的大小一致，备份过程中文件变大时只写入文件头记录的大小
+	h := sha256.New()
+	dst := io.MultiWriter(tw, h)
+	if progress != nil {
+		dst = io.MultiWriter(tw, h, progress)
+	}
+	n, err := io.CopyN(dst, utils.NewContextReader(ctx, src), header.Size)
+	switch {
+	case err == io.EOF && tol.Changed(e.Name, fmt.Errorf("文件在读取过程中变小 (%d -> %d 字节), 已以零字节补齐", header.Size, n)):
+		if _, err := io.CopyN(io.MultiWriter(tw, h), zeroReader{}, header.Size-n); err != nil {
+			return "", fmt.Errorf("写入文件 '%s' 失败: %w", e.Name, err)
+		}
+	case err != nil:
+		return "", fmt.Errorf("写入文件 '%s' 失败: %w", e.Name, err)
+	default:
+		tol.CheckChanged(e, src)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// zeroReader 无限读取零字节
+type zeroReader struct{}
+
+// Read 将 p 全部填充为零字节
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// isTarFormat 判断归档格式是否为 tar 格式（包括压缩的 tar 格式）
+func isTarFormat(format string) bool {
+	return format == types.FormatTar || format == types.FormatTarGz || format == types.FormatTarBz2
+}
+
+// newTarReader 按归档格式返回读取 tar 数据的 Reader（tar.gz 和 tar.bz2 格式先解压）
+//
+// 参数:
+//   - r: 归档数据
+//   - format: 归档格式（tar/tar.gz/tar.bz2）
+//
+// 返回值:
+//   - io.Reader: tar 数据
+//   - func(): 释放解压器的函数
+//   - error: 压缩数据头无效时返回错误信息
+func newTarReader(r io.Reader, format string) (io.Reader, func(), error) {
+	switch format {
+	case types.FormatTarGz:
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("读取 gzip 数据失败: %w", err)
+		}
+		return gr, func() { _ = gr.Close() }, nil
+	case types.FormatTarBz2:
+		return bzip2.NewReader(r), func() {}, nil
+	default:
+		return r, func() {}, nil
+	}
+}
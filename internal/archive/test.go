@@ -0,0 +1,209 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/bakctl/internal/utils"
+)
+
+// DetectFormat 根据备份文件名判断归档格式
+//
+// 参数:
+//   - name: 备份文件名（不含加密扩展名）
+//
+// 返回值:
+//   - string: 归档格式（无法识别时为空）
+func DetectFormat(name string) string {
+	switch {
+	case strings.HasSuffix(name, types.FormatExt(types.FormatTarBz2)):
+		return types.FormatTarBz2
+	case strings.HasSuffix(name, types.FormatExt(types.FormatTarGz)):
+		return types.FormatTarGz
+	case strings.HasSuffix(name, types.FormatExt(types.FormatTar)):
+		return types.FormatTar
+	case strings.HasSuffix(name, types.FormatExt(types.FormatZip)):
+		return types.FormatZip
+	default:
+		return ""
+	}
+}
+
+// Test 读取归档中的所有条目，检查归档结构和每个条目的内容校验
+//
+// zip 格式校验每个条目的 CRC32；tar.gz 格式校验 gzip 流的 CRC32；tar.bz2 格式校验每个 bzip2 块的 CRC；
+// tar 格式没有内容校验，只能检查归档结构是否完整。加密的备份同时验证每个加密块，
+// 加密的 zip 备份解密到备份文件所在目录中的临时文件，检查完成后删除。
+// 指定了文件清单中记录的内容哈希时，同时计算每个普通文件的 sha256 哈希与之对比，
+// 并检查清单中的文件是否都在归档中。
+//
+// 参数:
+//   - ctx: 上下文，被取消或超时后立即停止读取
+//   - src: 备份文件（分卷备份按顺序传入所有分卷）
+//   - hashes: 文件清单中记录的普通文件内容哈希，键为归档内路径（为 nil 时不对比）
+//
+// 返回值:
+//   - int: 检查的条目数
+//   - error: 归档损坏、内容与文件清单不一致或读取失败时返回错误信息
+func Test(ctx context.Context, src Source, hashes map[string]string) (int, error) {
+	mr, closeAll, err := src.open()
+	if err != nil {
+		return 0, err
+	}
+	defer closeAll()
+
+	check := &hashCheck{want: hashes, got: make(map[string]string)}
+	var count int
+	switch src.Format {
+	case types.FormatZip:
+		r, size, cleanup, err := src.zipReader(ctx, mr, filepath.Dir(src.Paths[0]))
+		if err != nil {
+			return 0, err
+		}
+		defer cleanup()
+		if count, err = testZip(ctx, r, size, check); err != nil {
+			return count, err
+		}
+	case types.FormatTar, types.FormatTarGz, types.FormatTarBz2:
+		r, err := src.stream(mr)
+		if err != nil {
+			return 0, err
+		}
+		if count, err = testTar(ctx, r, src.Format, check); err != nil {
+			return count, err
+		}
+	default:
+		return 0, fmt.Errorf("不支持的归档格式: %s", src.Format)
+	}
+
+	return count, check.missing()
+}
+
+// testZip 读取 zip 归档中的所有条目并校验 CRC32
+func testZip(ctx context.Context, ra io.ReaderAt, size int64, check *hashCheck) (int, error) {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return 0, fmt.Errorf("读取 zip 归档失败: %w", err)
+	}
+
+	for i, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return i, fmt.Errorf("打开归档条目 %s 失败: %w", f.Name, err)
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, utils.NewContextReader(ctx, rc))
+		_ = rc.Close()
+		if err != nil {
+			return i, fmt.Errorf("归档条目 %s 校验失败: %w", f.Name, err)
+		}
+
+		switch link := zipHardlink(f.Extra); {
+		case link != "":
+			err = check.link(f.Name, link)
+		case f.Mode().IsRegular():
+			err = check.add(f.Name, hex.EncodeToString(h.Sum(nil)))
+		}
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(zr.File), nil
+}
+
+// testTar 读取 tar、tar.gz 或 tar.bz2 归档中的所有条目
+func testTar(ctx context.Context, src io.Reader, format string, check *hashCheck) (int, error) {
+	src = utils.NewContextReader(ctx, src)
+	r, release, err := newTarReader(src, format)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	tr := tar.NewReader(r)
+	count := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, fmt.Errorf("读取 tar 归档失败: %w", err)
+		}
+
+		h := sha256.New()
+		if _, err := io.Copy(h, tr); err != nil {
+			return count, fmt.Errorf("归档条目 %s 校验失败: %w", hdr.Name, err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			err = check.add(hdr.Name, hex.EncodeToString(h.Sum(nil)))
+		case tar.TypeLink:
+			err = check.link(hdr.Name, hdr.Linkname)
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, drainTar(r, src)
+}
+
+// hashCheck 对比归档中普通文件的内容哈希与文件清单中记录的哈希
+type hashCheck struct {
+	want map[string]string // 文件清单中记录的哈希（为 nil 时不对比）
+	got  map[string]string // 归档中普通文件的哈希
+}
+
+// add 记录普通文件的内容哈希并与文件清单对比
+func (c *hashCheck) add(name, sum string) error {
+	c.got[name] = sum
+	if want, ok := c.want[name]; ok && want != sum {
+		return fmt.Errorf("归档条目 %s 的内容与文件清单不一致: 期望 %s, 实际 %s", name, want, sum)
+	}
+	return nil
+}
+
+// link 记录硬链接条目，内容与归档中的目标文件相同
+func (c *hashCheck) link(name, target string) error {
+	sum, ok := c.got[target]
+	if !ok {
+		return fmt.Errorf("硬链接 %s 的目标 %s 不在归档中", name, target)
+	}
+	return c.add(name, sum)
+}
+
+// missing 检查文件清单中的文件是否都在归档中
+func (c *hashCheck) missing() error {
+	var names []string
+	for name := range c.want {
+		if _, ok := c.got[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	sort.Strings(names)
+	n := len(names)
+	if n > 3 {
+		names = append(names[:3], "...")
+	}
+	return fmt.Errorf("文件清单中的 %d 个文件不在归档中: %s", n, strings.Join(names, ", "))
+}
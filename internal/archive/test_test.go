@@ -0,0 +1,80 @@
+package archive
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitee.com/MM-Q/bakctl/internal/crypt"
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"gitee.com/MM-Q/comprx"
+)
+
+func TestTestManifestHashes(t *testing.T) {
+	secret := crypt.Secret{Passphrase: "correct horse"}
+	key, err := crypt.NewKey(secret)
+	if err != nil {
+		t.Fatalf("NewKey() error = %v", err)
+	}
+
+	tests := []struct {
+		format string
+		key    *crypt.Key
+	}{
+		{types.FormatTarGz, nil},
+		{types.FormatZip, nil},
+		{types.FormatTar, key},
+		{types.FormatZip, key},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "data")
+			writeTree(t, dir, map[string]string{"data/a.txt": "a", "data/sub/b.txt": "b"})
+			if err := os.Link(filepath.Join(src, "a.txt"), filepath.Join(src, "sub", "a-link.txt")); err != nil {
+				t.Fatalf("Link() error = %v", err)
+			}
+
+			entries, err := Collect(src, nil)
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			dst := filepath.Join(dir, "backup"+types.FormatExt(tt.format))
+			hashes, err := Write(context.Background(), tt.format, dst, 0, entries, comprx.CompressionLevelDefault, nil, tt.key, nil, nil)
+			if err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if len(hashes) != 3 {
+				t.Fatalf("Write() 返回的哈希数 = %d, want 3", len(hashes))
+			}
+
+			s := Source{Paths: []string{dst}, Format: tt.format}
+			if tt.key != nil {
+				s.Secret = &secret
+			}
+			if _, err := Test(context.Background(), s, hashes); err != nil {
+				t.Fatalf("Test() error = %v", err)
+			}
+
+			// 内容哈希与文件清单不一致
+			wrong := map[string]string{"data/sub/b.txt": strings.Repeat("0", 64)}
+			if _, err := Test(context.Background(), s, wrong); err == nil || !strings.Contains(err.Error(), "data/sub/b.txt") {
+				t.Errorf("Test() 哈希不一致 error = %v", err)
+			}
+
+			// 文件清单中的文件不在归档中
+			missing := map[string]string{"data/gone.txt": hashes["data/a.txt"]}
+			if _, err := Test(context.Background(), s, missing); err == nil || !strings.Contains(err.Error(), "data/gone.txt") {
+				t.Errorf("Test() 缺少文件 error = %v", err)
+			}
+
+			leftovers, _ := filepath.Glob(filepath.Join(dir, decryptTempPattern))
+			if len(leftovers) > 0 {
+				t.Errorf("备份目录中残留解密临时文件: %v", leftovers)
+			}
+		})
+	}
+}
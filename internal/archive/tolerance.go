@@ -0,0 +1,136 @@
+package archive
+
+import (
+	"fmt"
+	"os"
+
+	"gitee.com/MM-Q/bakctl/internal/types"
+)
+
+// FileWarning 按错误处理策略被跳过或未能完整备份的条目
+type FileWarning struct {
+	Name string // 条目在归档内的路径
+	Err  error  // 原因
+}
+
+// Error 返回错误信息
+func (w FileWarning) Error() string {
+	return fmt.Sprintf("%s: %v", w.Name, w.Err)
+}
+
+// Tolerance 按任务的错误处理策略处理单个条目的错误，并收集被容忍的错误
+//
+// 为 nil 时（strict 策略）任何条目的错误都会中止备份，所有方法都可以在 nil 上调用。
+// 只容忍打开、读取文件信息和读取链接等针对单个条目的错误，写入备份文件失败、
+// 文件内容读取到一半时出错（归档中的条目已经无法撤回）等错误仍然中止备份。
+type Tolerance struct {
+	changed  bool            // 是否容忍读取过程中被修改的文件
+	warnings []FileWarning   // 被容忍的错误
+	skipped  map[string]bool // 被跳过的条目（归档内路径）
+}
+
+// NewTolerance 根据错误处理策略创建 Tolerance
+//
+// 参数:
+//   - policy: 错误处理策略（strict/skip-unreadable/skip-changed）
+//
+// 返回值:
+//   - *Tolerance: strict 策略返回 nil
+func NewTolerance(policy string) *Tolerance {
+	switch policy {
+	case types.ErrorPolicySkipUnreadable:
+		return &Tolerance{skipped: make(map[string]bool)}
+	case types.ErrorPolicySkipChanged:
+		return &Tolerance{changed: true, skipped: make(map[string]bool)}
+	default:
+		return nil
+	}
+}
+
+// Skip 条目无法读取（权限不足、备份过程中被删除等）时判断是否跳过该条目
+//
+// 参数:
+//   - name: 条目在归档内的路径
+//   - err: 读取条目时的错误
+//
+// 返回值:
+//   - bool: 为 true 时已记录警告，调用方应跳过该条目；为 false 时调用方应返回错误
+func (t *Tolerance) Skip(name string, err error) bool {
+	if !t.warn(name, err) {
+		return false
+	}
+	t.skipped[name] = true
+	return true
+}
+
+// warn 记录无法完整读取的条目（如无法列出内容的目录），条目本身仍然保留
+func (t *Tolerance) warn(name string, err error) bool {
+	if t == nil {
+		return false
+	}
+	t.warnings = append(t.warnings, FileWarning{Name: name, Err: err})
+	return true
+}
+
+// Changed 文件在读取过程中被修改时判断是否继续备份
+//
+// 参数:
+//   - name: 条目在归档内的路径
+//   - err: 文件被修改的原因
+//
+// 返回值:
+//   - bool: 为 true 时已记录警告，调用方应继续备份；为 false 时不容忍
+func (t *Tolerance) Changed(name string, err error) bool {
+	if t == nil || !t.changed {
+		return false
+	}
+	t.warnings = append(t.warnings, FileWarning{Name: name, Err: err})
+	return true
+}
+
+// CheckChanged 读取完成后检查文件的大小和修改时间是否与遍历时一致
+//
+// 只有容忍被修改的文件时才检查，不一致时记录警告；其他策略与之前一样不检查。
+//
+// 参数:
+//   - e: 条目
+//   - f: 已读取完成、尚未关闭的文件
+func (t *Tolerance) CheckChanged(e Entry, f *os.File) {
+	if t == nil || !t.changed {
+		return
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return
+	}
+	if info.Size() != e.Info.Size() || !info.ModTime().Equal(e.Info.ModTime()) {
+		t.Changed(e.Name, fmt.Errorf("文件在读取过程中被修改 (大小 %d -> %d)", e.Info.Size(), info.Size()))
+	}
+}
+
+// Skipped 判断条目是否因错误被跳过
+func (t *Tolerance) Skipped(name string) bool {
+	return t != nil && t.skipped[name]
+}
+
+// Filter 返回未被跳过的条目
+func (t *Tolerance) Filter(entries []Entry) []Entry {
+	if t == nil || len(t.skipped) == 0 {
+		return entries
+	}
+	kept := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if !t.skipped[e.Name] {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// Warnings 返回被容忍的错误（按发生顺序排列）
+func (t *Tolerance) Warnings() []FileWarning {
+	if t == nil {
+		return nil
+	}
+	return t.warnings
+}
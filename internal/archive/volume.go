@@ -0,0 +1,133 @@
+package archive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gitee.com/MM-Q/bakctl/internal/types"
+)
+
+// volumeWriter 将写入的数据按固定大小依次写入多个分卷文件（base.001、base.002...）
+type volumeWriter struct {
+	base  string   // 备份文件路径
+	size  int64    // 每个分卷的大小
+	f     *os.File // 当前写入的分卷文件
+	n     int64    // 当前分卷已写入的字节数
+	count int      // 已创建的分卷数量
+}
+
+// Write 写入数据，当前分卷写满时自动创建下一个分卷
+func (w *volumeWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if w.f == nil || w.n >= w.size {
+			if err := w.next(); err != nil {
+				return written, err
+			}
+		}
+
+		chunk := p
+		if remain := w.size - w.n; int64(len(chunk)) > remain {
+			chunk = chunk[:remain]
+		}
+		n, err := w.f.Write(chunk)
+		written += n
+		w.n += int64(n)
+		if err != nil {
+			return written, fmt.Errorf("写入分卷文件失败: %w", err)
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// next 关闭当前分卷并创建下一个分卷
+func (w *volumeWriter) next() error {
+	if err := w.closeCurrent(); err != nil {
+		return err
+	}
+
+	path := types.VolumePath(w.base, w.count+1)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("创建分卷文件失败: %w", err)
+	}
+	w.f, w.n = f, 0
+	w.count++
+	return nil
+}
+
+// closeCurrent 关闭当前写入的分卷文件
+func (w *volumeWriter) closeCurrent() error {
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	if err != nil {
+		return fmt.Errorf("关闭分卷文件失败: %w", err)
+	}
+	return nil
+}
+
+// Close 关闭最后一个分卷（没有写入任何数据时也创建一个空的分卷）
+func (w *volumeWriter) Close() error {
+	if w.count == 0 {
+		if err := w.next(); err != nil {
+			return err
+		}
+	}
+	return w.closeCurrent()
+}
+
+// remove 删除已创建的所有分卷文件
+func (w *volumeWriter) remove() {
+	_ = w.closeCurrent()
+	for seq := 1; seq <= w.count; seq++ {
+		_ = os.Remove(types.VolumePath(w.base, seq))
+	}
+}
+
+// VolumeFiles 返回备份文件已存在的分卷文件路径
+//
+// 分卷序号可能不连续（如进程在重命名分卷的过程中被中断），因此通过读取目录查找。
+//
+// 参数:
+//   - backupPath: 备份文件路径
+//
+// 返回值:
+//   - []string: 分卷文件路径（按分卷序号排序）
+//   - error: 读取目录失败时返回错误信息
+func VolumeFiles(backupPath string) ([]string, error) {
+	dir, base := filepath.Split(backupPath)
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取目录失败: %w", err)
+	}
+
+	seqs := make(map[string]int)
+	var paths []string
+	for _, e := range entries {
+		suffix, ok := strings.CutPrefix(e.Name(), base+".")
+		if !ok || e.IsDir() {
+			continue
+		}
+		seq, err := strconv.Atoi(suffix)
+		if err != nil || seq <= 0 || types.VolumePath(base, seq) != e.Name() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		seqs[path] = seq
+		paths = append(paths, path)
+	}
+
+	sort.Slice(paths, func(i, j int) bool { return seqs[paths[i]] < seqs[paths[j]] })
+	return paths, nil
+}
@@ -0,0 +1,15 @@
+//go:build !(darwin || freebsd || linux || netbsd)
+
+package archive
+
+import "errors"
+
+// readXattrs 当前平台不支持扩展属性
+func readXattrs(path string, follow bool) (map[string][]byte, error) {
+	return nil, nil
+}
+
+// writeXattr 当前平台不支持扩展属性
+func writeXattr(path, name string, value []byte) error {
+	return errors.New("当前平台不支持扩展属性")
+}
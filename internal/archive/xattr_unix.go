@@ -0,0 +1,77 @@
+//go:build darwin || freebsd || linux || netbsd
+
+package archive
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// readXattrs 读取文件的扩展属性
+//
+// 参数:
+//   - path: 文件路径
+//   - follow: 是否跟随符号链接（为 false 时读取符号链接本身的扩展属性）
+//
+// 返回值:
+//   - map[string][]byte: 扩展属性（没有扩展属性时为 nil）
+//   - error: 读取失败时返回错误信息（文件系统不支持扩展属性时返回 nil）
+func readXattrs(path string, follow bool) (map[string][]byte, error) {
+	list, get := unix.Llistxattr, unix.Lgetxattr
+	if follow {
+		list, get = unix.Listxattr, unix.Getxattr
+	}
+
+	names, err := readXattrBuf(func(buf []byte) (int, error) { return list(path, buf) })
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var xattrs map[string][]byte
+	for _, name := range bytes.Split(names, []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		value, err := readXattrBuf(func(buf []byte) (int, error) { return get(path, string(name), buf) })
+		if err != nil {
+			return nil, err
+		}
+		if xattrs == nil {
+			xattrs = make(map[string][]byte)
+		}
+		xattrs[string(name)] = value
+	}
+	return xattrs, nil
+}
+
+// readXattrBuf 先获取所需的缓冲区大小再读取内容（两次调用之间内容变大时重试）
+func readXattrBuf(read func([]byte) (int, error)) ([]byte, error) {
+	for {
+		size, err := read(nil)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size)
+		n, err := read(buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
+
+// writeXattr 设置文件的扩展属性（不跟随符号链接）
+func writeXattr(path, name string, value []byte) error {
+	return unix.Lsetxattr(path, name, value, 0)
+}
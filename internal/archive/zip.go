@@ -0,0 +1,201 @@
+package archive
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitee.com/MM-Q/bakctl/internal/utils"
+	"gitee.com/MM-Q/comprx"
+)
+
+// WriteZip 将条目以 ZIP 格式写入 w
+//
+// 普通文件在写入的同时计算内容的 sha256 哈希。
+// 扩展名位于 storeExts 中的文件（如已经压缩过的图片、视频和压缩包）始终仅存储，避免重复压缩浪费 CPU。
+// ZIP 格式没有硬链接条目，同一文件的多个硬链接只存储一次内容（与 tar 格式相同），
+// 之后的硬链接写入为空文件，并在 bakctl 扩展字段中记录第一次写入的归档内路径，解压时重建为硬链接
+// （其他解压工具会将其解压为空文件）。
+// 属主和属组记录在 Info-ZIP 的 Unix 扩展字段中（与 unzip -X 兼容），访问时间和扩展属性记录在 bakctl 自有的扩展字段中。
+// 无法读取的条目按错误处理策略跳过，被跳过的条目不会写入归档，也没有内容哈希。
+//
+// 参数:
+//   - ctx: 上下文，被取消或超时后立即停止写入
+//   - w: 写入目标（不会被关闭）
+//   - entries: 待写入的条目
+//   - level: 压缩等级（CompressionLevelNone 表示仅存储）
+//   - storeExts: 始终仅存储的文件扩展名（小写，不含 "."）
+//   - tol: 错误处理策略（为 nil 时任何条目出错都中止写入）
+//   - progress: 写入进度（接收已读取的文件内容，为 nil 时不显示）
+//
+// 返回值:
+//   - map[string]string: 普通文件的内容哈希，键为归档内路径
+//   - error: 写入失败时返回错误信息
+func WriteZip(ctx context.Context, w io.Writer, entries []Entry, level comprx.CompressionLevel, storeExts []string, tol *Tolerance, progress io.Writer) (hashes map[string]string, err error) {
+	// 压缩方法与 comprx 保持一致：不压缩时仅存储，否则使用 Deflate
+	method := zip.Deflate
+	if level == comprx.CompressionLevelNone {
+		method = zip.Store
+	}
+
+	zw := zip.NewWriter(w)
+	if method == zip.Deflate {
+		zw.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, int(level))
+		})
+	}
+	store := make(map[string]bool, len(storeExts))
+	for _, ext := range storeExts {
+		store[ext] = true
+	}
+
+	hashes = make(map[string]string)
+	links := make(map[string]string) // 硬链接文件的唯一标识 -> 第一次写入的归档内路径
+	for _, e := range entries {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		key, linked := HardlinkKey(e.Info)
+		if first, ok := links[key]; linked && ok {
+			if err = writeZipHardlink(zw, e, first); err != nil {
+				return nil, err
+			}
+			hashes[e.Name] = hashes[first]
+			continue
+		}
+
+		switch {
+		case e.Info.IsDir():
+			err = writeDir(zw, e)
+		case e.IsRegular():
+			var sum string
+			fileMethod := method
+			if store[strings.ToLower(strings.TrimPrefix(filepath.Ext(e.Name), "."))] {
+				fileMethod = zip.Store
+			}
+			sum, err = writeFile(ctx, zw, e, fileMethod, tol, progress)
+			if !tol.Skipped(e.Name) {
+				hashes[e.Name] = sum
+				if linked {
+					links[key] = e.Name // 只有成功写入的文件才能作为之后硬链接的目标
+				}
+			}
+		case e.Info.Mode()&fs.ModeSymlink != 0:
+			err = writeSymlink(zw, e, tol)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("写入 ZIP 目录失败: %w", err)
+	}
+
+	return hashes, nil
+}
+
+// writeDir 写入目录条目
+func writeDir(zw *zip.Writer, e Entry) error {
+	header, err := zip.FileInfoHeader(e.Info)
+	if err != nil {
+		return fmt.Errorf("创建目录 '%s' 的文件头失败: %w", e.Name, err)
+	}
+	header.Name = e.Name + "/"
+	header.Method = zip.Store
+	header.Extra = zipExtra(ReadMetadata(e), "")
+
+	if _, err := zw.CreateHeader(header); err != nil {
+		return fmt.Errorf("写入目录 '%s' 失败: %w", e.Name, err)
+	}
+	return nil
+}
+
+// writeFile 写入普通文件条目并返回内容哈希
+//
+// 文件在写入文件头之前打开，无法读取时按错误处理策略跳过，不会在归档中留下空条目。
+func writeFile(ctx context.Context, zw *zip.Writer, e Entry, method uint16, tol *Tolerance, progress io.Writer) (string, error) {
+	src, err := os.Open(e.Path)
+	if err != nil {
+		if tol.Skip(e.Name, err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("打开文件 '%s' 失败: %w", e.Path, err)
+	}
+	defer func() { _ = src.Close() }()
+
+	header, err := zip.FileInfoHeader(e.Info)
+	if err != nil {
+		return "", fmt.Errorf("创建文件 '%s' 的文件头失败: %w", e.Name, err)
+	}
+	header.Name = e.Name
+	header.Method = method
+	header.Extra = zipExtra(ReadMetadata(e), "")
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return "", fmt.Errorf("写入文件 '%s' 失败: %w", e.Name, err)
+	}
+
+	h := sha256.New()
+	dst := io.MultiWriter(w, h)
+	if progress != nil {
+		dst = io.MultiWriter(w, h, progress)
+	}
+	if _, err := io.Copy(dst, utils.NewContextReader(ctx, src)); err != nil {
+		return "", fmt.Errorf("写入文件 '%s' 失败: %w", e.Name, err)
+	}
+	tol.CheckChanged(e, src)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeZipHardlink 写入指向归档内已写入文件的硬链接条目（内容为空，链接目标记录在扩展字段中）
+func writeZipHardlink(zw *zip.Writer, e Entry, target string) error {
+	header, err := zip.FileInfoHeader(e.Info)
+	if err != nil {
+		return fmt.Errorf("创建 '%s' 的文件头失败: %w", e.Name, err)
+	}
+	header.Name = e.Name
+	header.Method = zip.Store
+	header.UncompressedSize64 = 0
+	header.Extra = zipExtra(ReadMetadata(e), target)
+
+	if _, err := zw.CreateHeader(header); err != nil {
+		return fmt.Errorf("写入硬链接 '%s' 失败: %w", e.Name, err)
+	}
+	return nil
+}
+
+// writeSymlink 写入符号链接条目（内容为链接目标）
+func writeSymlink(zw *zip.Writer, e Entry, tol *Tolerance) error {
+	target, err := os.Readlink(e.Path)
+	if err != nil {
+		if tol.Skip(e.Name, err) {
+			return nil
+		}
+		return fmt.Errorf("读取符号链接 '%s' 失败: %w", e.Path, err)
+	}
+
+	header := &zip.FileHeader{Name: e.Name, Method: zip.Store, Modified: e.Info.ModTime()}
+	header.SetMode(e.Info.Mode())
+	header.Extra = zipExtra(ReadMetadata(e), "")
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("写入符号链接 '%s' 失败: %w", e.Name, err)
+	}
+	if _, err := w.Write([]byte(target)); err != nil {
+		return fmt.Errorf("写入符号链接 '%s' 失败: %w", e.Name, err)
+	}
+	return nil
+}
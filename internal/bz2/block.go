@@ -0,0 +1,407 @@
+package bz2
+
+const (
+	groupSize  = 50 // 每个编码表选择器覆盖的符号数
+	maxCodeLen = 17 // 压缩时使用的最大 Huffman 编码长度（与 bzip2 相同）
+	maxGroups  = 6  // 最多的 Huffman 编码表数
+	iterations = 4  // 优化编码表的迭代次数
+)
+
+// encodeBlock 对游程编码后的块进行块排序、前移编码和 Huffman 编码并写入
+//
+// 参数:
+//   - bw: 位写入器（块头标记和 CRC 已写入）
+//   - data: 游程编码后的块数据（不能为空）
+func encodeBlock(bw *bitWriter, data []byte) {
+	n := len(data)
+
+	// 块排序：输出所有循环移位排序后的最后一列，以及原始数据所在的行
+	rot := sortRotations(data)
+	last := make([]byte, n)
+	origPtr := 0
+	for i, p := range rot {
+		if p == 0 {
+			origPtr = i
+			last[i] = data[n-1]
+		} else {
+			last[i] = data[p-1]
+		}
+	}
+	bw.writeBits(24, uint64(origPtr))
+
+	// 使用的字节表：16 位标记每 16 个字节中是否有使用的字节，再逐个标记
+	var inUse [256]bool
+	for _, b := range data {
+		inUse[b] = true
+	}
+	var ranges uint64
+	for i := 0; i < 16; i++ {
+		for j := 0; j < 16; j++ {
+			if inUse[i*16+j] {
+				ranges |= 1 << (15 - i)
+				break
+			}
+		}
+	}
+	bw.writeBits(16, ranges)
+	for i := 0; i < 16; i++ {
+		if ranges&(1<<(15-i)) == 0 {
+			continue
+		}
+		var bits uint64
+		for j := 0; j < 16; j++ {
+			if inUse[i*16+j] {
+				bits |= 1 << (15 - j)
+			}
+		}
+		bw.writeBits(16, bits)
+	}
+
+	var seq [256]byte
+	nInUse := 0
+	for i, used := range inUse {
+		if used {
+			seq[i] = byte(nInUse)
+			nInUse++
+		}
+	}
+
+	syms := mtfEncode(last, &seq, nInUse)
+	writeHuffman(bw, syms, nInUse+2)
+}
+
+// sortRotations 返回按字典序排列的所有循环移位的起始位置
+//
+// 使用倍增法（每轮按前 2h 个字节的排名进行计数排序），
+// 对重复内容较多的数据也保持 O(n log n) 的时间复杂度。
+func sortRotations(data []byte) []int32 {
+	n := len(data)
+	p := make([]int32, n)
+	c := make([]int32, n)
+	pn := make([]int32, n)
+	cn := make([]int32, n)
+	cnt := make([]int32, max(256, n))
+
+	for _, b := range data {
+		cnt[b]++
+	}
+	for i := 1; i < 256; i++ {
+		cnt[i] += cnt[i-1]
+	}
+	for i := n - 1; i >= 0; i-- {
+		cnt[data[i]]--
+		p[cnt[data[i]]] = int32(i)
+	}
+	classes := int32(1)
+	for i := 1; i < n; i++ {
+		if data[p[i]] != data[p[i-1]] {
+			classes++
+		}
+		c[p[i]] = classes - 1
+	}
+
+	for h := 1; h < n && int(classes) < n; h <<= 1 {
+		// 按后半部分排序的结果即前半部分向前移动 h 的位置，只需再按前半部分的排名稳定排序
+		for i := range p {
+			pn[i] = p[i] - int32(h)
+			if pn[i] < 0 {
+				pn[i] += int32(n)
+			}
+		}
+		clear(cnt[:classes])
+		for _, v := range pn {
+			cnt[c[v]]++
+		}
+		for i := int32(1); i < classes; i++ {
+			cnt[i] += cnt[i-1]
+		}
+		for i := n - 1; i >= 0; i-- {
+			cnt[c[pn[i]]]--
+			p[cnt[c[pn[i]]]] = pn[i]
+		}
+
+		cn[p[0]] = 0
+		classes = 1
+		for i := 1; i < n; i++ {
+			cur, prev := int(p[i]), int(p[i-1])
+			if c[cur] != c[prev] || c[(cur+h)%n] != c[(prev+h)%n] {
+				classes++
+			}
+			cn[cur] = classes - 1
+		}
+		c, cn = cn, c
+	}
+
+	return p
+}
+
+// mtfEncode 对块排序的输出进行前移编码，零游程写为 RUNA/RUNB，末尾追加块结束符号
+//
+// 参数:
+//   - last: 块排序的输出
+//   - seq: 字节在使用的字节表中的序号
+//   - nInUse: 使用的字节数
+//
+// 返回值:
+//   - []uint16: 符号序列（0 为 RUNA，1 为 RUNB，非零的前移编码值 v 为 v+1，nInUse+1 为块结束）
+func mtfEncode(last []byte, seq *[256]byte, nInUse int) []uint16 {
+	var order [256]byte
+	for i := 0; i < nInUse; i++ {
+		order[i] = byte(i)
+	}
+
+	syms := make([]uint16, 0, len(last)+1)
+	zeros := 0
+	for _, b := range last {
+		s := seq[b]
+		if order[0] == s {
+			zeros++
+			continue
+		}
+		if zeros > 0 {
+			syms = appendZeroRun(syms, zeros)
+			zeros = 0
+		}
+
+		j := 1
+		for order[j] != s {
+			j++
+		}
+		copy(order[1:j+1], order[:j])
+		order[0] = s
+		syms = append(syms, uint16(j+1))
+	}
+	if zeros > 0 {
+		syms = appendZeroRun(syms, zeros)
+	}
+
+	return append(syms, uint16(nInUse+1))
+}
+
+// appendZeroRun 将长度为 n 的零游程以双射二进制（RUNA=1, RUNB=2，低位在前）写入符号序列
+func appendZeroRun(syms []uint16, n int) []uint16 {
+	n--
+	for {
+		syms = append(syms, uint16(n&1))
+		if n < 2 {
+			return syms
+		}
+		n = (n - 2) / 2
+	}
+}
+
+// writeHuffman 生成 Huffman 编码表和选择器，写入编码表和编码后的符号
+//
+// 与 bzip2 相同：先按符号频率将字母表划分为若干段作为初始编码表，
+// 再反复为每 50 个符号选择代价最小的编码表并按选择结果重新生成编码表。
+//
+// 参数:
+//   - bw: 位写入器
+//   - syms: 符号序列
+//   - alphaSize: 字母表大小（使用的字节数 + 2）
+func writeHuffman(bw *bitWriter, syms []uint16, alphaSize int) {
+	nGroups := 6
+	switch n := len(syms); {
+	case n < 200:
+		nGroups = 2
+	case n < 600:
+		nGroups = 3
+	case n < 1200:
+		nGroups = 4
+	case n < 2400:
+		nGroups = 5
+	}
+
+	freq := make([]int32, alphaSize)
+	for _, s := range syms {
+		freq[s]++
+	}
+
+	// 初始编码表：每个编码表对应字母表中频率之和大致相同的一段
+	var lens [maxGroups][]uint8
+	remaining := int32(len(syms))
+	gs := 0
+	for part := nGroups; part > 0; part-- {
+		target := remaining / int32(part)
+		ge := gs - 1
+		var acc int32
+		for acc < target && ge < alphaSize-1 {
+			ge++
+			acc += freq[ge]
+		}
+		if ge > gs && part != nGroups && part != 1 && (nGroups-part)%2 == 1 {
+			acc -= freq[ge]
+			ge--
+		}
+
+		t := make([]uint8, alphaSize)
+		for v := range t {
+			if v < gs || v > ge {
+				t[v] = 15
+			}
+		}
+		lens[part-1] = t
+		gs = ge + 1
+		remaining -= acc
+	}
+
+	selectors := make([]uint8, (len(syms)+groupSize-1)/groupSize)
+	for iter := 0; iter < iterations; iter++ {
+		var rfreq [maxGroups][]int32
+		for t := 0; t < nGroups; t++ {
+			rfreq[t] = make([]int32, alphaSize)
+		}
+
+		for sel := range selectors {
+			group := syms[sel*groupSize : min((sel+1)*groupSize, len(syms))]
+			best, bestCost := 0, -1
+			for t := 0; t < nGroups; t++ {
+				cost := 0
+				for _, s := range group {
+					cost += int(lens[t][s])
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = t, cost
+				}
+			}
+			selectors[sel] = uint8(best)
+			for _, s := range group {
+				rfreq[best][s]++
+			}
+		}
+
+		for t := 0; t < nGroups; t++ {
+			lens[t] = huffmanLengths(rfreq[t], maxCodeLen)
+		}
+	}
+
+	bw.writeBits(3, uint64(nGroups))
+	bw.writeBits(15, uint64(len(selectors)))
+
+	// 选择器：前移编码后以一元码写入
+	pos := [maxGroups]uint8{0, 1, 2, 3, 4, 5}
+	for _, sel := range selectors {
+		j := 0
+		for pos[j] != sel {
+			j++
+		}
+		copy(pos[1:j+1], pos[:j])
+		pos[0] = sel
+		for ; j > 0; j-- {
+			bw.writeBits(1, 1)
+		}
+		bw.writeBits(1, 0)
+	}
+
+	// 编码长度：以第一个符号的长度为起点，之后每个符号写入与前一个符号的差值
+	var codes [maxGroups][]uint32
+	for t := 0; t < nGroups; t++ {
+		cur := lens[t][0]
+		bw.writeBits(5, uint64(cur))
+		for _, l := range lens[t] {
+			for cur < l {
+				bw.writeBits(2, 2)
+				cur++
+			}
+			for cur > l {
+				bw.writeBits(2, 3)
+				cur--
+			}
+			bw.writeBits(1, 0)
+		}
+		codes[t] = canonicalCodes(lens[t])
+	}
+
+	for i, s := range syms {
+		t := selectors[i/groupSize]
+		bw.writeBits(uint(lens[t][s]), uint64(codes[t][s]))
+	}
+}
+
+// huffmanLengths 根据符号频率生成不超过最大长度的 Huffman 编码长度
+//
+// 频率为 0 的符号按 1 计算（每个符号都需要编码长度）；
+// 最长的编码超过最大长度时将频率减半后重新生成。
+func huffmanLengths(freq []int32, maxLen int) []uint8 {
+	n := len(freq)
+	weights := make([]int64, n)
+	for i, f := range freq {
+		weights[i] = max(int64(f), 1)
+	}
+
+	order := make([]int, n)
+	parent := make([]int, 2*n-1)
+	nodeWeight := make([]int64, 2*n-1)
+	depth := make([]uint8, 2*n-1)
+	for {
+		// 叶子按权重排序后依次编号，内部节点按生成顺序编号（权重单调不减），两个队列合并即可
+		for i := range order {
+			order[i] = i
+		}
+		sortByWeight(order, weights)
+		for i, sym := range order {
+			nodeWeight[i] = weights[sym]
+		}
+
+		leaf, inner, next := 0, n, n
+		pick := func() int {
+			if leaf < n && (inner >= next || nodeWeight[leaf] <= nodeWeight[inner]) {
+				leaf++
+				return leaf - 1
+			}
+			inner++
+			return inner - 1
+		}
+		for next < 2*n-1 {
+			a, b := pick(), pick()
+			nodeWeight[next] = nodeWeight[a] + nodeWeight[b]
+			parent[a], parent[b] = next, next
+			next++
+		}
+
+		depth[2*n-2] = 0
+		longest := 0
+		for i := 2*n - 3; i >= 0; i-- {
+			depth[i] = depth[parent[i]] + 1
+			if i < n {
+				longest = max(longest, int(depth[i]))
+			}
+		}
+
+		if longest <= maxLen {
+			lens := make([]uint8, n)
+			for i, sym := range order {
+				lens[sym] = depth[i]
+			}
+			return lens
+		}
+		for i := range weights {
+			weights[i] = 1 + weights[i]/2
+		}
+	}
+}
+
+// sortByWeight 按权重对符号排序（插入排序，字母表最多 258 个符号）
+func sortByWeight(order []int, weights []int64) {
+	for i := 1; i < len(order); i++ {
+		for j := i; j > 0 && weights[order[j]] < weights[order[j-1]]; j-- {
+			order[j], order[j-1] = order[j-1], order[j]
+		}
+	}
+}
+
+// canonicalCodes 按编码长度生成规范 Huffman 编码（同一长度按符号顺序递增）
+func canonicalCodes(lens []uint8) []uint32 {
+	codes := make([]uint32, len(lens))
+	code := uint32(0)
+	for l := uint8(1); l <= maxCodeLen; l++ {
+		for sym, sl := range lens {
+			if sl == l {
+				codes[sym] = code
+				code++
+			}
+		}
+		code <<= 1
+	}
+	return codes
+}
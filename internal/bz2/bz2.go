@@ -0,0 +1,259 @@
+// Package bz2 实现了 bzip2 格式的压缩（Go 标准库 compress/bzip2 只提供解压）。
+//
+// 压缩流程与 bzip2 1.0 相同，输出可以被标准库和 bzip2 命令行工具解压：
+//   - 游程编码：连续 4 到 255 个相同字节写为 4 个字节加上重复次数
+//   - 块排序（Burrows-Wheeler 变换），每块最多 块大小×100000 字节
+//   - 前移编码（MTF）和零游程编码（RUNA/RUNB）
+//   - 每 50 个符号选择 2 到 6 个 Huffman 编码表之一
+//
+// 每块和整个流都带有 CRC32 校验值，解压时可以检测数据损坏。
+package bz2
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// 压缩等级（块大小，单位为 100000 字节）
+const (
+	BestSpeed          = 1 // 最小的块，速度最快、内存占用最少
+	BestCompression    = 9 // 最大的块，压缩率最高
+	DefaultCompression = 9 // 默认等级（与 bzip2 命令行工具相同）
+)
+
+const (
+	blockMagic  = 0x314159265359 // 块头标记（π）
+	streamMagic = 0x177245385090 // 流结束标记（√π）
+	maxRun      = 255            // 游程编码的最大游程长度
+)
+
+// Writer bzip2 压缩写入器
+//
+// 写入的数据按块缓存，块写满或 Close 时压缩后写入底层 Writer。
+type Writer struct {
+	bw        *bitWriter // 底层位写入器
+	level     int        // 块大小（单位为 100000 字节）
+	maxBlock  int        // 每块游程编码后的最大字节数
+	block     []byte     // 当前块（游程编码后的数据）
+	blockCRC  uint32     // 当前块原始数据的 CRC
+	streamCRC uint32     // 已写入的所有块的组合 CRC
+	runByte   byte       // 当前游程的字节
+	runLen    int        // 当前游程的长度
+	header    bool       // 是否已写入流头
+	closed    bool       // 是否已关闭
+	err       error      // 写入底层 Writer 时发生的错误
+}
+
+// NewWriter 创建使用默认压缩等级的 bzip2 压缩写入器
+//
+// 参数:
+//   - w: 写入目标（关闭压缩写入器时不会被关闭）
+//
+// 返回值:
+//   - *Writer: 压缩写入器
+func NewWriter(w io.Writer) *Writer {
+	zw, _ := NewWriterLevel(w, DefaultCompression)
+	return zw
+}
+
+// NewWriterLevel 创建指定压缩等级的 bzip2 压缩写入器
+//
+// 参数:
+//   - w: 写入目标（关闭压缩写入器时不会被关闭）
+//   - level: 压缩等级（1-9，即块大小为 level×100000 字节）
+//
+// 返回值:
+//   - *Writer: 压缩写入器
+//   - error: 压缩等级无效时返回错误信息
+func NewWriterLevel(w io.Writer, level int) (*Writer, error) {
+	if level < BestSpeed || level > BestCompression {
+		return nil, fmt.Errorf("bzip2 压缩等级无效: %d", level)
+	}
+
+	// 与 bzip2 相同，为块末尾的游程留出余量
+	maxBlock := level*100000 - 19
+	return &Writer{
+		bw:       newBitWriter(w),
+		level:    level,
+		maxBlock: maxBlock,
+		block:    make([]byte, 0, maxBlock),
+		blockCRC: crcInit,
+	}, nil
+}
+
+// Write 压缩并写入数据
+//
+// 参数:
+//   - p: 待写入的数据
+//
+// 返回值:
+//   - int: 写入的字节数
+//   - error: 写入器已关闭或写入底层 Writer 失败时返回错误信息
+func (z *Writer) Write(p []byte) (int, error) {
+	if z.closed {
+		return 0, errors.New("bzip2: 写入已关闭的压缩写入器")
+	}
+	if z.err != nil {
+		return 0, z.err
+	}
+
+	for _, b := range p {
+		if z.runLen > 0 && b == z.runByte && z.runLen < maxRun {
+			z.runLen++
+			continue
+		}
+		z.flushRun()
+		z.runByte, z.runLen = b, 1
+	}
+
+	return len(p), z.err
+}
+
+// Close 压缩剩余的数据并写入流结束标记
+//
+// 不会关闭底层 Writer。
+//
+// 返回值:
+//   - error: 写入底层 Writer 失败时返回错误信息
+func (z *Writer) Close() error {
+	if z.closed {
+		return z.err
+	}
+	z.closed = true
+	if z.err != nil {
+		return z.err
+	}
+
+	z.flushRun()
+	if len(z.block) > 0 {
+		z.writeBlock()
+	}
+	z.writeHeader()
+	z.bw.writeBits(48, streamMagic)
+	z.bw.writeBits(32, uint64(z.streamCRC))
+	z.bw.flush()
+
+	z.err = z.bw.err
+	return z.err
+}
+
+// flushRun 将当前游程按游程编码写入当前块，块已满时先压缩当前块
+func (z *Writer) flushRun() {
+	if z.runLen == 0 {
+		return
+	}
+	if len(z.block)+5 > z.maxBlock {
+		z.writeBlock()
+	}
+
+	for i := 0; i < z.runLen; i++ {
+		z.blockCRC = crcUpdate(z.blockCRC, z.runByte)
+	}
+	for i := 0; i < z.runLen && i < 4; i++ {
+		z.block = append(z.block, z.runByte)
+	}
+	if z.runLen >= 4 {
+		z.block = append(z.block, byte(z.runLen-4))
+	}
+	z.runLen = 0
+}
+
+// writeHeader 写入流头（只写一次）
+func (z *Writer) writeHeader() {
+	if z.header {
+		return
+	}
+	z.header = true
+	z.bw.writeBits(8, 'B')
+	z.bw.writeBits(8, 'Z')
+	z.bw.writeBits(8, 'h')
+	z.bw.writeBits(8, uint64('0'+z.level))
+}
+
+// writeBlock 压缩并写入当前块
+func (z *Writer) writeBlock() {
+	z.writeHeader()
+
+	crc := ^z.blockCRC
+	z.streamCRC = (z.streamCRC<<1 | z.streamCRC>>31) ^ crc
+
+	z.bw.writeBits(48, blockMagic)
+	z.bw.writeBits(32, uint64(crc))
+	z.bw.writeBits(1, 0) // 不使用随机化
+	encodeBlock(z.bw, z.block)
+
+	z.block = z.block[:0]
+	z.blockCRC = crcInit
+	if z.bw.err != nil {
+		z.err = z.bw.err
+	}
+}
+
+// bitWriter 按最高位优先的顺序写入位
+type bitWriter struct {
+	w     io.Writer // 写入目标
+	buf   []byte    // 已拼接完成的字节
+	acc   uint64    // 尚未拼接成字节的位
+	nbits uint      // acc 中的位数
+	err   error     // 写入错误
+}
+
+// newBitWriter 创建位写入器
+func newBitWriter(w io.Writer) *bitWriter {
+	return &bitWriter{w: w, buf: make([]byte, 0, 4096)}
+}
+
+// writeBits 写入 v 的低 n 位（n 不超过 48）
+func (b *bitWriter) writeBits(n uint, v uint64) {
+	b.acc = b.acc<<n | v&(1<<n-1)
+	b.nbits += n
+	for b.nbits >= 8 {
+		b.nbits -= 8
+		b.buf = append(b.buf, byte(b.acc>>b.nbits))
+	}
+	if len(b.buf) >= 4096 {
+		b.flushBuf()
+	}
+}
+
+// flush 写入剩余的位（不足一个字节时以 0 补齐）
+func (b *bitWriter) flush() {
+	if b.nbits > 0 {
+		b.writeBits(8-b.nbits, 0)
+	}
+	b.flushBuf()
+}
+
+// flushBuf 将已拼接完成的字节写入目标
+func (b *bitWriter) flushBuf() {
+	if b.err == nil && len(b.buf) > 0 {
+		_, b.err = b.w.Write(b.buf)
+	}
+	b.buf = b.buf[:0]
+}
+
+// crcInit bzip2 CRC 的初始值
+const crcInit = 0xffffffff
+
+// crcTable bzip2 使用的 CRC32 表（多项式 0x04c11db7，最高位优先）
+var crcTable = func() [256]uint32 {
+	var t [256]uint32
+	for i := range t {
+		c := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if c&0x80000000 != 0 {
+				c = c<<1 ^ 0x04c11db7
+			} else {
+				c <<= 1
+			}
+		}
+		t[i] = c
+	}
+	return t
+}()
+
+// crcUpdate 将一个字节计入 CRC
+func crcUpdate(crc uint32, b byte) uint32 {
+	return crc<<8 ^ crcTable[byte(crc>>24)^b]
+}
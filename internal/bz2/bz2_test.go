@@ -0,0 +1,97 @@
+package bz2
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	random := make([]byte, 300000)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	tests := []struct {
+		name  string
+		data  []byte
+		level int
+	}{
+		{"空数据", nil, DefaultCompression},
+		{"单个字节", []byte{'x'}, DefaultCompression},
+		{"恰好一个游程", []byte("aaaa"), DefaultCompression},
+		{"超过最大游程", bytes.Repeat([]byte{0}, 1000), DefaultCompression},
+		{"周期性数据", bytes.Repeat([]byte("ab"), 100000), DefaultCompression},
+		{"文本", bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog\n"), 5000), DefaultCompression},
+		{"随机数据跨越多个块", random, BestSpeed},
+		{"所有字节值", func() []byte {
+			b := make([]byte, 256*10)
+			for i := range b {
+				b[i] = byte(i)
+			}
+			return b
+		}(), BestSpeed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriterLevel(&buf, tt.level)
+			if err != nil {
+				t.Fatalf("NewWriterLevel() error = %v", err)
+			}
+			// 分多次写入，游程跨越两次写入
+			half := len(tt.data) / 2
+			if _, err := w.Write(tt.data[:half]); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if _, err := w.Write(tt.data[half:]); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			got, err := io.ReadAll(bzip2.NewReader(&buf))
+			if err != nil {
+				t.Fatalf("解压失败: %v", err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Fatalf("解压结果不一致: 长度 %d, 期望 %d", len(got), len(tt.data))
+			}
+		})
+	}
+}
+
+func TestWriterCompresses(t *testing.T) {
+	data := bytes.Repeat([]byte("bakctl backup "), 10000)
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if buf.Len() > len(data)/100 {
+		t.Errorf("压缩后 %d 字节, 重复数据应压缩到原大小的 1%% 以内", buf.Len())
+	}
+}
+
+func TestWriterAfterClose(t *testing.T) {
+	w := NewWriter(io.Discard)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Error("关闭后写入应返回错误")
+	}
+}
+
+func TestNewWriterLevel(t *testing.T) {
+	for _, level := range []int{-1, 0, 10} {
+		if _, err := NewWriterLevel(io.Discard, level); err == nil {
+			t.Errorf("NewWriterLevel(%d) 应返回错误", level)
+		}
+	}
+}
@@ -12,6 +12,9 @@
 //   - retainDays: 保留最近N天内的备份文件
 //   - 同时设置时：先按天数过滤，然后每天只保留最新的N个备份文件
 //   - 当两个策略都为0时，不执行任何清理操作
+//   - 被保留的备份所依赖的备份文件（如增量备份链中的上游版本）不会被删除，
+//     增量备份链在其最新的版本也超出保留策略后整条删除
+//   - 分卷备份的所有分卷（name_YYYYMMDD_HHMMSS.zip.001 等）视为一个备份，一起保留或删除
 package cleanup
 
 import (
@@ -26,10 +29,23 @@ import (
 
 // BackupFileInfo 备份文件信息
 type BackupFileInfo struct {
-	FilePath    string    // 文件完整路径
+	FilePath    string    // 文件完整路径（分卷备份为不带分卷序号的备份文件路径）
 	CreatedTime time.Time // 创建时间
+	Volumes     []string  // 分卷文件的完整路径（未分卷的备份为空）
 }
 
+// Files 返回备份实际占用的所有文件
+func (b BackupFileInfo) Files() []string {
+	if len(b.Volumes) > 0 {
+		return b.Volumes
+	}
+	return []string{b.FilePath}
+}
+
+// DependencyResolver 备份文件依赖解析函数
+// 返回指定备份文件恢复时所依赖的其他备份文件的完整路径（如增量备份链中的所有上游版本）
+type DependencyResolver func(filePath string) []string
+
 // CleanupResult 清理结果
 type CleanupResult struct {
 	TotalFiles   int      // 总文件数
@@ -44,12 +60,13 @@ type CleanupResult struct {
 //   - taskName: 任务名称
 //   - retainCount: 保留备份数量 (0表示不限制数量)
 //   - retainDays: 保留天数 (0表示不限制天数)
-//   - backupFileExt: 备份文件扩展名 (如 ".zip")
+//   - backupFileExts: 备份文件扩展名列表 (如 ".zip", ".tar.gz")
+//   - resolver: 备份文件依赖解析函数 (nil表示备份文件之间没有依赖)
 //
 // 返回值:
 //   - CleanupResult: 清理结果统计
 //   - error: 清理过程中的错误
-func CleanupBackupFiles(storageDir, taskName string, retainCount, retainDays int, backupFileExt string) (CleanupResult, error) {
+func CleanupBackupFiles(storageDir, taskName string, retainCount, retainDays int, backupFileExts []string, resolver DependencyResolver) (CleanupResult, error) {
 	result := CleanupResult{
 		ErrorFiles: make([]string, 0),
 	}
@@ -60,7 +77,7 @@ func CleanupBackupFiles(storageDir, taskName string, retainCount, retainDays int
 	}
 
 	// 1. 收集备份文件信息
-	backupFiles, err := collectBackupFiles(storageDir, taskName, backupFileExt)
+	backupFiles, err := collectBackupFiles(storageDir, taskName, backupFileExts)
 	if err != nil {
 		return result, fmt.Errorf("收集备份文件失败: %w", err)
 	}
@@ -80,12 +97,19 @@ func CleanupBackupFiles(storageDir, taskName string, retainCount, retainDays int
 	// 3. 确定需要删除的文件
 	filesToDelete := determineFilesToDelete(backupFiles, retainCount, retainDays)
 
-	// 4. 执行删除操作
+	// 保留被其他备份依赖的文件
+	filesToDelete = applyDependencyCheck(filesToDelete, backupFiles, resolver)
+
+	// 4. 执行删除操作（分卷备份删除所有分卷）
 	for _, fileInfo := range filesToDelete {
-		// 删除文件
-		if err := os.Remove(fileInfo.FilePath); err != nil {
-			result.ErrorFiles = append(result.ErrorFiles, fileInfo.FilePath)
-		} else { // 删除成功
+		failed := false
+		for _, path := range fileInfo.Files() {
+			if err := os.Remove(path); err != nil {
+				result.ErrorFiles = append(result.ErrorFiles, path)
+				failed = true
+			}
+		}
+		if !failed { // 删除成功
 			result.DeletedFiles++
 		}
 	}
@@ -98,12 +122,12 @@ func CleanupBackupFiles(storageDir, taskName string, retainCount, retainDays int
 // 参数:
 //   - storageDir: 备份存储目录
 //   - taskName: 任务名称
-//   - backupFileExt: 备份文件扩展名
+//   - backupFileExts: 备份文件扩展名列表（修改过归档格式的任务存在多种扩展名的备份文件）
 //
 // 返回值:
 //   - []BackupFileInfo: 备份文件信息列表
 //   - error: 收集过程中的错误
-func collectBackupFiles(storageDir, taskName, backupFileExt string) ([]BackupFileInfo, error) {
+func collectBackupFiles(storageDir, taskName string, backupFileExts []string) ([]BackupFileInfo, error) {
 	var backupFiles []BackupFileInfo
 
 	// 检查目录是否存在
@@ -112,8 +136,12 @@ func collectBackupFiles(storageDir, taskName, backupFileExt string) ([]BackupFil
 	}
 
 	// 构建文件名匹配模式: {taskName}_{YYYYMMDD_HHMMSS}.zip
-	// 使用正则表达式匹配: taskName_时间字符串.zip
-	pattern := fmt.Sprintf(`^%s_(\d{8}_\d{6})%s$`, regexp.QuoteMeta(taskName), regexp.QuoteMeta(backupFileExt))
+	// 使用正则表达式匹配: taskName_时间字符串(.zip|.tar|.tar.gz|.tar.bz2)，分卷文件追加分卷序号(.001|.002...)
+	quotedExts := make([]string, 0, len(backupFileExts))
+	for _, ext := range backupFileExts {
+		quotedExts = append(quotedExts, regexp.QuoteMeta(ext))
+	}
+	pattern := fmt.Sprintf(`^(%s_(\d{8}_\d{6})(?:%s))(\.\d{3,})?$`, regexp.QuoteMeta(taskName), strings.Join(quotedExts, "|"))
 	regex, err := regexp.Compile(pattern)
 	if err != nil {
 		return nil, fmt.Errorf("编译正则表达式失败: %w", err)
@@ -130,7 +158,8 @@ func collectBackupFiles(storageDir, taskName, backupFileExt string) ([]BackupFil
 		return backupFiles, nil
 	}
 
-	// 遍历目录中的文件
+	// 遍历目录中的文件（同一个备份的所有分卷合并为一个备份）
+	volumeSets := make(map[string]int) // 备份文件路径 -> 在 backupFiles 中的下标
 	for _, entry := range entries {
 		// 跳过目录
 		if entry.IsDir() {
@@ -142,19 +171,35 @@ func collectBackupFiles(storageDir, taskName, backupFileExt string) ([]BackupFil
 
 		// 检查文件名是否匹配备份文件格式
 		matches := regex.FindStringSubmatch(fileName)
-		if len(matches) != 2 {
+		if len(matches) != 4 {
 			continue // 不匹配，跳过
 		}
 
 		// 解析时间字符串 (格式: YYYYMMDD_HHMMSS)
-		timeStr := matches[1]
+		timeStr := matches[2]
 		createdTime, err := time.Parse("20060102_150405", timeStr)
 		if err != nil {
 			continue // 时间字符串解析失败，跳过
 		}
 
 		// 获取文件完整路径
-		filePath := filepath.Join(storageDir, fileName)
+		filePath := filepath.Join(storageDir, matches[1])
+
+		// 分卷文件加入所属的备份
+		if matches[3] != "" {
+			volumePath := filepath.Join(storageDir, fileName)
+			if i, ok := volumeSets[filePath]; ok {
+				backupFiles[i].Volumes = append(backupFiles[i].Volumes, volumePath)
+				continue
+			}
+			volumeSets[filePath] = len(backupFiles)
+			backupFiles = append(backupFiles, BackupFileInfo{
+				FilePath:    filePath,             // 备份文件路径
+				CreatedTime: createdTime,          // 创建时间
+				Volumes:     []string{volumePath}, // 分卷文件路径
+			})
+			continue
+		}
 
 		// 创建备份文件信息
 		backupFileInfo := BackupFileInfo{
@@ -252,6 +297,48 @@ func applySafetyCheck(filesToDelete, allFiles []BackupFileInfo) []BackupFileInfo
 	return filesToDelete
 }
 
+// applyDependencyCheck 从删除列表中移除被保留的备份所依赖的文件
+//
+// 参数:
+//   - filesToDelete: 计划删除的文件列表
+//   - allFiles: 所有备份文件列表
+//   - resolver: 备份文件依赖解析函数
+//
+// 返回值:
+//   - []BackupFileInfo: 经过依赖检查后的删除文件列表
+func applyDependencyCheck(filesToDelete, allFiles []BackupFileInfo, resolver DependencyResolver) []BackupFileInfo {
+	if resolver == nil || len(filesToDelete) == 0 {
+		return filesToDelete
+	}
+
+	// 记录计划删除的文件
+	deleting := make(map[string]bool, len(filesToDelete))
+	for _, fileInfo := range filesToDelete {
+		deleting[fileInfo.FilePath] = true
+	}
+
+	// 收集所有被保留文件的依赖
+	protected := make(map[string]bool)
+	for _, fileInfo := range allFiles {
+		if deleting[fileInfo.FilePath] {
+			continue
+		}
+		for _, dep := range resolver(fileInfo.FilePath) {
+			protected[dep] = true
+		}
+	}
+
+	// 移除受保护的文件
+	var result []BackupFileInfo
+	for _, fileInfo := range filesToDelete {
+		if !protected[fileInfo.FilePath] {
+			result = append(result, fileInfo)
+		}
+	}
+
+	return result
+}
+
 // determineFilesToDeleteWithBothPolicies 处理同时设置保留数量和保留天数的情况
 //
 // 逻辑：先按天数过滤，然后每天只保留最新的N个备份文件
@@ -50,12 +50,13 @@ type BackupTask interface {
 //
 // 参数:
 //   - task: 备份任务对象
-//   - backupFileExt: 备份文件扩展名
+//   - backupFileExts: 备份文件扩展名列表
+//   - resolver: 备份文件依赖解析函数 (nil表示备份文件之间没有依赖)
 //   - cl: 颜色库对象
 //
 // 返回值:
 //   - error: 清理过程中的错误
-func CleanupBackupFilesWithLogging(task BackupTask, backupFileExt string, cl *colorlib.ColorLib) error {
+func CleanupBackupFilesWithLogging(task BackupTask, backupFileExts []string, resolver DependencyResolver, cl *colorlib.ColorLib) error {
 	// 验证参数
 	if err := ValidateCleanupParams(task.GetStorageDir(), task.GetName(), task.GetRetainCount(), task.GetRetainDays()); err != nil {
 		return fmt.Errorf("清理参数验证失败: %w", err)
@@ -72,7 +73,8 @@ func CleanupBackupFilesWithLogging(task BackupTask, backupFileExt string, cl *co
 		task.GetName(),
 		task.GetRetainCount(),
 		task.GetRetainDays(),
-		backupFileExt,
+		backupFileExts,
+		resolver,
 	)
 
 	if err != nil {
@@ -0,0 +1,422 @@
+// Package crypt 实现了 bakctl 备份文件的加密和解密。
+//
+// 备份文件在打包的同时以流的方式加密（AES-256-GCM），明文不会落盘：
+//   - 密钥由密码（PBKDF2-SHA256）或密钥文件（HKDF-SHA256）派生，每个备份文件使用随机的盐
+//   - 也可以加密给一个或多个 X25519 公钥（接收者）：每个备份文件使用随机的文件密钥，
+//     文件密钥分别为每个接收者加密后写入文件头，只有持有对应身份私钥的人才能解密
+//   - 数据按固定大小分块加密，每块独立认证，最后一块带有结束标记，可以检测截断
+//   - 文件头包含密钥校验值，密码或密钥文件错误时在解密前给出明确的错误
+//
+// 加密文件格式：
+//
+//	文件头: magic(8) | kdf(1) | 参数(4) | salt(16) | nonce前缀(7) | 块大小(4) | 接收者条目(80×N) | 密钥校验值(16)
+//	参数: 密码加密时为 PBKDF2 迭代次数，公钥加密时为接收者数量 N（其他方式没有接收者条目）
+//	接收者条目: 临时公钥(32) | AES-256-GCM(文件密钥)(48)
+//	数据块: AES-256-GCM(明文块), nonce = nonce前缀 | 块序号(4) | 结束标记(1)，附加数据为文件头
+package crypt
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// 加密方案
+const (
+	SchemePassphrase = "aes-256-gcm+pbkdf2"  // 密码派生密钥
+	SchemeKeyFile    = "aes-256-gcm+keyfile" // 密钥文件派生密钥
+	SchemeRecipients = "aes-256-gcm+x25519"  // 加密给 X25519 公钥
+)
+
+// 密钥派生方式
+const (
+	kdfPassphrase byte = 1 // PBKDF2-SHA256
+	kdfKeyFile    byte = 2 // HKDF-SHA256
+	kdfRecipients byte = 3 // X25519 接收者
+)
+
+const (
+	magic          = "BAKCTLE1"             // 文件头魔数（含格式版本）
+	fixedSize      = 8 + 1 + 4 + 16 + 7 + 4 // 文件头中固定长度的部分
+	checkSize      = 16                     // 密钥校验值长度
+	keySize        = 32                     // AES-256
+	saltSize       = 16                     // 盐的长度
+	prefixSize     = 7                      // nonce 前缀长度
+	chunkSize      = 64 * 1024              // 明文块大小
+	pbkdf2Iter     = 600000                 // PBKDF2 迭代次数
+	minKeyFileSize = 32                     // 密钥文件的最小长度
+	hkdfInfo       = "bakctl backup encryption"
+)
+
+// ErrWrongKey 密码、密钥文件或身份文件错误
+var ErrWrongKey = errors.New("密码、密钥文件或身份文件错误")
+
+// ErrTooLarge 数据块数超过块序号的范围，继续加密会重复使用 nonce
+var ErrTooLarge = errors.New("数据过大, 超过单个加密文件支持的最大块数")
+
+// ErrCorrupted 加密数据被篡改、损坏或不完整
+var ErrCorrupted = errors.New("加密数据认证失败, 备份文件已损坏、被篡改或不完整")
+
+// Secret 加密或解密时使用的密码、密钥文件或接收者
+type Secret struct {
+	Passphrase string   // 密码
+	KeyFile    string   // 密钥文件路径
+	Recipients []string // 接收者公钥（仅用于加密）
+	Identity   string   // 身份文件路径（仅用于解密公钥加密的备份）
+}
+
+// Key 加密密钥及其派生参数
+type Key struct {
+	kdf     byte   // 密钥派生方式
+	param   uint32 // PBKDF2 迭代次数或接收者数量
+	salt    []byte // 盐
+	stanzas []byte // 接收者条目（仅公钥加密）
+	key     []byte // 派生的密钥
+}
+
+// NewKey 为一个新的备份文件派生加密密钥（使用随机的盐）
+//
+// 参数:
+//   - secret: 接收者、密钥文件或密码（按此优先级使用）
+//
+// 返回值:
+//   - *Key: 加密密钥
+//   - error: 解析接收者、读取密钥文件或派生密钥失败时返回错误信息
+func NewKey(secret Secret) (*Key, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("生成随机盐失败: %w", err)
+	}
+
+	if len(secret.Recipients) > 0 {
+		return newRecipientsKey(secret.Recipients, salt)
+	}
+
+	k := &Key{kdf: kdfPassphrase, param: pbkdf2Iter, salt: salt}
+	if secret.KeyFile != "" {
+		k.kdf, k.param = kdfKeyFile, 0
+	}
+	if err := k.derive(secret); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Scheme 返回密钥对应的加密方案，记录到备份记录中
+func (k *Key) Scheme() string {
+	switch k.kdf {
+	case kdfKeyFile:
+		return SchemeKeyFile
+	case kdfRecipients:
+		return SchemeRecipients
+	default:
+		return SchemePassphrase
+	}
+}
+
+// derive 根据密钥派生方式从密码或密钥文件派生密钥
+func (k *Key) derive(secret Secret) error {
+	var err error
+	switch k.kdf {
+	case kdfPassphrase:
+		if secret.Passphrase == "" {
+			return errors.New("未提供密码")
+		}
+		k.key, err = pbkdf2.Key(sha256.New, secret.Passphrase, k.salt, int(k.param), keySize)
+	case kdfKeyFile:
+		if secret.KeyFile == "" {
+			return errors.New("备份文件使用密钥文件加密, 未提供密钥文件")
+		}
+		var data []byte
+		if data, err = os.ReadFile(secret.KeyFile); err != nil {
+			return fmt.Errorf("读取密钥文件失败: %w", err)
+		}
+		if len(data) < minKeyFileSize {
+			return fmt.Errorf("密钥文件 %s 太短, 至少需要 %d 字节", secret.KeyFile, minKeyFileSize)
+		}
+		k.key, err = hkdf.Key(sha256.New, data, k.salt, hkdfInfo, keySize)
+	case kdfRecipients:
+		if secret.Identity == "" {
+			return errors.New("备份文件使用公钥加密, 未提供身份文件")
+		}
+		var fileKey []byte
+		if fileKey, err = unwrapFileKey(k.stanzas, secret.Identity); err != nil {
+			return err
+		}
+		k.key, err = hkdf.Key(sha256.New, fileKey, k.salt, hkdfInfo, keySize)
+	default:
+		return fmt.Errorf("不支持的密钥派生方式: %d", k.kdf)
+	}
+	if err != nil {
+		return fmt.Errorf("派生密钥失败: %w", err)
+	}
+	return nil
+}
+
+// writer 加密写入器
+type writer struct {
+	w       io.Writer   // 底层写入器
+	aead    cipher.AEAD // AES-GCM
+	header  []byte      // 文件头（作为附加数据）
+	prefix  []byte      // nonce 前缀
+	buf     []byte      // 未加密的明文
+	counter uint32      // 块序号
+	closed  bool        // 是否已关闭
+}
+
+// NewWriter 创建加密写入器，立即写入文件头
+//
+// 写入完成后必须调用 Close 写入最后一个数据块（不会关闭底层写入器）。
+//
+// 参数:
+//   - w: 底层写入器
+//   - key: 加密密钥
+//
+// 返回值:
+//   - io.WriteCloser: 加密写入器
+//   - error: 写入文件头失败时返回错误信息
+func NewWriter(w io.Writer, key *Key) (io.WriteCloser, error) {
+	aead, err := newAEAD(key.key)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := make([]byte, prefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, fmt.Errorf("生成随机 nonce 失败: %w", err)
+	}
+
+	header := make([]byte, 0, fixedSize+len(key.stanzas)+checkSize)
+	header = append(header, magic...)
+	header = append(header, key.kdf)
+	header = binary.BigEndian.AppendUint32(header, key.param)
+	header = append(header, key.salt...)
+	header = append(header, prefix...)
+	header = binary.BigEndian.AppendUint32(header, chunkSize)
+	header = append(header, key.stanzas...)
+	header = append(header, keyCheck(aead, header)...)
+
+	if _, err := w.Write(header); err != nil {
+		return nil, fmt.Errorf("写入加密文件头失败: %w", err)
+	}
+
+	return &writer{w: w, aead: aead, header: header, prefix: prefix, buf: make([]byte, 0, chunkSize)}, nil
+}
+
+// Write 实现 io.Writer 接口
+func (e *writer) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("加密写入器已关闭")
+	}
+
+	n := 0
+	for len(p) > 0 {
+		// 缓冲区已满且还有数据时，缓冲区中的块不是最后一块
+		if len(e.buf) == chunkSize {
+			if err := e.seal(false); err != nil {
+				return n, err
+			}
+		}
+		c := copy(e.buf[len(e.buf):chunkSize], p)
+		e.buf = e.buf[:len(e.buf)+c]
+		p = p[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// Close 加密并写入最后一个数据块
+func (e *writer) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.seal(true)
+}
+
+// seal 加密并写入缓冲区中的数据块
+//
+// 块序号达到上限时只允许写入最后一块，否则块序号回绕后会在同一密钥下重复使用 nonce。
+func (e *writer) seal(last bool) error {
+	if e.counter == math.MaxUint32 && !last {
+		return ErrTooLarge
+	}
+	out := e.aead.Seal(nil, e.nonce(last), e.buf, e.header)
+	if _, err := e.w.Write(out); err != nil {
+		return fmt.Errorf("写入加密数据失败: %w", err)
+	}
+	e.buf = e.buf[:0]
+	e.counter++
+	return nil
+}
+
+// nonce 返回当前数据块的 nonce
+func (e *writer) nonce(last bool) []byte {
+	return chunkNonce(e.prefix, e.counter, last)
+}
+
+// reader 解密读取器
+type reader struct {
+	r       *bufio.Reader // 底层读取器
+	aead    cipher.AEAD   // AES-GCM
+	header  []byte        // 文件头（作为附加数据）
+	prefix  []byte        // nonce 前缀
+	chunk   []byte        // 读取密文块的缓冲区
+	plain   []byte        // 未读取的明文
+	counter uint32        // 块序号
+	done    bool          // 是否已读取最后一块
+}
+
+// NewReader 创建解密读取器，读取文件头并验证密钥
+//
+// 参数:
+//   - r: 底层读取器
+//   - secret: 密码或密钥文件（根据文件头中的密钥派生方式使用）
+//
+// 返回值:
+//   - io.Reader: 解密读取器，读取到被篡改或不完整的数据时返回 ErrCorrupted
+//   - error: 不是加密文件时返回错误信息，密码或密钥文件错误时返回 ErrWrongKey
+func NewReader(r io.Reader, secret Secret) (io.Reader, error) {
+	header := make([]byte, fixedSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("读取加密文件头失败: %w", err)
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, errors.New("不是 bakctl 加密的备份文件")
+	}
+
+	p := header[len(magic):]
+	k := &Key{kdf: p[0], param: binary.BigEndian.Uint32(p[1:5]), salt: p[5:21]}
+	prefix := p[21:28]
+	size := binary.BigEndian.Uint32(p[28:32])
+	if size == 0 || size > 16*1024*1024 {
+		return nil, fmt.Errorf("无效的加密块大小: %d", size)
+	}
+	if k.kdf == kdfPassphrase && (k.param == 0 || k.param > 100*pbkdf2Iter) {
+		return nil, fmt.Errorf("无效的密钥派生迭代次数: %d", k.param)
+	}
+	if k.kdf == kdfRecipients && (k.param == 0 || k.param > maxRecipients) {
+		return nil, fmt.Errorf("无效的接收者数量: %d", k.param)
+	}
+
+	// 接收者条目和密钥校验值
+	rest := checkSize
+	if k.kdf == kdfRecipients {
+		rest += int(k.param) * stanzaSize
+	}
+	header = append(header, make([]byte, rest)...)
+	if _, err := io.ReadFull(r, header[fixedSize:]); err != nil {
+		return nil, fmt.Errorf("读取加密文件头失败: %w", err)
+	}
+	k.stanzas = header[fixedSize : len(header)-checkSize]
+	check := header[len(header)-checkSize:]
+
+	if err := k.derive(secret); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(k.key)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(keyCheck(aead, header[:len(header)-checkSize]), check) {
+		return nil, ErrWrongKey
+	}
+
+	return &reader{
+		r:      bufio.NewReaderSize(r, int(size)+aead.Overhead()),
+		aead:   aead,
+		header: header,
+		prefix: prefix,
+		chunk:  make([]byte, int(size)+aead.Overhead()),
+	}, nil
+}
+
+// Read 实现 io.Reader 接口
+func (d *reader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// open 读取并解密下一个数据块
+func (d *reader) open() error {
+	n, err := io.ReadFull(d.r, d.chunk)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		if err == io.EOF {
+			return ErrCorrupted // 缺少最后一块
+		}
+		return fmt.Errorf("读取加密数据失败: %w", err)
+	}
+
+	// 之后没有数据时当前块应为最后一块
+	last := err == io.ErrUnexpectedEOF
+	if !last {
+		if _, peekErr := d.r.Peek(1); peekErr == io.EOF {
+			last = true
+		}
+	}
+
+	// 加密时块序号不会回绕，超出范围的数据不可能是合法的
+	if d.counter == math.MaxUint32 && !last {
+		return ErrCorrupted
+	}
+
+	plain, openErr := d.aead.Open(d.chunk[:0], chunkNonce(d.prefix, d.counter, last), d.chunk[:n], d.header)
+	if openErr != nil {
+		return ErrCorrupted
+	}
+	d.plain = plain
+	d.counter++
+	d.done = last
+	return nil
+}
+
+// newAEAD 创建 AES-256-GCM
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建 AES 加密器失败: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("创建 GCM 加密器失败: %w", err)
+	}
+	return aead, nil
+}
+
+// keyCheck 计算密钥校验值（使用保留的 nonce 对空明文加密得到的认证标签）
+func keyCheck(aead cipher.AEAD, header []byte) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	nonce[len(nonce)-1] = 0xff // 数据块的结束标记只会是 0 或 1
+	return aead.Seal(nil, nonce, nil, header)
+}
+
+// chunkNonce 返回数据块的 nonce
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 0, prefixSize+5)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
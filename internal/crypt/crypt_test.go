@@ -0,0 +1,253 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeKeyFile 在临时目录中写入密钥文件并返回其路径
+func writeKeyFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "backup.key")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("写入密钥文件失败: %v", err)
+	}
+	return path
+}
+
+// encrypt 使用 secret 加密 data 并返回密文
+func encrypt(t *testing.T, secret Secret, data []byte) []byte {
+	t.Helper()
+	key, err := NewKey(secret)
+	if err != nil {
+		t.Fatalf("NewKey() error = %v", err)
+	}
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, key)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// decrypt 使用 secret 解密密文，返回明文和错误
+func decrypt(ciphertext []byte, secret Secret) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(ciphertext), secret)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// randomBytes 返回固定种子生成的随机数据
+func randomBytes(n int) []byte {
+	data := make([]byte, n)
+	rand.New(rand.NewSource(int64(n))).Read(data)
+	return data
+}
+
+func TestRoundTrip(t *testing.T) {
+	keyFile := writeKeyFile(t, "0123456789abcdef0123456789abcdef")
+
+	tests := []struct {
+		name   string
+		secret Secret
+		size   int
+		scheme string
+	}{
+		{"密钥文件-空数据", Secret{KeyFile: keyFile}, 0, SchemeKeyFile},
+		{"密钥文件-单个字节", Secret{KeyFile: keyFile}, 1, SchemeKeyFile},
+		{"密钥文件-不足一块", Secret{KeyFile: keyFile}, chunkSize - 1, SchemeKeyFile},
+		{"密钥文件-恰好一块", Secret{KeyFile: keyFile}, chunkSize, SchemeKeyFile},
+		{"密钥文件-恰好两块", Secret{KeyFile: keyFile}, 2 * chunkSize, SchemeKeyFile},
+		{"密钥文件-多块", Secret{KeyFile: keyFile}, 3*chunkSize + 17, SchemeKeyFile},
+		{"密码", Secret{Passphrase: "correct horse battery staple"}, chunkSize + 1, SchemePassphrase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := randomBytes(tt.size)
+			key, err := NewKey(tt.secret)
+			if err != nil {
+				t.Fatalf("NewKey() error = %v", err)
+			}
+			if got := key.Scheme(); got != tt.scheme {
+				t.Errorf("Scheme() = %q, want %q", got, tt.scheme)
+			}
+
+			ciphertext := encrypt(t, tt.secret, data)
+			if len(data) >= 16 && bytes.Contains(ciphertext, data[:16]) {
+				t.Fatal("密文中包含明文")
+			}
+			got, err := decrypt(ciphertext, tt.secret)
+			if err != nil {
+				t.Fatalf("解密失败: %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("解密结果不一致: 长度 %d, 期望 %d", len(got), len(data))
+			}
+		})
+	}
+}
+
+func TestTamperedCiphertext(t *testing.T) {
+	secret := Secret{KeyFile: writeKeyFile(t, "0123456789abcdef0123456789abcdef")}
+	ciphertext := encrypt(t, secret, randomBytes(3*chunkSize+100))
+
+	headerSize := fixedSize + checkSize
+	block := chunkSize + 16 // 密文块大小（明文块 + GCM 认证标签）
+	chunk := func(i int) []byte {
+		start := headerSize + i*block
+		return ciphertext[start:min(start+block, len(ciphertext))]
+	}
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+	header := ciphertext[:headerSize]
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"截断最后一块", ciphertext[:len(ciphertext)-10]},
+		{"缺少最后一块", join(header, chunk(0), chunk(1), chunk(2))},
+		{"只剩文件头", header},
+		{"交换前两块", join(header, chunk(1), chunk(0), chunk(2), chunk(3))},
+		{"重复一块", join(header, chunk(0), chunk(0), chunk(1), chunk(2), chunk(3))},
+		{"最后一块放到中间", join(header, chunk(0), chunk(3), chunk(1), chunk(2))},
+		{"修改数据", func() []byte {
+			b := bytes.Clone(ciphertext)
+			b[headerSize+block+5] ^= 0x01
+			return b
+		}()},
+		{"追加数据", join(ciphertext, []byte("extra"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decrypt(tt.data, secret)
+			if !errors.Is(err, ErrCorrupted) {
+				t.Fatalf("解密错误 = %v, want ErrCorrupted", err)
+			}
+		})
+	}
+}
+
+func TestTamperedHeader(t *testing.T) {
+	secret := Secret{KeyFile: writeKeyFile(t, "0123456789abcdef0123456789abcdef")}
+	ciphertext := encrypt(t, secret, []byte("hello"))
+
+	tests := []struct {
+		name    string
+		offset  int
+		wantErr error
+	}{
+		{"修改盐", len(magic) + 5, ErrWrongKey},
+		{"修改 nonce 前缀", len(magic) + 21, ErrWrongKey},
+		{"修改密钥校验值", fixedSize, ErrWrongKey},
+		{"修改魔数", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bytes.Clone(ciphertext)
+			b[tt.offset] ^= 0x01
+			_, err := decrypt(b, secret)
+			if err == nil {
+				t.Fatal("修改文件头后解密应失败")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("解密错误 = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWrongKey(t *testing.T) {
+	keyFile := writeKeyFile(t, "0123456789abcdef0123456789abcdef")
+	otherKeyFile := writeKeyFile(t, "fedcba9876543210fedcba9876543210")
+	keyFileCiphertext := encrypt(t, Secret{KeyFile: keyFile}, []byte("hello"))
+	passphraseCiphertext := encrypt(t, Secret{Passphrase: "secret"}, []byte("hello"))
+
+	tests := []struct {
+		name       string
+		ciphertext []byte
+		secret     Secret
+		wrongKey   bool // 是否应返回 ErrWrongKey（否则为缺少密钥等其他错误）
+	}{
+		{"其他密钥文件", keyFileCiphertext, Secret{KeyFile: otherKeyFile}, true},
+		{"未提供密钥文件", keyFileCiphertext, Secret{Passphrase: "secret"}, false},
+		{"错误的密码", passphraseCiphertext, Secret{Passphrase: "Secret"}, true},
+		{"未提供密码", passphraseCiphertext, Secret{KeyFile: keyFile}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decrypt(tt.ciphertext, tt.secret)
+			if err == nil {
+				t.Fatal("解密应失败")
+			}
+			if errors.Is(err, ErrWrongKey) != tt.wrongKey {
+				t.Fatalf("解密错误 = %v, 是否为 ErrWrongKey 应为 %v", err, tt.wrongKey)
+			}
+		})
+	}
+}
+
+func TestShortKeyFile(t *testing.T) {
+	if _, err := NewKey(Secret{KeyFile: writeKeyFile(t, "too short")}); err == nil {
+		t.Fatal("过短的密钥文件应返回错误")
+	}
+}
+
+func TestNotEncrypted(t *testing.T) {
+	_, err := decrypt([]byte("PK\x03\x04 this is a zip file, not an encrypted backup"), Secret{Passphrase: "x"})
+	if err == nil || errors.Is(err, ErrWrongKey) || errors.Is(err, ErrCorrupted) {
+		t.Fatalf("解密错误 = %v, want 不是加密文件的错误", err)
+	}
+}
+
+func TestCounterOverflow(t *testing.T) {
+	key, err := NewKey(Secret{KeyFile: writeKeyFile(t, "0123456789abcdef0123456789abcdef")})
+	if err != nil {
+		t.Fatalf("NewKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		size    int
+		wantErr error
+	}{
+		{"最大块序号作为最后一块", chunkSize, nil},
+		{"超过最大块序号", chunkSize + 1, ErrTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewWriter(io.Discard, key)
+			if err != nil {
+				t.Fatalf("NewWriter() error = %v", err)
+			}
+			w.(*writer).counter = math.MaxUint32
+			_, err = w.Write(randomBytes(tt.size))
+			if err == nil {
+				err = w.Close()
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("加密错误 = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package crypt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// PassphraseEnv 提供加密密码的环境变量
+const PassphraseEnv = "BAKCTL_PASSPHRASE"
+
+var (
+	passphraseMu     sync.Mutex // 保护 cachedPassphrase
+	cachedPassphrase string     // 本次执行中已输入的密码
+)
+
+// Passphrase 获取加密密码
+//
+// 优先使用环境变量 BAKCTL_PASSPHRASE；未设置时如果标准输入是终端则提示输入，
+// 同一次执行中只提示一次（并行执行多个任务时共用）。
+//
+// 参数:
+//   - confirm: 是否要求再次输入确认（用于加密）
+//
+// 返回值:
+//   - string: 密码
+//   - error: 无法获取密码时返回错误信息
+func Passphrase(confirm bool) (string, error) {
+	if p := os.Getenv(PassphraseEnv); p != "" {
+		return p, nil
+	}
+
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+	if cachedPassphrase != "" {
+		return cachedPassphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("未提供密码: 请设置 %s 环境变量或使用密钥文件", PassphraseEnv)
+	}
+
+	p, err := readPassword(fd, "请输入备份加密密码: ")
+	if err != nil {
+		return "", err
+	}
+	if p == "" {
+		return "", errors.New("密码不能为空")
+	}
+	if confirm {
+		again, err := readPassword(fd, "请再次输入密码: ")
+		if err != nil {
+			return "", err
+		}
+		if again != p {
+			return "", errors.New("两次输入的密码不一致")
+		}
+	}
+
+	cachedPassphrase = p
+	return p, nil
+}
+
+// readPassword 在终端中提示并读取密码（不回显）
+func readPassword(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	data, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("读取密码失败: %w", err)
+	}
+	return string(data), nil
+}
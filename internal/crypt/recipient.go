@@ -0,0 +1,219 @@
+package crypt
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// 公钥和身份私钥的文本格式
+const (
+	PublicKeyPrefix = "bakctl-pub-" // 接收者公钥前缀
+	IdentityPrefix  = "BAKCTL-KEY-" // 身份私钥前缀
+)
+
+const (
+	stanzaSize    = 32 + keySize + 16 // 接收者条目长度：临时公钥 | 加密的文件密钥
+	maxRecipients = 64                // 接收者数量上限
+	wrapInfo      = "bakctl x25519 file key"
+)
+
+// keyEncoding 公钥和私钥的编码方式
+var keyEncoding = base64.RawURLEncoding
+
+// GenerateIdentity 生成新的 X25519 身份
+//
+// 返回值:
+//   - string: 身份私钥（文本格式，写入身份文件）
+//   - string: 对应的接收者公钥（配置到任务中）
+//   - error: 生成失败时返回错误信息
+func GenerateIdentity() (string, string, error) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("生成密钥对失败: %w", err)
+	}
+	return IdentityPrefix + keyEncoding.EncodeToString(priv.Bytes()), FormatRecipient(priv.PublicKey()), nil
+}
+
+// IdentityFileContent 返回身份文件的内容
+//
+// 参数:
+//   - identity: 身份私钥
+//   - recipient: 对应的接收者公钥
+//
+// 返回值:
+//   - string: 身份文件内容（以 # 开头的行为注释）
+func IdentityFileContent(identity, recipient string) string {
+	return fmt.Sprintf("# created: %s\n# public key: %s\n%s\n", time.Now().Format(time.RFC3339), recipient, identity)
+}
+
+// FormatRecipient 返回公钥的文本格式
+func FormatRecipient(pub *ecdh.PublicKey) string {
+	return PublicKeyPrefix + keyEncoding.EncodeToString(pub.Bytes())
+}
+
+// ParseRecipient 解析接收者公钥
+//
+// 参数:
+//   - s: 接收者公钥，如 bakctl-pub-...
+//
+// 返回值:
+//   - *ecdh.PublicKey: X25519 公钥
+//   - error: 格式无效时返回错误信息
+func ParseRecipient(s string) (*ecdh.PublicKey, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, PublicKeyPrefix) {
+		return nil, fmt.Errorf("无效的接收者公钥 '%s': 应以 %s 开头", s, PublicKeyPrefix)
+	}
+	data, err := keyEncoding.DecodeString(strings.TrimPrefix(s, PublicKeyPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("无效的接收者公钥 '%s': %w", s, err)
+	}
+	pub, err := ecdh.X25519().NewPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("无效的接收者公钥 '%s': %w", s, err)
+	}
+	return pub, nil
+}
+
+// ParseIdentityFile 读取身份文件中的身份私钥
+//
+// 身份文件中以 # 开头的行和空行被忽略，可以包含多个身份私钥。
+//
+// 参数:
+//   - path: 身份文件路径
+//
+// 返回值:
+//   - []*ecdh.PrivateKey: 身份私钥列表
+//   - error: 读取失败、格式无效或不包含身份私钥时返回错误信息
+func ParseIdentityFile(path string) ([]*ecdh.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取身份文件失败: %w", err)
+	}
+
+	var identities []*ecdh.PrivateKey
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if !strings.HasPrefix(text, IdentityPrefix) {
+			return nil, fmt.Errorf("身份文件 %s 第 %d 行格式无效: 应以 %s 开头", path, line, IdentityPrefix)
+		}
+		raw, err := keyEncoding.DecodeString(strings.TrimPrefix(text, IdentityPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("身份文件 %s 第 %d 行格式无效: %w", path, line, err)
+		}
+		priv, err := ecdh.X25519().NewPrivateKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("身份文件 %s 第 %d 行格式无效: %w", path, line, err)
+		}
+		identities = append(identities, priv)
+	}
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("身份文件 %s 中没有身份私钥", path)
+	}
+	return identities, nil
+}
+
+// newRecipientsKey 生成随机的文件密钥，并为每个接收者加密文件密钥
+func newRecipientsKey(recipients []string, salt []byte) (*Key, error) {
+	if len(recipients) > maxRecipients {
+		return nil, fmt.Errorf("接收者数量不能超过 %d 个", maxRecipients)
+	}
+
+	fileKey := make([]byte, keySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, fmt.Errorf("生成文件密钥失败: %w", err)
+	}
+
+	k := &Key{kdf: kdfRecipients, param: uint32(len(recipients)), salt: salt}
+	for _, s := range recipients {
+		pub, err := ParseRecipient(s)
+		if err != nil {
+			return nil, err
+		}
+		stanza, err := wrapFileKey(fileKey, pub)
+		if err != nil {
+			return nil, err
+		}
+		k.stanzas = append(k.stanzas, stanza...)
+	}
+
+	var err error
+	if k.key, err = hkdf.Key(sha256.New, fileKey, salt, hkdfInfo, keySize); err != nil {
+		return nil, fmt.Errorf("派生密钥失败: %w", err)
+	}
+	return k, nil
+}
+
+// wrapFileKey 使用临时密钥对为接收者加密文件密钥，返回接收者条目
+func wrapFileKey(fileKey []byte, recipient *ecdh.PublicKey) ([]byte, error) {
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("生成临时密钥对失败: %w", err)
+	}
+	shared, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return nil, fmt.Errorf("计算共享密钥失败: %w", err)
+	}
+
+	aead, err := wrapAEAD(shared, ephemeral.PublicKey().Bytes(), recipient.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	stanza := append([]byte{}, ephemeral.PublicKey().Bytes()...)
+	return aead.Seal(stanza, make([]byte, aead.NonceSize()), fileKey, nil), nil
+}
+
+// unwrapFileKey 使用身份文件中的私钥解密文件密钥
+func unwrapFileKey(stanzas []byte, identityFile string) ([]byte, error) {
+	identities, err := ParseIdentityFile(identityFile)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, priv := range identities {
+		for off := 0; off+stanzaSize <= len(stanzas); off += stanzaSize {
+			stanza := stanzas[off : off+stanzaSize]
+			ephemeral, err := ecdh.X25519().NewPublicKey(stanza[:32])
+			if err != nil {
+				continue
+			}
+			shared, err := priv.ECDH(ephemeral)
+			if err != nil {
+				continue
+			}
+			aead, err := wrapAEAD(shared, stanza[:32], priv.PublicKey().Bytes())
+			if err != nil {
+				return nil, err
+			}
+			if fileKey, err := aead.Open(nil, make([]byte, aead.NonceSize()), stanza[32:], nil); err == nil {
+				return fileKey, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: 身份文件中没有该备份的接收者私钥", ErrWrongKey)
+}
+
+// wrapAEAD 根据共享密钥派生加密文件密钥使用的 AES-256-GCM
+// 每个接收者条目使用新的临时密钥对，派生的密钥只使用一次，因此 nonce 固定为 0
+func wrapAEAD(shared, ephemeral, recipient []byte) (cipher.AEAD, error) {
+	salt := append(append([]byte{}, ephemeral...), recipient...)
+	key, err := hkdf.Key(sha256.New, shared, salt, wrapInfo, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("派生密钥失败: %w", err)
+	}
+	return newAEAD(key)
+}
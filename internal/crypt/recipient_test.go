@@ -0,0 +1,191 @@
+package crypt
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newIdentity 生成身份并写入临时目录中的身份文件，返回身份文件路径和接收者公钥
+func newIdentity(t *testing.T) (string, string) {
+	t.Helper()
+	identity, recipient, err := GenerateIdentity()
+	if err != nil {
+		t.Fatalf("GenerateIdentity() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "identity.txt")
+	if err := os.WriteFile(path, []byte(IdentityFileContent(identity, recipient)), 0600); err != nil {
+		t.Fatalf("写入身份文件失败: %v", err)
+	}
+	return path, recipient
+}
+
+func TestRecipientsRoundTrip(t *testing.T) {
+	alice, aliceRecipient := newIdentity(t)
+	bob, bobRecipient := newIdentity(t)
+	eve, _ := newIdentity(t)
+	data := randomBytes(2*chunkSize + 3)
+
+	tests := []struct {
+		name       string
+		recipients []string
+		identity   string
+		wrongKey   bool // 是否应返回 ErrWrongKey
+	}{
+		{"单个接收者", []string{aliceRecipient}, alice, false},
+		{"多个接收者-第一个", []string{aliceRecipient, bobRecipient}, alice, false},
+		{"多个接收者-第二个", []string{aliceRecipient, bobRecipient}, bob, false},
+		{"不是接收者", []string{aliceRecipient, bobRecipient}, eve, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ciphertext := encrypt(t, Secret{Recipients: tt.recipients}, data)
+			got, err := decrypt(ciphertext, Secret{Identity: tt.identity})
+			if tt.wrongKey {
+				if !errors.Is(err, ErrWrongKey) {
+					t.Fatalf("解密错误 = %v, want ErrWrongKey", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("解密失败: %v", err)
+			}
+			if string(got) != string(data) {
+				t.Fatal("解密结果不一致")
+			}
+		})
+	}
+}
+
+func TestRecipientsWithoutIdentity(t *testing.T) {
+	_, recipient := newIdentity(t)
+	ciphertext := encrypt(t, Secret{Recipients: []string{recipient}}, []byte("hello"))
+	if _, err := decrypt(ciphertext, Secret{Passphrase: "secret"}); err == nil {
+		t.Fatal("未提供身份文件时解密应失败")
+	}
+}
+
+func TestRecipientLimit(t *testing.T) {
+	_, recipient := newIdentity(t)
+	repeat := func(n int) []string {
+		recipients := make([]string, n)
+		for i := range recipients {
+			recipients[i] = recipient
+		}
+		return recipients
+	}
+
+	tests := []struct {
+		name    string
+		count   int
+		wantErr bool
+	}{
+		{"1 个接收者", 1, false},
+		{"达到上限", maxRecipients, false},
+		{"超过上限", maxRecipients + 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := NewKey(Secret{Recipients: repeat(tt.count)})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(key.stanzas) != tt.count*stanzaSize {
+				t.Errorf("接收者条目长度 = %d, want %d", len(key.stanzas), tt.count*stanzaSize)
+			}
+		})
+	}
+}
+
+func TestReaderRejectsRecipientCount(t *testing.T) {
+	identity, recipient := newIdentity(t)
+	ciphertext := encrypt(t, Secret{Recipients: []string{recipient}}, []byte("hello"))
+
+	tests := []struct {
+		name  string
+		count uint32
+	}{
+		{"0 个接收者", 0},
+		{"超过上限", maxRecipients + 1},
+		{"大于实际数量", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := append([]byte{}, ciphertext...)
+			binary.BigEndian.PutUint32(b[len(magic)+1:], tt.count)
+			if _, err := decrypt(b, Secret{Identity: identity}); err == nil {
+				t.Fatal("接收者数量无效时解密应失败")
+			}
+		})
+	}
+}
+
+func TestParseRecipient(t *testing.T) {
+	_, recipient := newIdentity(t)
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"有效公钥", recipient, false},
+		{"前后空白", "  " + recipient + "\n", false},
+		{"缺少前缀", strings.TrimPrefix(recipient, PublicKeyPrefix), true},
+		{"编码无效", PublicKeyPrefix + "!!!", true},
+		{"长度错误", recipient[:len(recipient)-4], true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRecipient(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRecipient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseIdentityFile(t *testing.T) {
+	identity, recipient, err := GenerateIdentity()
+	if err != nil {
+		t.Fatalf("GenerateIdentity() error = %v", err)
+	}
+	other, _, err := GenerateIdentity()
+	if err != nil {
+		t.Fatalf("GenerateIdentity() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr bool
+	}{
+		{"生成的身份文件", IdentityFileContent(identity, recipient), 1, false},
+		{"多个身份和空行", identity + "\n\n# comment\n" + other + "\n", 2, false},
+		{"只有注释", "# nothing here\n", 0, true},
+		{"格式无效", "not-a-key\n", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "identity.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatalf("写入身份文件失败: %v", err)
+			}
+			got, err := ParseIdentityFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseIdentityFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("身份数量 = %d, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
@@ -61,9 +61,12 @@ func CleanupOrphanRecords(db *sqlx.DB, taskID int64) (int, error) {
 			continue
 		}
 
-		// 检查StoragePath指向的文件是否存在
-		if _, err := os.Stat(record.StoragePath); os.IsNotExist(err) {
-			orphanIDs = append(orphanIDs, record.ID)
+		// 检查备份文件是否存在（分卷备份缺少任一分卷都无法恢复）
+		for _, path := range record.VolumePaths() {
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				orphanIDs = append(orphanIDs, record.ID)
+				break
+			}
 		}
 	}
 
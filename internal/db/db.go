@@ -95,11 +95,15 @@ func InitSQLite(dbFilename string, dataDirPath string) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("连接数据库失败 (路径：%s) :%w", dbFullPath, err)
 	}
 
-	// 如果数据库文件不存在，则执行初始化脚本
+	// 如果数据库文件不存在，则执行初始化脚本；否则升级已有数据库的表结构
 	if !dbExists {
 		if _, err := sqlDB.Exec(initDbScript); err != nil {
 			return nil, fmt.Errorf("执行数据库初始化脚本失败：%w", err)
 		}
+	} else {
+		if err := migrateSchema(sqlDB); err != nil {
+			return nil, fmt.Errorf("升级数据库表结构失败：%w", err)
+		}
 	}
 
 	return sqlDB, nil
@@ -119,6 +123,33 @@ CREATE TABLE IF NOT EXISTS backup_tasks (
     exclude_rules TEXT,                  -- 排除规则 (JSON数组字符串)
     max_file_size INTEGER,               -- 最大文件大小 (字节)
     min_file_size INTEGER,               -- 最小文件大小 (字节)
+    backup_mode TEXT DEFAULT 'full',     -- 备份模式 (full/incremental)
+    storage_mode TEXT DEFAULT 'archive', -- 存储模式 (archive/repository)
+    pre_hook TEXT DEFAULT '',            -- 备份前执行的钩子命令
+    post_hook TEXT DEFAULT '',           -- 备份成功后执行的钩子命令
+    on_failure_hook TEXT DEFAULT '',     -- 备份失败时执行的钩子命令
+    hook_timeout INTEGER DEFAULT 300,    -- 钩子命令超时时间（秒）
+    timeout INTEGER DEFAULT 0,           -- 任务超时时间（秒，0表示不限制）
+    backup_sources TEXT DEFAULT '',      -- 备份源路径列表（JSON格式字符串，为空表示只有备份源目录）
+    format TEXT DEFAULT 'zip',           -- 归档格式 (zip/tar/tar.gz/tar.bz2)
+    compression TEXT DEFAULT '',         -- 压缩等级（为空时根据 compress 确定）
+    store_exts TEXT DEFAULT '',          -- 不压缩的文件扩展名（JSON格式字符串）
+    encryption TEXT DEFAULT '',          -- 加密算法（为空表示不加密）
+    key_file TEXT DEFAULT '',            -- 密钥文件路径（为空时使用密码）
+    recipients TEXT DEFAULT '',          -- 接收者公钥（JSON数组格式）
+    volume_size INTEGER DEFAULT 0,       -- 分卷大小（字节，0表示不分卷）
+    read_limit INTEGER DEFAULT 0,        -- 读取限速（字节/秒，0表示不限速）
+    hash_algorithm TEXT DEFAULT 'sha256', -- 校验码哈希算法 (sha256/sha512/blake2b/xxhash)
+    ignore_files BOOLEAN DEFAULT FALSE,   -- 是否使用源目录中的 .bakignore 忽略文件
+    newer_than TEXT DEFAULT '',           -- 只备份在此之后修改的文件（时长或日期，为空表示不限制）
+    older_than TEXT DEFAULT '',           -- 只备份在此之前修改的文件（时长或日期，为空表示不限制）
+    file_types TEXT DEFAULT '',           -- 只备份这些类型的文件（JSON数组格式，为空表示不限制）
+    file_owner TEXT DEFAULT '',           -- 只备份属于此用户的文件（用户名或UID，为空表示不限制）
+    file_group TEXT DEFAULT '',           -- 只备份属于此用户组的文件（组名或GID，为空表示不限制）
+    exclude_hidden BOOLEAN DEFAULT FALSE, -- 是否排除隐藏文件和目录（名称以 . 开头）
+    symlinks TEXT DEFAULT 'store',        -- 符号链接处理策略 (store/follow/skip)
+    error_policy TEXT DEFAULT 'strict',   -- 单个条目出错时的处理策略 (strict/skip-unreadable/skip-changed)
+    full_every INTEGER DEFAULT 7,         -- 增量备份链的最大长度（每隔多少次备份执行一次全量备份）
     created_at TEXT DEFAULT CURRENT_TIMESTAMP, -- 任务创建时间 (ISO8601格式)
     updated_at TEXT DEFAULT CURRENT_TIMESTAMP  -- 任务最后更新时间 (ISO8601格式)
 );
@@ -134,7 +165,51 @@ CREATE TABLE IF NOT EXISTS backup_records (
     failure_message TEXT,                     -- 备份失败时的错误信息
     checksum TEXT,                            -- 备份文件校验码
     storage_path TEXT NOT NULL,               -- 备份文件存放路径，非空
-    created_at TEXT DEFAULT CURRENT_TIMESTAMP -- 备份完成时间 (ISO8601格式)
+    created_at TEXT DEFAULT CURRENT_TIMESTAMP, -- 备份完成时间 (ISO8601格式)
+    parent_version_id TEXT DEFAULT '',        -- 增量备份依赖的上一个版本ID (全量备份为空)
+    storage_mode TEXT DEFAULT 'archive',      -- 存储模式 (archive: 归档文件, repository: 仓库快照)
+    state TEXT DEFAULT '',                    -- 备份状态（success/partial/failed/cancelled/timeout）
+    encryption TEXT DEFAULT '',               -- 加密方案（为空表示未加密）
+    volume_count INTEGER DEFAULT 0,           -- 分卷数量（0表示未分卷）
+    started_at TEXT DEFAULT '',               -- 开始时间（UTC，格式同 created_at）
+    finished_at TEXT DEFAULT '',              -- 结束时间（UTC，格式同 created_at）
+    duration_ms INTEGER DEFAULT 0,            -- 执行耗时（毫秒）
+    file_count INTEGER DEFAULT 0,             -- 备份源中的文件数
+    dir_count INTEGER DEFAULT 0,              -- 备份源中的目录数
+    source_bytes INTEGER DEFAULT 0,           -- 从备份源读取的字节数
+    skipped_count INTEGER DEFAULT 0,          -- 被过滤器跳过的条目数
+    host_name TEXT DEFAULT '',                -- 执行备份的主机名
+    bakctl_version TEXT DEFAULT '',           -- 执行备份的 bakctl 版本
+    verified_at TEXT DEFAULT '',              -- 最近一次校验时间（UTC，为空表示从未校验）
+    verify_result TEXT DEFAULT '',            -- 最近一次校验结果（ok/missing/mismatch/corrupt）
+    verify_message TEXT DEFAULT ''            -- 最近一次校验发现的问题
+);
+
+CREATE TABLE IF NOT EXISTS backup_files (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT, -- 记录唯一标识，自增主键
+    version_id TEXT NOT NULL,             -- 所属的备份版本ID
+    path TEXT NOT NULL,                   -- 归档内的相对路径
+    size INTEGER NOT NULL,                -- 文件大小 (字节)
+    mod_time INTEGER NOT NULL,            -- 修改时间 (Unix纳秒时间戳)
+    mode INTEGER DEFAULT 0,               -- 文件权限
+    hash TEXT,                            -- 文件内容哈希 (sha256)
+    change_time INTEGER DEFAULT 0,        -- 状态变化时间 (Unix纳秒时间戳，0表示未知)
+    change_type TEXT NOT NULL             -- 相对上一个版本的变化类型 (added/modified/unchanged/deleted)
+);
+
+CREATE TABLE IF NOT EXISTS backup_volumes (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT, -- 记录唯一标识，自增主键
+    version_id TEXT NOT NULL,             -- 所属的备份版本ID
+    seq INTEGER NOT NULL,                 -- 分卷序号 (从1开始)
+    size INTEGER NOT NULL,                -- 分卷文件大小 (字节)
+    checksum TEXT                         -- 分卷文件校验码
+);
+
+CREATE TABLE IF NOT EXISTS backup_warnings (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT, -- 记录唯一标识，自增主键
+    version_id TEXT NOT NULL,             -- 所属的备份版本ID
+    path TEXT NOT NULL,                   -- 条目在归档内的路径
+    message TEXT NOT NULL                 -- 警告信息
 );
 
 -- backup_tasks 表索引(显式)
@@ -144,6 +219,15 @@ CREATE INDEX IF NOT EXISTS idx_backup_tasks_name ON backup_tasks (name);
 CREATE INDEX IF NOT EXISTS idx_backup_records_created_at ON backup_records(created_at);
 CREATE INDEX IF NOT EXISTS idx_backup_records_task_id ON backup_records (task_id); 
 CREATE INDEX IF NOT EXISTS idx_backup_records_task_name ON backup_records (task_name);
+
+-- backup_files 表索引
+CREATE INDEX IF NOT EXISTS idx_backup_files_version_id ON backup_files (version_id);
+
+-- backup_volumes 表索引
+CREATE INDEX IF NOT EXISTS idx_backup_volumes_version_id ON backup_volumes (version_id);
+
+-- backup_warnings 表索引
+CREATE INDEX IF NOT EXISTS idx_backup_warnings_version_id ON backup_warnings (version_id);
 `
 
 // 固定的SQL更新语句
@@ -155,6 +239,34 @@ const updateBackupTaskSQL = `UPDATE backup_tasks SET
 	exclude_rules = ?,
 	max_file_size = ?,
 	min_file_size = ?,
+	backup_mode = ?,
+	storage_mode = ?,
+	pre_hook = ?,
+	post_hook = ?,
+	on_failure_hook = ?,
+	hook_timeout = ?,
+	timeout = ?,
+	backup_dir = ?,
+	backup_sources = ?,
+	format = ?,
+	compression = ?,
+	store_exts = ?,
+	encryption = ?,
+	key_file = ?,
+	recipients = ?,
+	volume_size = ?,
+	read_limit = ?,
+	hash_algorithm = ?,
+	ignore_files = ?,
+	newer_than = ?,
+	older_than = ?,
+	file_types = ?,
+	file_owner = ?,
+	file_group = ?,
+	exclude_hidden = ?,
+	symlinks = ?,
+	error_policy = ?,
+	full_every = ?,
 	updated_at = CURRENT_TIMESTAMP
 WHERE ID = ?`
 
@@ -176,6 +288,34 @@ func UpdateTask(db *sqlx.DB, params types.UpdateTaskParams) error {
 		params.ExcludeRules,
 		params.MaxFileSize,
 		params.MinFileSize,
+		params.BackupMode,
+		params.StorageMode,
+		params.PreHook,
+		params.PostHook,
+		params.OnFailureHook,
+		params.HookTimeout,
+		params.Timeout,
+		params.BackupDir,
+		params.BackupSources,
+		params.Format,
+		params.Compression,
+		params.StoreExts,
+		params.Encryption,
+		params.KeyFile,
+		params.Recipients,
+		params.VolumeSize,
+		params.ReadLimit,
+		params.HashAlgorithm,
+		params.IgnoreFiles,
+		params.NewerThan,
+		params.OlderThan,
+		params.FileTypes,
+		params.FileOwner,
+		params.FileGroup,
+		params.ExcludeHidden,
+		params.Symlinks,
+		params.ErrorPolicy,
+		params.FullEvery,
 		params.ID)
 
 	if err != nil {
@@ -222,6 +362,36 @@ func InsertAddTaskConfig(db *sqlx.DB, cfg *types.TaskConfig) error {
 		return fmt.Errorf("编码排除规则失败: %w", err)
 	}
 
+	// 处理不压缩的文件扩展名
+	storeExtsJSON, err := utils.MarshalRules(cfg.StoreExts)
+	if err != nil {
+		return fmt.Errorf("编码不压缩的文件扩展名失败: %w", err)
+	}
+
+	// 处理接收者公钥（不使用公钥加密时为空）
+	var recipientsJSON string
+	if len(cfg.Recipients) > 0 {
+		if recipientsJSON, err = utils.MarshalRules(cfg.Recipients); err != nil {
+			return fmt.Errorf("编码接收者公钥失败: %w", err)
+		}
+	}
+
+	// 处理文件类型（不限制文件类型时为空）
+	var fileTypesJSON string
+	if len(cfg.FileTypes) > 0 {
+		if fileTypesJSON, err = utils.MarshalRules(cfg.FileTypes); err != nil {
+			return fmt.Errorf("编码文件类型失败: %w", err)
+		}
+	}
+
+	// 处理备份源路径（只有一个备份源时不记录列表）
+	var sourcesJSON string
+	if len(cfg.BackupSources) > 1 {
+		if sourcesJSON, err = utils.MarshalRules(cfg.BackupSources); err != nil {
+			return fmt.Errorf("编码备份源路径失败: %w", err)
+		}
+	}
+
 	// 存储目录(默认: ~/.bakctl)
 	storageDir := cfg.StorageDir
 	if storageDir == "" {
@@ -233,16 +403,43 @@ func InsertAddTaskConfig(db *sqlx.DB, cfg *types.TaskConfig) error {
 
 	// 将 AddTaskConfig 转换为 BackupTask, 处理规则字段的 JSON 编码
 	backupTask := types.BackupTask{
-		Name:         cfg.Name,         // 任务名称
-		RetainCount:  cfg.RetainCount,  // 保留备份数量
-		RetainDays:   cfg.RetainDays,   // 保留天数
-		BackupDir:    cfg.BackupDir,    // 备份源目录
-		StorageDir:   actualStorageDir, // 存储目录（存储目录 + 备份源目录名）
-		Compress:     cfg.Compress,     // 是否压缩
-		IncludeRules: includeRulesJSON, // 包含规则
-		ExcludeRules: excludeRulesJSON, // 排除规则
-		MaxFileSize:  cfg.MaxFileSize,  // 最大文件大小
-		MinFileSize:  cfg.MinFileSize,  // 最小文件大小
+		Name:          cfg.Name,          // 任务名称
+		RetainCount:   cfg.RetainCount,   // 保留备份数量
+		RetainDays:    cfg.RetainDays,    // 保留天数
+		BackupDir:     cfg.BackupDir,     // 备份源目录
+		StorageDir:    actualStorageDir,  // 存储目录（存储目录 + 备份源目录名）
+		Compress:      cfg.Compress,      // 是否压缩
+		IncludeRules:  includeRulesJSON,  // 包含规则
+		ExcludeRules:  excludeRulesJSON,  // 排除规则
+		MaxFileSize:   cfg.MaxFileSize,   // 最大文件大小
+		MinFileSize:   cfg.MinFileSize,   // 最小文件大小
+		BackupMode:    cfg.BackupMode,    // 备份模式
+		StorageMode:   cfg.StorageMode,   // 存储模式
+		PreHook:       cfg.PreHook,       // 备份前执行的钩子命令
+		PostHook:      cfg.PostHook,      // 备份成功后执行的钩子命令
+		OnFailureHook: cfg.OnFailureHook, // 备份失败时执行的钩子命令
+		HookTimeout:   cfg.HookTimeout,   // 钩子命令超时时间（秒）
+		Timeout:       cfg.Timeout,       // 任务超时时间（秒，0表示不限制）
+		BackupSources: sourcesJSON,       // 备份源路径列表（JSON格式字符串，为空表示只有备份源目录）
+		Format:        cfg.Format,        // 归档格式 (zip/tar/tar.gz/tar.bz2)
+		Compression:   cfg.Compression,   // 压缩等级（为空时根据 compress 确定）
+		StoreExts:     storeExtsJSON,     // 不压缩的文件扩展名（JSON格式字符串）
+		Encryption:    cfg.Encryption,    // 加密算法（为空表示不加密）
+		KeyFile:       cfg.KeyFile,       // 密钥文件路径（为空时使用密码）
+		Recipients:    recipientsJSON,    // 接收者公钥（JSON数组格式）
+		VolumeSize:    cfg.VolumeSize,    // 分卷大小（字节，0表示不分卷）
+		ReadLimit:     cfg.ReadLimit,     // 读取限速（字节/秒，0表示不限速）
+		HashAlgorithm: cfg.HashAlgorithm, // 校验码哈希算法 (sha256/sha512/blake2b/xxhash)
+		IgnoreFiles:   cfg.IgnoreFiles,   // 是否使用源目录中的 .bakignore 忽略文件
+		NewerThan:     cfg.NewerThan,     // 只备份在此之后修改的文件（时长或日期，为空表示不限制）
+		OlderThan:     cfg.OlderThan,     // 只备份在此之前修改的文件（时长或日期，为空表示不限制）
+		FileTypes:     fileTypesJSON,     // 只备份这些类型的文件（JSON数组格式，为空表示不限制）
+		FileOwner:     cfg.FileOwner,     // 只备份属于此用户的文件（用户名或UID，为空表示不限制）
+		FileGroup:     cfg.FileGroup,     // 只备份属于此用户组的文件（组名或GID，为空表示不限制）
+		ExcludeHidden: cfg.ExcludeHidden, // 是否排除隐藏文件和目录（名称以 . 开头）
+		Symlinks:      cfg.Symlinks,      // 符号链接处理策略 (store/follow/skip)
+		ErrorPolicy:   cfg.ErrorPolicy,   // 单个条目出错时的处理策略 (strict/skip-unreadable/skip-changed)
+		FullEvery:     cfg.FullEvery,     // 增量备份链的最大长度（每隔多少次备份执行一次全量备份）
 	}
 
 	// 执行插入操作
@@ -268,7 +465,34 @@ const insertBackupTaskQuery = `
 		include_rules,
 		exclude_rules,
 		max_file_size,
-		min_file_size
+		min_file_size,
+		backup_mode,
+		storage_mode,
+		pre_hook,
+		post_hook,
+		on_failure_hook,
+		hook_timeout,
+		timeout,
+		backup_sources,
+		format,
+		compression,
+		store_exts,
+		encryption,
+		key_file,
+		recipients,
+		volume_size,
+		read_limit,
+		hash_algorithm,
+		ignore_files,
+		newer_than,
+		older_than,
+		file_types,
+		file_owner,
+		file_group,
+		exclude_hidden,
+		symlinks,
+		error_policy,
+		full_every
 	) VALUES (
 		:name,
 		:retain_count,
@@ -279,7 +503,34 @@ const insertBackupTaskQuery = `
 		:include_rules,
 		:exclude_rules,
 		:max_file_size,
-		:min_file_size
+		:min_file_size,
+		:backup_mode,
+		:storage_mode,
+		:pre_hook,
+		:post_hook,
+		:on_failure_hook,
+		:hook_timeout,
+		:timeout,
+		:backup_sources,
+		:format,
+		:compression,
+		:store_exts,
+		:encryption,
+		:key_file,
+		:recipients,
+		:volume_size,
+		:read_limit,
+		:hash_algorithm,
+		:ignore_files,
+		:newer_than,
+		:older_than,
+		:file_types,
+		:file_owner,
+		:file_group,
+		:exclude_hidden,
+		:symlinks,
+		:error_policy,
+		:full_every
 	)`
 
 // SQL INSERT 语句，用于 backup_records 表
@@ -293,7 +544,24 @@ const insertBackupRecordQuery = `
 		status,
 		failure_message,
 		checksum,
-		storage_path
+		storage_path,
+		parent_version_id,
+		storage_mode,
+		state,
+		encryption,
+		volume_count,
+		started_at,
+		finished_at,
+		duration_ms,
+		file_count,
+		dir_count,
+		source_bytes,
+		skipped_count,
+		host_name,
+		bakctl_version,
+		verified_at,
+		verify_result,
+		verify_message
 	) VALUES (
 		:task_id,
 		:task_name,
@@ -303,7 +571,24 @@ const insertBackupRecordQuery = `
 		:status,
 		:failure_message,
 		:checksum,
-		:storage_path
+		:storage_path,
+		:parent_version_id,
+		:storage_mode,
+		:state,
+		:encryption,
+		:volume_count,
+		:started_at,
+		:finished_at,
+		:duration_ms,
+		:file_count,
+		:dir_count,
+		:source_bytes,
+		:skipped_count,
+		:host_name,
+		:bakctl_version,
+		:verified_at,
+		:verify_result,
+		:verify_message
 	)`
 
 // InsertBackupRecord 将 BackupRecord 结构体的数据插入到 backup_records 表中。
@@ -324,3 +609,43 @@ func InsertBackupRecord(db *sqlx.DB, rec *types.BackupRecord) error {
 
 	return nil
 }
+
+// InsertBackupResult 在一个事务中写入备份记录及其文件清单、分卷信息和警告
+//
+// 任意一项写入失败时回滚整个事务，不会留下缺少文件清单或分卷信息的成功记录。
+//
+// 参数：
+//   - db：数据库连接对象
+//   - rec：要插入的备份记录
+//   - files：文件清单（可为空）
+//   - volumes：分卷信息（可为空）
+//   - warnings：未能备份的条目（可为空）
+//
+// 返回值：
+//   - error：如果写入过程中发生错误，则返回非 nil 错误信息
+func InsertBackupResult(db *sqlx.DB, rec *types.BackupRecord, files []types.BackupFile, volumes []types.BackupVolume, warnings []types.BackupWarning) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.NamedExec(insertBackupRecordQuery, rec); err != nil {
+		return fmt.Errorf("插入备份记录失败: %w", err)
+	}
+	if err := insertBackupFiles(tx, rec.VersionID, files); err != nil {
+		return err
+	}
+	if err := insertBackupVolumes(tx, rec.VersionID, volumes); err != nil {
+		return err
+	}
+	if err := insertBackupWarnings(tx, rec.VersionID, warnings); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交备份记录失败: %w", err)
+	}
+
+	return nil
+}
@@ -33,6 +33,20 @@ import (
 //   - int：删除的记录数量
 //   - error：删除过程中的错误
 func DeleteBackupRecords(db *sqlx.DB, taskID int64) (int, error) {
+	// 先删除这些记录的文件清单、分卷记录和警告
+	filesQuery := `DELETE FROM backup_files WHERE version_id IN (SELECT version_id FROM backup_records WHERE task_id = ?)`
+	if _, err := db.Exec(filesQuery, taskID); err != nil {
+		return 0, fmt.Errorf("删除文件清单失败: %w", err)
+	}
+	volumesQuery := `DELETE FROM backup_volumes WHERE version_id IN (SELECT version_id FROM backup_records WHERE task_id = ?)`
+	if _, err := db.Exec(volumesQuery, taskID); err != nil {
+		return 0, fmt.Errorf("删除分卷记录失败: %w", err)
+	}
+	warningsQuery := `DELETE FROM backup_warnings WHERE version_id IN (SELECT version_id FROM backup_records WHERE task_id = ?)`
+	if _, err := db.Exec(warningsQuery, taskID); err != nil {
+		return 0, fmt.Errorf("删除备份警告失败: %w", err)
+	}
+
 	query := `DELETE FROM backup_records WHERE task_id = ?`
 
 	result, err := db.Exec(query, taskID)
@@ -90,6 +104,29 @@ func DeleteBackupRecordsByIDs(db *sqlx.DB, recordIDs []int64) (int, error) {
 		return 0, nil
 	}
 
+	// 先删除这些记录的文件清单、分卷记录和警告
+	filesQuery, filesArgs, err := sqlx.In("DELETE FROM backup_files WHERE version_id IN (SELECT version_id FROM backup_records WHERE ID IN (?))", recordIDs)
+	if err != nil {
+		return 0, fmt.Errorf("构建删除查询失败: %w", err)
+	}
+	if _, err := db.Exec(db.Rebind(filesQuery), filesArgs...); err != nil {
+		return 0, fmt.Errorf("删除文件清单失败: %w", err)
+	}
+	volumesQuery, volumesArgs, err := sqlx.In("DELETE FROM backup_volumes WHERE version_id IN (SELECT version_id FROM backup_records WHERE ID IN (?))", recordIDs)
+	if err != nil {
+		return 0, fmt.Errorf("构建删除查询失败: %w", err)
+	}
+	if _, err := db.Exec(db.Rebind(volumesQuery), volumesArgs...); err != nil {
+		return 0, fmt.Errorf("删除分卷记录失败: %w", err)
+	}
+	warningsQuery, warningsArgs, err := sqlx.In("DELETE FROM backup_warnings WHERE version_id IN (SELECT version_id FROM backup_records WHERE ID IN (?))", recordIDs)
+	if err != nil {
+		return 0, fmt.Errorf("构建删除查询失败: %w", err)
+	}
+	if _, err := db.Exec(db.Rebind(warningsQuery), warningsArgs...); err != nil {
+		return 0, fmt.Errorf("删除备份警告失败: %w", err)
+	}
+
 	// 使用sqlx.In来构建IN查询
 	query := "DELETE FROM backup_records WHERE ID IN (?)"
 	query, args, err := sqlx.In(query, recordIDs)
@@ -0,0 +1,170 @@
+// Package db 实现了 bakctl 的备份文件清单操作功能。
+//
+// 该文件提供了 backup_files 表的读写功能，包括：
+//   - 批量写入某个备份版本的文件清单
+//   - 查询某个备份版本的文件清单
+//   - 按路径模式和变化类型过滤文件清单，查询文件所在的备份版本
+//
+// 文件清单记录了备份时源目录中每个文件的路径、大小、修改时间、权限和内容哈希，
+// 无需解压即可查询某个文件包含在哪些版本中；增量备份通过对比上一个版本的清单来确定需要打包的文件。
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"github.com/jmoiron/sqlx"
+)
+
+// backupFileColumns 查询 backup_files 表时使用的列, 与 types.BackupFile 的字段一一对应
+const backupFileColumns = `ID, version_id, path, size, mod_time, mode, hash, change_time, change_type`
+
+// SQL INSERT 语句，用于 backup_files 表
+const insertBackupFileQuery = `
+	INSERT INTO backup_files (
+		version_id,
+		path,
+		size,
+		mod_time,
+		mode,
+		hash,
+		change_time,
+		change_type
+	) VALUES (
+		:version_id,
+		:path,
+		:size,
+		:mod_time,
+		:mode,
+		:hash,
+		:change_time,
+		:change_type
+	)`
+
+// insertBackupFiles 在事务中批量写入备份版本的文件清单
+//
+// 参数：
+//   - tx：数据库事务
+//   - versionID：备份版本ID
+//   - files：文件清单（为空时不写入）
+//
+// 返回值：
+//   - error：如果写入过程中发生错误，则返回非 nil 错误信息
+func insertBackupFiles(tx *sqlx.Tx, versionID string, files []types.BackupFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareNamed(insertBackupFileQuery)
+	if err != nil {
+		return fmt.Errorf("预编译插入语句失败: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for i := range files {
+		files[i].VersionID = versionID
+		if _, err := stmt.Exec(files[i]); err != nil {
+			return fmt.Errorf("插入文件清单失败 (%s): %w", files[i].Path, err)
+		}
+	}
+
+	return nil
+}
+
+// GetBackupFilesByVersion 获取指定备份版本的文件清单
+//
+// 参数：
+//   - db：数据库连接对象
+//   - versionID：备份版本ID
+//
+// 返回值：
+//   - []types.BackupFile：文件清单（按路径排序）
+//   - error：查询过程中的错误
+func GetBackupFilesByVersion(db *sqlx.DB, versionID string) ([]types.BackupFile, error) {
+	query := `SELECT ` + backupFileColumns + ` FROM backup_files WHERE version_id = ? ORDER BY path`
+
+	var files []types.BackupFile
+	if err := db.Select(&files, query, versionID); err != nil {
+		return nil, fmt.Errorf("查询文件清单失败: %w", err)
+	}
+
+	return files, nil
+}
+
+// GetBackupFilesWithFilter 获取指定备份版本中符合过滤条件的文件清单
+//
+// 参数：
+//   - db：数据库连接对象
+//   - versionID：备份版本ID
+//   - filter：过滤条件
+//
+// 返回值：
+//   - []types.BackupFile：文件清单（按路径排序）
+//   - error：查询过程中的错误
+func GetBackupFilesWithFilter(db *sqlx.DB, versionID string, filter types.BackupFileFilter) ([]types.BackupFile, error) {
+	where, args := backupFileConditions(filter)
+	query := `SELECT ` + backupFileColumns + ` FROM backup_files WHERE version_id = ?` + where + ` ORDER BY path`
+
+	var files []types.BackupFile
+	if err := db.Select(&files, query, append([]interface{}{versionID}, args...)...); err != nil {
+		return nil, fmt.Errorf("查询文件清单失败: %w", err)
+	}
+
+	return files, nil
+}
+
+// GetTaskBackupFilesWithFilter 在任务所有成功备份的文件清单中查询符合过滤条件的文件
+//
+// 用于查询某个文件包含在哪些备份版本中, 结果中的 VersionID 为文件所在的版本。
+//
+// 参数：
+//   - db：数据库连接对象
+//   - taskID：任务ID
+//   - filter：过滤条件
+//
+// 返回值：
+//   - []types.BackupFile：文件清单（按版本从新到旧、路径排序）
+//   - error：查询过程中的错误
+func GetTaskBackupFilesWithFilter(db *sqlx.DB, taskID int64, filter types.BackupFileFilter) ([]types.BackupFile, error) {
+	where, args := backupFileConditions(filter)
+	query := `SELECT f.ID, f.version_id, f.path, f.size, f.mod_time, f.mode, f.hash, f.change_time, f.change_type
+		FROM backup_files f JOIN backup_records r ON r.version_id = f.version_id
+		WHERE r.task_id = ? AND r.status = 1` + where + `
+		ORDER BY r.created_at DESC, r.ID DESC, f.path`
+
+	var files []types.BackupFile
+	if err := db.Select(&files, query, append([]interface{}{taskID}, args...)...); err != nil {
+		return nil, fmt.Errorf("查询文件清单失败: %w", err)
+	}
+
+	return files, nil
+}
+
+// backupFileConditions 将过滤条件转换为 SQL 条件（以 AND 开头）和参数
+//
+// 路径匹配使用 SQLite 的 GLOB（区分大小写），模式中不含 / 时同时匹配任意目录下的文件名。
+func backupFileConditions(filter types.BackupFileFilter) (string, []interface{}) {
+	where := ""
+	var args []interface{}
+
+	if filter.Pattern != "" {
+		if strings.Contains(filter.Pattern, "/") {
+			where += " AND path GLOB ?"
+			args = append(args, filter.Pattern)
+		} else {
+			where += " AND (path GLOB ? OR path GLOB ?)"
+			args = append(args, filter.Pattern, "*/"+filter.Pattern)
+		}
+	}
+	if filter.ChangeType != "" {
+		where += " AND change_type = ?"
+		args = append(args, filter.ChangeType)
+	}
+	if filter.ExcludeDeleted {
+		where += " AND change_type != ?"
+		args = append(args, types.ChangeTypeDeleted)
+	}
+
+	return where, args
+}
@@ -25,6 +25,21 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+// backupTaskColumns 查询 backup_tasks 表时使用的列, 与 types.BackupTask 的字段一一对应
+const backupTaskColumns = `ID, name, retain_count, retain_days, backup_dir, storage_dir, compress,
+	include_rules, exclude_rules, max_file_size, min_file_size, backup_mode, storage_mode,
+	pre_hook, post_hook, on_failure_hook, hook_timeout, timeout, backup_sources, format,
+	compression, store_exts, encryption, key_file, recipients, volume_size, read_limit,
+	hash_algorithm, ignore_files, newer_than, older_than, file_types, file_owner, file_group,
+	exclude_hidden, symlinks, error_policy, full_every`
+
+// backupRecordColumns 查询 backup_records 表时使用的列, 与 types.BackupRecord 的字段一一对应
+const backupRecordColumns = `ID, task_id, task_name, version_id, backup_filename, backup_size,
+	storage_path, status, failure_message, checksum, created_at, parent_version_id, storage_mode,
+	state, encryption, volume_count, started_at, finished_at, duration_ms, file_count,
+	dir_count, source_bytes, skipped_count, host_name, bakctl_version, verified_at,
+	verify_result, verify_message`
+
 // TaskExists 检查指定ID的任务是否存在
 //
 // 参数：
@@ -40,6 +55,24 @@ func TaskExists(db *sqlx.DB, taskID int64) bool {
 	return err == nil && count > 0
 }
 
+// BackupRecordExists 检查指定版本是否已有备份记录（无论成功或失败）
+//
+// 参数：
+//   - db：数据库连接对象
+//   - versionID：版本ID
+//
+// 返回值：
+//   - bool：是否存在备份记录
+//   - error：查询失败时返回错误信息
+func BackupRecordExists(db *sqlx.DB, versionID string) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM backup_records WHERE version_id = ?`
+	if err := db.Get(&count, query, versionID); err != nil {
+		return false, fmt.Errorf("查询备份记录失败: %w", err)
+	}
+	return count > 0, nil
+}
+
 // GetBackupRecordByTaskAndVersion 根据任务ID和版本ID获取备份记录
 //
 // 参数：
@@ -52,8 +85,7 @@ func TaskExists(db *sqlx.DB, taskID int64) bool {
 //   - error：查询过程中的错误
 func GetBackupRecordByTaskAndVersion(db *sqlx.DB, taskID int64, versionID string) (*types.BackupRecord, error) {
 	query := `
-		SELECT ID, task_id, task_name, version_id, backup_filename, backup_size, 
-		       storage_path, status, failure_message, checksum, created_at
+		SELECT ` + backupRecordColumns + `
 		FROM backup_records 
 		WHERE task_id = ? AND version_id = ? AND status = 1
 	`
@@ -72,18 +104,7 @@ func GetBackupRecordByTaskAndVersion(db *sqlx.DB, taskID int64, versionID string
 
 // queryGetAllBackupRecords SQL SELECT 语句，用于查询所有备份记录
 const queryGetAllBackupRecords = `
-	SELECT
-		ID,
-		task_id,
-		task_name,
-		version_id,
-		backup_filename,
-		backup_size,
-		status,
-		failure_message,
-		checksum,
-		storage_path,
-		created_at
+	SELECT ` + backupRecordColumns + `
 	FROM backup_records
 	ORDER BY created_at DESC
 `
@@ -153,8 +174,7 @@ func GetBatchBackupRecords(db *sqlx.DB, tasks []types.BackupTask) (map[int64][]t
 	}
 
 	query := `
-		SELECT ID, task_id, task_name, version_id, backup_filename, backup_size, 
-		       storage_path, status, failure_message, checksum, created_at
+		SELECT ` + backupRecordColumns + `
 		FROM backup_records 
 		WHERE task_id IN (?)
 		ORDER BY task_id, created_at DESC
@@ -240,7 +260,7 @@ func GetTaskNameByID(db *sqlx.DB, taskID int64) (string, error) {
 //   - error：如果获取过程中发生错误，则返回非 nil 错误信息
 func GetTaskByID(db *sqlx.DB, taskID int64) (*types.BackupTask, error) {
 	var task types.BackupTask
-	query := `SELECT ID, name, retain_count, retain_days, backup_dir, storage_dir, compress, include_rules, exclude_rules, max_file_size, min_file_size FROM backup_tasks WHERE ID = ?`
+	query := `SELECT ` + backupTaskColumns + ` FROM backup_tasks WHERE ID = ?`
 	err := db.Get(&task, query, taskID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -266,7 +286,7 @@ func GetTasksByIDs(db *sqlx.DB, taskIDs []int64) ([]types.BackupTask, error) {
 	}
 
 	// 使用sqlx.In展开参数
-	query := `SELECT ID, name, retain_count, retain_days, backup_dir, storage_dir, compress, include_rules, exclude_rules, max_file_size, min_file_size FROM backup_tasks WHERE ID IN (?)`
+	query := `SELECT ` + backupTaskColumns + ` FROM backup_tasks WHERE ID IN (?)`
 	query, args, err := sqlx.In(query, taskIDs)
 	if err != nil {
 		return nil, fmt.Errorf("构建批量查询SQL失败: %w", err)
@@ -297,7 +317,7 @@ func GetTasksByIDs(db *sqlx.DB, taskIDs []int64) ([]types.BackupTask, error) {
 //   - error：查询过程中的错误
 func GetBackupRecordsByTaskIDWithLimit(db *sqlx.DB, taskID int64, limit int) ([]types.BackupRecord, error) {
 	query := `
-		SELECT id, task_id, task_name, version_id, backup_filename, backup_size, storage_path, status, failure_message, checksum, created_at
+		SELECT ` + backupRecordColumns + `
 		FROM backup_records 
 		WHERE task_id = ?
 		ORDER BY created_at DESC
@@ -329,7 +349,7 @@ func GetBackupRecordsByTaskIDWithLimit(db *sqlx.DB, taskID int64, limit int) ([]
 //   - error：如果获取过程中发生错误，则返回非 nil 错误信息
 func GetAllTasks(db *sqlx.DB) ([]types.BackupTask, error) {
 	var tasks []types.BackupTask
-	query := `SELECT ID, name, retain_count, retain_days, backup_dir, storage_dir, compress, include_rules, exclude_rules, max_file_size, min_file_size FROM backup_tasks ORDER BY ID`
+	query := `SELECT ` + backupTaskColumns + ` FROM backup_tasks ORDER BY ID`
 
 	err := db.Select(&tasks, query)
 	if err != nil {
@@ -350,8 +370,7 @@ func GetAllTasks(db *sqlx.DB) ([]types.BackupTask, error) {
 //   - error：查询过程中的错误
 func GetBackupRecordsByTaskID(db *sqlx.DB, taskID int64) ([]types.BackupRecord, error) {
 	query := `
-		SELECT id, task_id, task_name, version_id, backup_filename, backup_size,
-		       storage_path, status, failure_message, checksum, created_at
+		SELECT ` + backupRecordColumns + `
 		FROM backup_records 
 		WHERE task_id = ?
 		ORDER BY created_at DESC
@@ -377,8 +396,7 @@ func GetBackupRecordsByTaskID(db *sqlx.DB, taskID int64) ([]types.BackupRecord,
 //   - error：查询过程中的错误
 func GetLatestBackupRecordByTask(db *sqlx.DB, taskID int64) (*types.BackupRecord, error) {
 	query := `
-		SELECT ID, task_id, task_name, version_id, backup_filename, backup_size, 
-		       storage_path, status, failure_message, checksum, created_at
+		SELECT ` + backupRecordColumns + `
 		FROM backup_records 
 		WHERE task_id = ? AND status = 1
 		ORDER BY created_at DESC
@@ -407,8 +425,7 @@ func GetLatestBackupRecordByTask(db *sqlx.DB, taskID int64) (*types.BackupRecord
 //   - error：查询过程中的错误
 func GetFailedBackupRecords(db *sqlx.DB) ([]types.BackupRecord, error) {
 	query := `
-		SELECT ID, task_id, task_name, version_id, backup_filename, backup_size, 
-		       storage_path, status, failure_message, checksum, created_at
+		SELECT ` + backupRecordColumns + `
 		FROM backup_records 
 		WHERE status = 0
 		ORDER BY created_at DESC
@@ -437,8 +454,7 @@ func GetFailedBackupRecords(db *sqlx.DB) ([]types.BackupRecord, error) {
 //   - error：查询过程中的错误
 func GetBackupRecordsWithFilter(db *sqlx.DB, taskID int, taskName string, onlyFailed bool, limit int) ([]types.BackupRecord, error) {
 	query := `
-		SELECT ID, task_id, task_name, version_id, backup_filename, backup_size, 
-		       storage_path, status, failure_message, checksum, created_at
+		SELECT ` + backupRecordColumns + `
 		FROM backup_records 
 		WHERE 1=1
 	`
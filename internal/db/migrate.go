@@ -0,0 +1,123 @@
+// Package db 实现了 bakctl 的数据库表结构升级功能。
+//
+// 该文件负责将旧版本创建的数据库升级到当前版本的表结构，包括：
+//   - 补齐新版本新增的数据表和索引
+//   - 为已有数据表补齐新版本新增的列
+//
+// 升级操作是幂等的，每次打开已有数据库时都会执行，已存在的表和列会被跳过。
+package db
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// columnMigration 描述一个需要补齐的数据列
+type columnMigration struct {
+	table      string // 表名
+	column     string // 列名
+	definition string // 列定义（类型和默认值）
+}
+
+// columnMigrations 新版本中新增的数据列
+// 新增列时需要同时修改 initDbScript 中的建表语句和此列表
+var columnMigrations = []columnMigration{
+	{table: "backup_tasks", column: "backup_mode", definition: "TEXT DEFAULT 'full'"},
+	{table: "backup_records", column: "parent_version_id", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "storage_mode", definition: "TEXT DEFAULT 'archive'"},
+	{table: "backup_records", column: "storage_mode", definition: "TEXT DEFAULT 'archive'"},
+	{table: "backup_tasks", column: "pre_hook", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "post_hook", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "on_failure_hook", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "hook_timeout", definition: "INTEGER DEFAULT 300"},
+	{table: "backup_tasks", column: "timeout", definition: "INTEGER DEFAULT 0"},
+	{table: "backup_records", column: "state", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "backup_sources", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "format", definition: "TEXT DEFAULT 'zip'"},
+	{table: "backup_tasks", column: "compression", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "store_exts", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "encryption", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "key_file", definition: "TEXT DEFAULT ''"},
+	{table: "backup_records", column: "encryption", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "recipients", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "volume_size", definition: "INTEGER DEFAULT 0"},
+	{table: "backup_records", column: "volume_count", definition: "INTEGER DEFAULT 0"},
+	{table: "backup_tasks", column: "read_limit", definition: "INTEGER DEFAULT 0"},
+	{table: "backup_files", column: "mode", definition: "INTEGER DEFAULT 0"},
+	{table: "backup_records", column: "started_at", definition: "TEXT DEFAULT ''"},
+	{table: "backup_records", column: "finished_at", definition: "TEXT DEFAULT ''"},
+	{table: "backup_records", column: "duration_ms", definition: "INTEGER DEFAULT 0"},
+	{table: "backup_records", column: "file_count", definition: "INTEGER DEFAULT 0"},
+	{table: "backup_records", column: "dir_count", definition: "INTEGER DEFAULT 0"},
+	{table: "backup_records", column: "source_bytes", definition: "INTEGER DEFAULT 0"},
+	{table: "backup_records", column: "skipped_count", definition: "INTEGER DEFAULT 0"},
+	{table: "backup_records", column: "host_name", definition: "TEXT DEFAULT ''"},
+	{table: "backup_records", column: "bakctl_version", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "hash_algorithm", definition: "TEXT DEFAULT 'sha256'"},
+	{table: "backup_records", column: "verified_at", definition: "TEXT DEFAULT ''"},
+	{table: "backup_records", column: "verify_result", definition: "TEXT DEFAULT ''"},
+	{table: "backup_records", column: "verify_message", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "ignore_files", definition: "BOOLEAN DEFAULT FALSE"},
+	{table: "backup_tasks", column: "newer_than", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "older_than", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "file_types", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "file_owner", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "file_group", definition: "TEXT DEFAULT ''"},
+	{table: "backup_tasks", column: "exclude_hidden", definition: "BOOLEAN DEFAULT FALSE"},
+	{table: "backup_tasks", column: "symlinks", definition: "TEXT DEFAULT 'store'"},
+	{table: "backup_tasks", column: "error_policy", definition: "TEXT DEFAULT 'strict'"},
+	{table: "backup_tasks", column: "full_every", definition: "INTEGER DEFAULT 7"},
+	{table: "backup_files", column: "change_time", definition: "INTEGER DEFAULT 0"},
+}
+
+// migrateSchema 升级已有数据库的表结构
+//
+// 参数:
+//   - db: 数据库连接
+//
+// 返回值:
+//   - error: 升级失败时返回错误信息，否则返回 nil
+func migrateSchema(db *sqlx.DB) error {
+	// 1. 补齐新增的数据表和索引（建库脚本中的语句均为 IF NOT EXISTS）
+	if _, err := db.Exec(initDbScript); err != nil {
+		return fmt.Errorf("执行数据库初始化脚本失败: %w", err)
+	}
+
+	// 2. 补齐新增的数据列
+	for _, m := range columnMigrations {
+		exists, err := columnExists(db, m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("为表 %s 添加列 %s 失败: %w", m.table, m.column, err)
+		}
+	}
+
+	return nil
+}
+
+// columnExists 检查指定表中是否存在指定列
+//
+// 参数:
+//   - db: 数据库连接
+//   - table: 表名
+//   - column: 列名
+//
+// 返回值:
+//   - bool: 列是否存在
+//   - error: 查询失败时返回错误信息，否则返回 nil
+func columnExists(db *sqlx.DB, table, column string) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`
+	if err := db.Get(&count, query, table, column); err != nil {
+		return false, fmt.Errorf("查询表 %s 的结构失败: %w", table, err)
+	}
+	return count > 0, nil
+}
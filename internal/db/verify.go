@@ -0,0 +1,62 @@
+// Package db 实现了 bakctl 的备份校验记录操作功能。
+//
+// 该文件提供了 verify 命令使用的数据库操作，包括：
+//   - 查询任务的所有成功备份记录
+//   - 记录备份版本最近一次校验的时间和结果
+package db
+
+import (
+	"fmt"
+
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"github.com/jmoiron/sqlx"
+)
+
+// GetSuccessfulBackupRecordsByTask 根据任务ID获取所有成功的备份记录
+//
+// 参数：
+//   - db：数据库连接对象
+//   - taskID：任务ID
+//
+// 返回值：
+//   - []types.BackupRecord：成功的备份记录列表（按创建时间倒序排列）
+//   - error：查询过程中的错误
+func GetSuccessfulBackupRecordsByTask(db *sqlx.DB, taskID int64) ([]types.BackupRecord, error) {
+	query := `
+		SELECT ` + backupRecordColumns + `
+		FROM backup_records
+		WHERE task_id = ? AND status = 1
+		ORDER BY created_at DESC, ID DESC
+	`
+
+	var records []types.BackupRecord
+	if err := db.Select(&records, query, taskID); err != nil {
+		return nil, fmt.Errorf("查询任务ID %d 的成功备份记录失败: %w", taskID, err)
+	}
+
+	return records, nil
+}
+
+// UpdateBackupRecordVerification 记录备份版本最近一次校验的时间和结果
+//
+// 参数：
+//   - db：数据库连接对象
+//   - recordID：备份记录ID
+//   - result：校验结果（ok/missing/mismatch/corrupt）
+//   - message：校验发现的问题（校验通过时为空）
+//
+// 返回值：
+//   - error：如果更新过程中发生错误，则返回非 nil 错误信息
+func UpdateBackupRecordVerification(db *sqlx.DB, recordID int64, result, message string) error {
+	query := `
+		UPDATE backup_records
+		SET verified_at = CURRENT_TIMESTAMP, verify_result = ?, verify_message = ?
+		WHERE ID = ?
+	`
+
+	if _, err := db.Exec(query, result, message, recordID); err != nil {
+		return fmt.Errorf("记录备份记录 %d 的校验结果失败: %w", recordID, err)
+	}
+
+	return nil
+}
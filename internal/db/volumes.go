@@ -0,0 +1,82 @@
+// Package db 实现了 bakctl 的分卷记录操作功能。
+//
+// 该文件提供了 backup_volumes 表的读写功能，包括：
+//   - 批量写入某个备份版本的分卷信息
+//   - 查询某个备份版本的分卷信息
+//
+// 分卷备份的每个分卷文件单独记录大小和校验码，恢复时逐个校验后再合并。
+package db
+
+import (
+	"fmt"
+
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"github.com/jmoiron/sqlx"
+)
+
+// backupVolumeColumns 查询 backup_volumes 表时使用的列, 与 types.BackupVolume 的字段一一对应
+const backupVolumeColumns = `ID, version_id, seq, size, checksum`
+
+// SQL INSERT 语句，用于 backup_volumes 表
+const insertBackupVolumeQuery = `
+	INSERT INTO backup_volumes (
+		version_id,
+		seq,
+		size,
+		checksum
+	) VALUES (
+		:version_id,
+		:seq,
+		:size,
+		:checksum
+	)`
+
+// insertBackupVolumes 在事务中批量写入备份版本的分卷信息
+//
+// 参数：
+//   - tx：数据库事务
+//   - versionID：备份版本ID
+//   - volumes：分卷信息（为空时不写入）
+//
+// 返回值：
+//   - error：如果写入过程中发生错误，则返回非 nil 错误信息
+func insertBackupVolumes(tx *sqlx.Tx, versionID string, volumes []types.BackupVolume) error {
+	if len(volumes) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareNamed(insertBackupVolumeQuery)
+	if err != nil {
+		return fmt.Errorf("预编译插入语句失败: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for i := range volumes {
+		volumes[i].VersionID = versionID
+		if _, err := stmt.Exec(volumes[i]); err != nil {
+			return fmt.Errorf("插入分卷记录失败 (第 %d 卷): %w", volumes[i].Seq, err)
+		}
+	}
+
+	return nil
+}
+
+// GetBackupVolumesByVersion 获取指定备份版本的分卷信息
+//
+// 参数：
+//   - db：数据库连接对象
+//   - versionID：备份版本ID
+//
+// 返回值：
+//   - []types.BackupVolume：分卷信息（按分卷序号排序）
+//   - error：查询过程中的错误
+func GetBackupVolumesByVersion(db *sqlx.DB, versionID string) ([]types.BackupVolume, error) {
+	query := `SELECT ` + backupVolumeColumns + ` FROM backup_volumes WHERE version_id = ? ORDER BY seq`
+
+	var volumes []types.BackupVolume
+	if err := db.Select(&volumes, query, versionID); err != nil {
+		return nil, fmt.Errorf("查询分卷记录失败: %w", err)
+	}
+
+	return volumes, nil
+}
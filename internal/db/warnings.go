@@ -0,0 +1,81 @@
+// Package db 实现了 bakctl 的备份警告记录操作功能。
+//
+// 该文件提供了 backup_warnings 表的读写功能，包括：
+//   - 批量写入某个备份版本的警告
+//   - 查询某个备份版本的警告
+//
+// 任务的错误处理策略不是 strict 时，无法读取或读取过程中被修改的条目不会中止备份，
+// 而是逐条记录为警告，备份记录的执行状态为 partial。
+package db
+
+import (
+	"fmt"
+
+	"gitee.com/MM-Q/bakctl/internal/types"
+	"github.com/jmoiron/sqlx"
+)
+
+// backupWarningColumns 查询 backup_warnings 表时使用的列, 与 types.BackupWarning 的字段一一对应
+const backupWarningColumns = `ID, version_id, path, message`
+
+// SQL INSERT 语句，用于 backup_warnings 表
+const insertBackupWarningQuery = `
+	INSERT INTO backup_warnings (
+		version_id,
+		path,
+		message
+	) VALUES (
+		:version_id,
+		:path,
+		:message
+	)`
+
+// insertBackupWarnings 在事务中批量写入备份版本的警告
+//
+// 参数：
+//   - tx：数据库事务
+//   - versionID：备份版本ID
+//   - warnings：警告（为空时不写入）
+//
+// 返回值：
+//   - error：如果写入过程中发生错误，则返回非 nil 错误信息
+func insertBackupWarnings(tx *sqlx.Tx, versionID string, warnings []types.BackupWarning) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareNamed(insertBackupWarningQuery)
+	if err != nil {
+		return fmt.Errorf("预编译插入语句失败: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for i := range warnings {
+		warnings[i].VersionID = versionID
+		if _, err := stmt.Exec(warnings[i]); err != nil {
+			return fmt.Errorf("插入备份警告失败 (%s): %w", warnings[i].Path, err)
+		}
+	}
+
+	return nil
+}
+
+// GetBackupWarningsByVersion 获取指定备份版本的警告
+//
+// 参数：
+//   - db：数据库连接对象
+//   - versionID：备份版本ID
+//
+// 返回值：
+//   - []types.BackupWarning：警告列表（按写入顺序排列）
+//   - error：查询过程中的错误
+func GetBackupWarningsByVersion(db *sqlx.DB, versionID string) ([]types.BackupWarning, error) {
+	query := `SELECT ` + backupWarningColumns + ` FROM backup_warnings WHERE version_id = ? ORDER BY ID`
+
+	var warnings []types.BackupWarning
+	if err := db.Select(&warnings, query, versionID); err != nil {
+		return nil, fmt.Errorf("查询备份警告失败: %w", err)
+	}
+
+	return warnings, nil
+}
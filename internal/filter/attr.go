@@ -0,0 +1,235 @@
+package filter
+
+import (
+	"fmt"
+	"io/fs"
+	"os/user"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 文件类型
+const (
+	TypeFile    = "file"    // 普通文件
+	TypeSymlink = "symlink" // 符号链接
+	TypeDevice  = "device"  // 设备文件（块设备和字符设备）
+	TypeSocket  = "socket"  // 套接字
+	TypePipe    = "pipe"    // 命名管道
+)
+
+// TypeList 可以选择备份的文件类型列表
+//
+// 设备文件、套接字和命名管道没有可以备份的内容，遍历时总是被跳过，不能选择。
+var TypeList = []string{TypeFile, TypeSymlink}
+
+// FileType 返回文件模式对应的文件类型（目录和其他类型返回空字符串）
+func FileType(mode fs.FileMode) string {
+	switch {
+	case mode.IsRegular():
+		return TypeFile
+	case mode&fs.ModeSymlink != 0:
+		return TypeSymlink
+	case mode&fs.ModeDevice != 0:
+		return TypeDevice
+	case mode&fs.ModeSocket != 0:
+		return TypeSocket
+	case mode&fs.ModeNamedPipe != 0:
+		return TypePipe
+	default:
+		return ""
+	}
+}
+
+// NormalizeTypes 规范化文件类型列表（转换为小写并去除重复项）
+//
+// 参数:
+//   - types: 文件类型列表
+//
+// 返回值:
+//   - []string: 规范化后的文件类型列表
+//   - error: 包含不支持的文件类型时返回错误信息
+func NormalizeTypes(types []string) ([]string, error) {
+	var result []string
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !slices.Contains(TypeList, t) {
+			return nil, fmt.Errorf("不支持的文件类型: '%s', 可选类型: %s", t, strings.Join(TypeList, ", "))
+		}
+		if !slices.Contains(result, t) {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// 日期格式（按本地时区解析）
+var dateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// ParseTime 解析修改时间条件
+//
+// 支持两种写法：
+//   - 时长: 相对于当前时间，如 30m、12h、7d（天）、2w（周），也可以组合使用，如 1d12h
+//   - 日期: 如 2024-01-02、2024-01-02 15:04:05 或 RFC3339 格式，未指定时区时按本地时区解析
+//
+// 参数:
+//   - value: 时长或日期（为空表示不限制）
+//   - now: 当前时间
+//
+// 返回值:
+//   - time.Time: 对应的时间点（不限制时为零值）
+//   - error: 格式无效时返回错误信息
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	d, err := parseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的时长或日期: '%s', 时长如 12h、7d、2w, 日期如 2024-01-02", value)
+	}
+	return now.Add(-d), nil
+}
+
+// parseDuration 解析时长，在 time.ParseDuration 的基础上支持 d（天）和 w（周）
+func parseDuration(value string) (time.Duration, error) {
+	var total time.Duration
+	rest := value
+	for rest != "" {
+		i := strings.IndexAny(rest, "dw")
+		if i < 0 {
+			d, err := time.ParseDuration(rest)
+			if err != nil {
+				return 0, err
+			}
+			total += d
+			break
+		}
+
+		// 天和周之前的部分必须是整数，之前的单位由 time.ParseDuration 解析
+		j := i
+		for j > 0 && rest[j-1] >= '0' && rest[j-1] <= '9' {
+			j--
+		}
+		n, err := strconv.Atoi(rest[j:i])
+		if err != nil {
+			return 0, fmt.Errorf("无效的时长: %s", value)
+		}
+		if j > 0 {
+			d, err := time.ParseDuration(rest[:j])
+			if err != nil {
+				return 0, err
+			}
+			total += d
+		}
+		unit := 24 * time.Hour
+		if rest[i] == 'w' {
+			unit *= 7
+		}
+		total += time.Duration(n) * unit
+		rest = rest[i+1:]
+	}
+	if total <= 0 {
+		return 0, fmt.Errorf("时长必须大于0: %s", value)
+	}
+	return total, nil
+}
+
+// LookupOwner 将用户名或 UID 转换为 UID
+//
+// 参数:
+//   - owner: 用户名或 UID（为空表示不限制）
+//
+// 返回值:
+//   - string: UID（不限制时为空）
+//   - error: 用户不存在或当前平台不支持按属主过滤时返回错误信息
+func LookupOwner(owner string) (string, error) {
+	owner = strings.TrimSpace(owner)
+	if owner == "" {
+		return "", nil
+	}
+	if !ownerSupported {
+		return "", fmt.Errorf("当前平台不支持按属主过滤")
+	}
+	if _, err := strconv.ParseUint(owner, 10, 32); err == nil {
+		return owner, nil
+	}
+	u, err := user.Lookup(owner)
+	if err != nil {
+		return "", fmt.Errorf("查找用户 '%s' 失败: %w", owner, err)
+	}
+	return u.Uid, nil
+}
+
+// LookupGroup 将组名或 GID 转换为 GID
+//
+// 参数:
+//   - group: 组名或 GID（为空表示不限制）
+//
+// 返回值:
+//   - string: GID（不限制时为空）
+//   - error: 用户组不存在或当前平台不支持按属组过滤时返回错误信息
+func LookupGroup(group string) (string, error) {
+	group = strings.TrimSpace(group)
+	if group == "" {
+		return "", nil
+	}
+	if !ownerSupported {
+		return "", fmt.Errorf("当前平台不支持按属组过滤")
+	}
+	if _, err := strconv.ParseUint(group, 10, 32); err == nil {
+		return group, nil
+	}
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return "", fmt.Errorf("查找用户组 '%s' 失败: %w", group, err)
+	}
+	return g.Gid, nil
+}
+
+// attrReason 返回非目录条目因文件属性被跳过的原因
+func (f *Filter) attrReason(info fs.FileInfo) string {
+	if len(f.Types) > 0 {
+		if t := FileType(info.Mode()); !slices.Contains(f.Types, t) {
+			if t == "" {
+				t = "其他"
+			}
+			return fmt.Sprintf("文件类型 %s 不在 [%s] 中", t, strings.Join(f.Types, ", "))
+		}
+	}
+
+	if f.MinSize > 0 && info.Size() < f.MinSize {
+		return fmt.Sprintf("小于最小文件大小 (%d 字节)", f.MinSize)
+	}
+	if f.MaxSize > 0 && info.Size() > f.MaxSize {
+		return fmt.Sprintf("大于最大文件大小 (%d 字节)", f.MaxSize)
+	}
+
+	const layout = "2006-01-02 15:04:05"
+	if !f.NewerThan.IsZero() && !info.ModTime().After(f.NewerThan) {
+		return fmt.Sprintf("修改时间早于 %s", f.NewerThan.Format(layout))
+	}
+	if !f.OlderThan.IsZero() && !info.ModTime().Before(f.OlderThan) {
+		return fmt.Sprintf("修改时间晚于 %s", f.OlderThan.Format(layout))
+	}
+
+	if f.UID != "" || f.GID != "" {
+		uid, gid, ok := fileOwner(info)
+		if f.UID != "" && (!ok || uid != f.UID) {
+			return fmt.Sprintf("属主不是 UID %s", f.UID)
+		}
+		if f.GID != "" && (!ok || gid != f.GID) {
+			return fmt.Sprintf("属组不是 GID %s", f.GID)
+		}
+	}
+
+	return ""
+}
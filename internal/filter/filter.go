@@ -0,0 +1,298 @@
+// Package filter 实现了 bakctl 的包含/排除规则。
+//
+// 规则可以带有前缀，明确指定匹配方式：
+//   - glob:<模式>: 不含 / 时匹配任意层级的名称；含 / 时匹配任意层级下的相对路径，支持 ** 匹配零个或多个目录层级
+//   - path:<模式>: 锚定到备份源路径，匹配条目相对于备份源路径的完整路径，支持 **
+//   - re:<正则表达式>: 使用 Go 正则表达式匹配条目相对于备份源路径的路径（使用正斜杠分隔）
+//
+// glob: 和 path: 规则以 / 结尾时只匹配目录。匹配某个目录的包含规则同时包含该目录中的所有条目。
+// 不带前缀的规则保持原有的 comprx 匹配语义（同时匹配名称和绝对路径），兼容已有任务。
+package filter
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/comprx"
+)
+
+// 规则类型
+const (
+	KindLegacy = ""     // 不带前缀的规则（comprx 匹配语义）
+	KindGlob   = "glob" // 匹配任意层级的名称或路径
+	KindPath   = "path" // 锚定到备份源路径的路径匹配
+	KindRegexp = "re"   // 正则表达式匹配
+)
+
+// Rule 一条包含或排除规则
+type Rule struct {
+	Text    string                // 规则原文
+	Kind    string                // 规则类型
+	pattern string                // 去除前缀后的匹配模式
+	dirOnly bool                  // 是否只匹配目录（glob:/path: 规则以 / 结尾）
+	re      *regexp.Regexp        // 编译后的正则表达式（re: 规则）
+	legacy  *comprx.FilterOptions // 只包含该规则的过滤器（不带前缀的规则）
+}
+
+// Parse 解析一条规则
+//
+// 参数:
+//   - text: 规则原文
+//
+// 返回值:
+//   - Rule: 解析后的规则
+//   - error: 规则为空或模式无效时返回错误信息
+func Parse(text string) (Rule, error) {
+	if strings.TrimSpace(text) == "" {
+		return Rule{}, fmt.Errorf("规则不能为空")
+	}
+
+	rule := Rule{Text: text}
+	kind, pattern, found := strings.Cut(text, ":")
+	if !found || (kind != KindGlob && kind != KindPath && kind != KindRegexp) {
+		rule.legacy = &comprx.FilterOptions{Exclude: []string{text}}
+		return rule, nil
+	}
+	rule.Kind = kind
+
+	if kind == KindRegexp {
+		if pattern == "" {
+			return Rule{}, fmt.Errorf("规则 '%s' 缺少正则表达式", text)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return Rule{}, fmt.Errorf("规则 '%s' 中的正则表达式无效: %w", text, err)
+		}
+		rule.re = re
+		return rule, nil
+	}
+
+	pattern = strings.ReplaceAll(pattern, "\\", "/")
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+	}
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" {
+		return Rule{}, fmt.Errorf("规则 '%s' 缺少匹配模式", text)
+	}
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return Rule{}, fmt.Errorf("规则 '%s' 中的模式无效: %w", text, err)
+		}
+	}
+	rule.pattern = pattern
+	return rule, nil
+}
+
+// Validate 验证规则列表中的每条规则
+//
+// 参数:
+//   - rules: 规则列表
+//
+// 返回值:
+//   - error: 任一规则无效时返回错误信息
+func Validate(rules []string) error {
+	for _, text := range rules {
+		if _, err := Parse(text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match 判断规则是否匹配条目
+//
+// 参数:
+//   - absPath: 条目的绝对路径（不带前缀的规则使用）
+//   - rel: 条目相对于备份源路径的路径（使用正斜杠分隔，备份源路径本身为空）
+//   - isDir: 是否为目录
+//
+// 返回值:
+//   - bool: 是否匹配
+func (r *Rule) Match(absPath, rel string, isDir bool) bool {
+	if r.legacy != nil {
+		return r.legacy.ShouldSkipByParams(absPath, 0, isDir)
+	}
+	if rel == "" || (r.dirOnly && !isDir) {
+		return false
+	}
+
+	switch r.Kind {
+	case KindRegexp:
+		return r.re.MatchString(rel)
+	case KindPath:
+		return MatchSegments(strings.Split(r.pattern, "/"), strings.Split(rel, "/"))
+	default:
+		if !strings.Contains(r.pattern, "/") {
+			ok, _ := path.Match(r.pattern, path.Base(rel))
+			return ok
+		}
+		return MatchSegments(append([]string{"**"}, strings.Split(r.pattern, "/")...), strings.Split(rel, "/"))
+	}
+}
+
+// matchWithParents 判断规则是否匹配条目或条目所在的任一上级目录
+func (r *Rule) matchWithParents(absPath, rel string, isDir bool) bool {
+	if r.Match(absPath, rel, isDir) {
+		return true
+	}
+	if r.legacy != nil {
+		return false
+	}
+	for dir := path.Dir(rel); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if r.Match("", dir, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchSegments 逐级匹配路径，** 匹配零个或多个目录层级
+//
+// 参数:
+//   - pattern: 按 / 分割的匹配模式
+//   - parts: 按 / 分割的路径
+//
+// 返回值:
+//   - bool: 是否匹配
+func MatchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(parts); i++ {
+				if MatchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// Filter 按文件属性和包含/排除规则过滤条目
+//
+// 判断顺序：先排除隐藏条目，再检查非目录条目的文件类型、大小、修改时间和属主，
+// 然后检查包含规则，最后检查排除规则（与 comprx 一致，文件属性先于规则判断）。
+// 文件属性条件只作用于非目录条目，目录总是被遍历。
+type Filter struct {
+	Include       []Rule    // 包含规则（为空时包含所有条目）
+	Exclude       []Rule    // 排除规则
+	MinSize       int64     // 最小文件大小（字节），0 表示无限制
+	MaxSize       int64     // 最大文件大小（字节），0 表示无限制
+	NewerThan     time.Time // 只包含在此时间之后修改的文件（零值表示不限制）
+	OlderThan     time.Time // 只包含在此时间之前修改的文件（零值表示不限制）
+	Types         []string  // 只包含这些类型的文件（为空表示不限制）
+	UID           string    // 只包含属于此 UID 的文件（为空表示不限制）
+	GID           string    // 只包含属于此 GID 的文件（为空表示不限制）
+	ExcludeHidden bool      // 是否排除名称以 . 开头的文件和目录
+}
+
+// New 解析规则并创建过滤器
+//
+// 参数:
+//   - include: 包含规则
+//   - exclude: 排除规则
+//   - minSize: 最小文件大小（字节）
+//   - maxSize: 最大文件大小（字节）
+//
+// 返回值:
+//   - *Filter: 过滤器
+//   - error: 任一规则无效时返回错误信息
+func New(include, exclude []string, minSize, maxSize int64) (*Filter, error) {
+	f := &Filter{MinSize: minSize, MaxSize: maxSize}
+	for _, text := range include {
+		rule, err := Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("包含规则无效: %w", err)
+		}
+		f.Include = append(f.Include, rule)
+	}
+	for _, text := range exclude {
+		rule, err := Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("排除规则无效: %w", err)
+		}
+		f.Exclude = append(f.Exclude, rule)
+	}
+	return f, nil
+}
+
+// Reason 返回条目被过滤器跳过的原因
+//
+// 带前缀的包含规则无法判断目录中是否有需要包含的条目，因此不会因包含规则跳过目录，
+// 由 Pending 判断目录是否只在其中有被包含的条目时才需要保留。
+//
+// 参数:
+//   - absPath: 条目的绝对路径
+//   - rel: 条目相对于备份源路径的路径（使用正斜杠分隔，备份源路径本身为空）
+//   - info: 条目的文件信息
+//
+// 返回值:
+//   - string: 跳过原因（条目不会被跳过时返回空字符串）
+func (f *Filter) Reason(absPath, rel string, info fs.FileInfo) string {
+	isDir := info.IsDir()
+	if f.ExcludeHidden && rel != "" && strings.HasPrefix(path.Base(rel), ".") {
+		return "隐藏文件或目录"
+	}
+	if !isDir {
+		if reason := f.attrReason(info); reason != "" {
+			return reason
+		}
+	}
+
+	if len(f.Include) > 0 && !f.included(absPath, rel, isDir) && !(isDir && f.hasPatternInclude()) {
+		return "未匹配任何包含规则"
+	}
+
+	for i := range f.Exclude {
+		if f.Exclude[i].Match(absPath, rel, isDir) {
+			return fmt.Sprintf("匹配排除规则 '%s'", f.Exclude[i].Text)
+		}
+	}
+
+	return ""
+}
+
+// Pending 判断未被跳过的目录是否只在其中有被包含的条目时才需要保留
+//
+// 参数:
+//   - absPath: 目录的绝对路径
+//   - rel: 目录相对于备份源路径的路径
+//
+// 返回值:
+//   - bool: 目录未匹配任何包含规则，且存在带前缀的包含规则时返回 true
+func (f *Filter) Pending(absPath, rel string) bool {
+	return f.hasPatternInclude() && !f.included(absPath, rel, true)
+}
+
+// included 判断条目是否匹配任一包含规则
+func (f *Filter) included(absPath, rel string, isDir bool) bool {
+	for i := range f.Include {
+		if f.Include[i].matchWithParents(absPath, rel, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPatternInclude 判断是否存在带前缀的包含规则
+func (f *Filter) hasPatternInclude() bool {
+	for i := range f.Include {
+		if f.Include[i].legacy == nil {
+			return true
+		}
+	}
+	return false
+}
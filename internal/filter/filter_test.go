@@ -0,0 +1,218 @@
+package filter
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchSegments(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"a/b", "a/b", true},
+		{"a/b", "a/b/c", false},
+		{"a/*", "a/b", true},
+		{"a/*", "a/b/c", false},
+		{"**", "a/b/c", true},
+		{"**/c", "c", true},
+		{"**/c", "a/b/c", true},
+		{"**/c", "a/b/c/d", false},
+		{"a/**", "a", true},
+		{"a/**", "a/b/c", true},
+		{"a/**/d", "a/d", true},
+		{"a/**/d", "a/b/c/d", true},
+		{"a/**/d", "a/b/c/e", false},
+		{"a/**/b/**/c", "a/x/b/y/z/c", true},
+		{"a/**/b/**/c", "a/x/y/c", false},
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"a/?/c", "a/b/c", true},
+		{"a/[bc]/d", "a/c/d", true},
+		{"a/[bc]/d", "a/x/d", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"~"+tt.path, func(t *testing.T) {
+			got := MatchSegments(strings.Split(tt.pattern, "/"), strings.Split(tt.path, "/"))
+			if got != tt.want {
+				t.Errorf("MatchSegments(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text    string
+		kind    string
+		dirOnly bool
+		wantErr bool
+	}{
+		{"*.log", KindLegacy, false, false},
+		{"C:\\Users", KindLegacy, false, false},
+		{"glob:*.log", KindGlob, false, false},
+		{"glob:node_modules/", KindGlob, true, false},
+		{"path:src/**/*.go", KindPath, false, false},
+		{"path:build\\out/", KindPath, true, false},
+		{"re:\\.tmp$", KindRegexp, false, false},
+		{"", "", false, true},
+		{"   ", "", false, true},
+		{"glob:", "", false, true},
+		{"path:/", "", false, true},
+		{"glob:[", "", false, true},
+		{"re:", "", false, true},
+		{"re:(", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			rule, err := Parse(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if rule.Kind != tt.kind || rule.dirOnly != tt.dirOnly {
+				t.Errorf("Parse(%q) = kind %q dirOnly %v, want kind %q dirOnly %v", tt.text, rule.Kind, rule.dirOnly, tt.kind, tt.dirOnly)
+			}
+		})
+	}
+}
+
+func TestRuleMatch(t *testing.T) {
+	tests := []struct {
+		rule  string
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		// glob: 不含 / 时匹配任意层级的名称
+		{"glob:*.log", "a.log", false, true},
+		{"glob:*.log", "x/y/a.log", false, true},
+		{"glob:*.log", "a.log.bak", false, false},
+		// glob: 含 / 时匹配任意层级下的相对路径
+		{"glob:logs/*.log", "logs/a.log", false, true},
+		{"glob:logs/*.log", "x/logs/a.log", false, true},
+		{"glob:logs/*.log", "logs/old/a.log", false, false},
+		{"glob:logs/**/*.log", "x/logs/old/a.log", false, true},
+		// glob:/path: 以 / 结尾时只匹配目录
+		{"glob:cache/", "x/cache", true, true},
+		{"glob:cache/", "x/cache", false, false},
+		// path: 锚定到备份源路径
+		{"path:src/*.go", "src/main.go", false, true},
+		{"path:src/*.go", "x/src/main.go", false, false},
+		{"path:src/**", "src/a/b/c.go", false, true},
+		{"path:**/testdata", "a/b/testdata", true, true},
+		{"path:/build/", "build", true, true},
+		{"path:/build/", "build", false, false},
+		// re: 匹配相对路径
+		{"re:\\.tmp$", "a/b.tmp", false, true},
+		{"re:\\.tmp$", "a/b.tmp.bak", false, false},
+		{"re:^src/.*_test\\.go$", "src/pkg/a_test.go", false, true},
+		{"re:^src/.*_test\\.go$", "lib/src/a_test.go", false, false},
+		{"re:(^|/)vendor(/|$)", "a/vendor/b", false, true},
+		// 备份源路径本身不匹配带前缀的规则
+		{"glob:*", "", true, false},
+		{"re:.*", "", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule+"~"+tt.rel, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.rule, err)
+			}
+			if got := rule.Match("/src/"+tt.rel, tt.rel, tt.isDir); got != tt.want {
+				t.Errorf("%q.Match(%q, isDir=%v) = %v, want %v", tt.rule, tt.rel, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
+
+// fileInfo 测试使用的文件信息
+type fileInfo struct {
+	name  string
+	size  int64
+	isDir bool
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) ModTime() time.Time { return time.Time{} }
+func (fi fileInfo) IsDir() bool        { return fi.isDir }
+func (fi fileInfo) Sys() any           { return nil }
+func (fi fileInfo) Mode() fs.FileMode {
+	if fi.isDir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+
+func TestFilterReason(t *testing.T) {
+	f, err := New([]string{"path:src/**/*.go", "glob:docs/"}, []string{"glob:*_test.go", "re:(^|/)vendor/"}, 0, 0)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		rel     string
+		isDir   bool
+		pending bool   // 目录是否只在其中有被包含的条目时才保留（仅目录）
+		reason  string // 跳过原因的前缀（为空表示不跳过）
+	}{
+		{"src/main.go", false, false, ""},
+		{"src/pkg/a.go", false, false, ""},
+		{"src/pkg/a_test.go", false, false, "匹配排除规则 'glob:*_test.go'"},
+		{"src/vendor/x.go", false, false, "匹配排除规则 're:(^|/)vendor/'"},
+		{"README.md", false, false, "未匹配任何包含规则"},
+		{"docs/guide/intro.md", false, false, ""},
+		{"docs", true, false, ""},
+		{"src", true, true, ""},
+		{"other", true, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			info := fileInfo{name: tt.rel[strings.LastIndex(tt.rel, "/")+1:], size: 10, isDir: tt.isDir}
+			reason := f.Reason("/src/"+tt.rel, tt.rel, info)
+			if (tt.reason == "") != (reason == "") || !strings.HasPrefix(reason, tt.reason) {
+				t.Errorf("Reason(%q) = %q, want %q", tt.rel, reason, tt.reason)
+			}
+			if tt.isDir {
+				if got := f.Pending("/src/"+tt.rel, tt.rel); got != tt.pending {
+					t.Errorf("Pending(%q) = %v, want %v", tt.rel, got, tt.pending)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeTypes(t *testing.T) {
+	tests := []struct {
+		types   []string
+		want    []string
+		wantErr bool
+	}{
+		{[]string{"File", " symlink ", "file"}, []string{"file", "symlink"}, false},
+		{[]string{"device"}, nil, true},
+		{[]string{"socket"}, nil, true},
+		{[]string{"pipe"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.types, ","), func(t *testing.T) {
+			got, err := NormalizeTypes(tt.types)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeTypes(%q) error = %v, wantErr %v", tt.types, err, tt.wantErr)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("NormalizeTypes(%q) = %q, want %q", tt.types, got, tt.want)
+			}
+		})
+	}
+}
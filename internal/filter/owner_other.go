@@ -0,0 +1,13 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package filter
+
+import "io/fs"
+
+// ownerSupported 当前平台是否支持按属主和属组过滤
+const ownerSupported = false
+
+// fileOwner 当前平台无法获取文件属主
+func fileOwner(info fs.FileInfo) (uid, gid string, ok bool) {
+	return "", "", false
+}
@@ -0,0 +1,21 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package filter
+
+import (
+	"io/fs"
+	"strconv"
+	"syscall"
+)
+
+// ownerSupported 当前平台是否支持按属主和属组过滤
+const ownerSupported = true
+
+// fileOwner 返回文件属主的 UID 和 GID
+func fileOwner(info fs.FileInfo) (uid, gid string, ok bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", "", false
+	}
+	return strconv.FormatUint(uint64(st.Uid), 10), strconv.FormatUint(uint64(st.Gid), 10), true
+}
@@ -0,0 +1,181 @@
+// Package journal 实现了 bakctl 的备份进度日志。
+//
+// 每个正在执行的备份在数据目录的 journal 目录下有一个日志文件，记录备份的版本ID、
+// 临时文件路径、最终文件路径以及执行备份的进程ID。备份结束（无论成功失败）并记录到
+// 数据库后删除日志文件。
+//
+// 如果进程在备份过程中崩溃或被强制终止，日志文件会被保留下来。下次执行备份前，
+// 通过 Abandoned 找出进程已经退出的日志，清理遗留的不完整备份文件并记录为失败。
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/bakctl/internal/utils"
+)
+
+// 日志目录和文件
+const (
+	DirName = "journal" // 日志目录名（位于数据目录下）
+	fileExt = ".json"   // 日志文件扩展名
+	tmpExt  = ".tmp"    // 写入中的日志临时文件扩展名
+)
+
+// Entry 一个正在执行的备份
+type Entry struct {
+	TaskID      int64     `json:"task_id"`      // 任务ID
+	TaskName    string    `json:"task_name"`    // 任务名称
+	VersionID   string    `json:"version_id"`   // 版本ID
+	TempPath    string    `json:"temp_path"`    // 写入中的临时文件路径
+	BackupPath  string    `json:"backup_path"`  // 最终的备份文件路径
+	StorageMode string    `json:"storage_mode"` // 存储模式
+	PID         int       `json:"pid"`          // 执行备份的进程ID
+	StartedAt   time.Time `json:"started_at"`   // 开始时间
+}
+
+// Journal 备份进度日志
+type Journal struct {
+	dir string // 日志目录
+}
+
+// Open 打开数据目录下的日志目录，目录不存在时自动创建
+//
+// 参数:
+//   - dataDir: 数据目录
+//
+// 返回值:
+//   - *Journal: 备份进度日志
+//   - error: 创建目录失败时返回错误信息
+func Open(dataDir string) (*Journal, error) {
+	dir := filepath.Join(dataDir, DirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建备份日志目录失败: %w", err)
+	}
+	return &Journal{dir: dir}, nil
+}
+
+// path 返回版本对应的日志文件路径
+func (j *Journal) path(versionID string) string {
+	return filepath.Join(j.dir, versionID+fileExt)
+}
+
+// Begin 记录一个开始执行的备份
+//
+// 参数:
+//   - entry: 备份信息（PID 和 StartedAt 为空时自动填充）
+//
+// 返回值:
+//   - error: 写入日志失败时返回错误信息
+func (j *Journal) Begin(entry Entry) error {
+	if entry.PID == 0 {
+		entry.PID = os.Getpid()
+	}
+	if entry.StartedAt.IsZero() {
+		entry.StartedAt = time.Now()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("编码备份日志失败: %w", err)
+	}
+
+	// 先写入临时文件再重命名，避免崩溃时留下无法解析的日志
+	// 临时文件名包含进程ID，其他进程只清理进程已经退出的临时文件
+	path := j.path(entry.VersionID)
+	tmp := fmt.Sprintf("%s.%d%s", path, os.Getpid(), tmpExt)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("写入备份日志失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("写入备份日志失败: %w", err)
+	}
+
+	return nil
+}
+
+// End 删除已结束的备份的日志
+//
+// 参数:
+//   - versionID: 版本ID
+//
+// 返回值:
+//   - error: 删除日志失败时返回错误信息
+func (j *Journal) End(versionID string) error {
+	if err := os.Remove(j.path(versionID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除备份日志失败: %w", err)
+	}
+	return nil
+}
+
+// Abandoned 返回执行进程已经退出的备份（即被中断的备份）
+//
+// 无法解析的日志文件会被直接删除。
+//
+// 返回值:
+//   - []Entry: 被中断的备份列表
+//   - error: 读取日志目录失败时返回错误信息
+func (j *Journal) Abandoned() ([]Entry, error) {
+	files, err := os.ReadDir(j.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取备份日志目录失败: %w", err)
+	}
+
+	var entries []Entry
+	for _, f := range files {
+		name := f.Name()
+		path := filepath.Join(j.dir, name)
+
+		// 清理写入日志时中断留下的临时文件（写入的进程仍在运行时保留）
+		if strings.HasSuffix(name, tmpExt) {
+			if pid, ok := tmpOwner(name); !ok || (pid != os.Getpid() && !utils.ProcessAlive(pid)) {
+				_ = os.Remove(path)
+			}
+			continue
+		}
+		if f.IsDir() || filepath.Ext(name) != fileExt {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil || entry.VersionID == "" {
+			_ = os.Remove(path)
+			continue
+		}
+
+		if entry.PID != os.Getpid() && utils.ProcessAlive(entry.PID) {
+			continue // 其他进程正在执行该备份
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// tmpOwner 从日志临时文件名（<版本ID>.json.<进程ID>.tmp）中解析写入的进程ID
+//
+// 参数:
+//   - name: 临时文件名
+//
+// 返回值:
+//   - int: 写入临时文件的进程ID
+//   - bool: 文件名中是否包含有效的进程ID
+func tmpOwner(name string) (int, bool) {
+	name = strings.TrimSuffix(name, tmpExt)
+	pid, err := strconv.Atoi(strings.TrimPrefix(filepath.Ext(name), "."))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	return pid, true
+}
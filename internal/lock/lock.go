@@ -0,0 +1,297 @@
+// Package lock 实现了 bakctl 的跨进程锁。
+//
+// 锁文件位于数据目录的 locks 目录下，通过操作系统的文件锁（Unix 下为 flock，
+// Windows 下为 LockFileEx）实现互斥，进程退出时文件锁由操作系统自动释放。
+// 获取锁后将持有者信息（进程ID、主机名、命令行、获取时间）写入锁文件，
+// 锁被占用时据此给出持有者信息。
+//
+// 文件系统不支持文件锁时（如部分网络文件系统），退化为以 O_CREATE|O_EXCL 原子创建的标记文件
+// （锁文件路径加 .held 后缀）互斥，释放锁时删除标记文件。持有者异常退出后标记文件会残留，
+// 为避免多个进程同时清理后都认为自己获取到了锁，不会自动清理，而是报错提示手动删除。
+//
+// 包含两类锁：
+//   - 任务锁：同一个任务同一时间只能被一个进程执行
+//   - 任务目录锁：修改任务配置和数据库表结构的命令互斥执行
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/bakctl/internal/utils"
+	"gitee.com/MM-Q/colorlib"
+)
+
+// 锁目录和文件
+const (
+	DirName     = "locks"   // 锁目录名（位于数据目录下）
+	catalogName = "catalog" // 任务目录锁文件名
+	fileExt     = ".lock"   // 锁文件扩展名
+	markerExt   = ".held"   // 不支持文件锁时使用的标记文件后缀
+)
+
+// pollInterval 等待锁释放时的检查间隔
+const pollInterval = 500 * time.Millisecond
+
+// Holder 锁的持有者
+type Holder struct {
+	PID       int       `json:"pid"`        // 进程ID
+	Host      string    `json:"host"`       // 主机名
+	Command   string    `json:"command"`    // 命令行
+	StartedAt time.Time `json:"started_at"` // 获取锁的时间
+}
+
+// String 返回持有者的描述
+func (h Holder) String() string {
+	if h.PID == 0 {
+		return "未知进程"
+	}
+	s := fmt.Sprintf("进程 %d", h.PID)
+	if h.Host != "" {
+		s += "@" + h.Host
+	}
+	if h.Command != "" {
+		s += fmt.Sprintf(" (%s)", h.Command)
+	}
+	if !h.StartedAt.IsZero() {
+		s += ", 开始于 " + h.StartedAt.Format("2006-01-02 15:04:05")
+	}
+	return s
+}
+
+// alive 判断持有者进程是否仍在运行
+// 其他主机上的进程无法检测，视为仍在运行
+func (h Holder) alive() bool {
+	if h.PID == 0 {
+		return false
+	}
+	if host, _ := os.Hostname(); h.Host != "" && h.Host != host {
+		return true
+	}
+	return h.PID == os.Getpid() || utils.ProcessAlive(h.PID)
+}
+
+// HeldError 锁已被其他进程持有
+type HeldError struct {
+	What   string // 被锁定的对象
+	Holder Holder // 锁的持有者
+}
+
+// Error 实现 error 接口
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("%s正在被其他进程使用: %s", e.What, e.Holder)
+}
+
+// Lock 已获取的锁
+type Lock struct {
+	f      *os.File // 锁文件（使用标记文件时为标记文件）
+	marker string   // 标记文件路径（使用文件锁时为空）
+}
+
+// TaskPath 返回任务锁文件路径
+//
+// 参数:
+//   - dataDir: 数据目录
+//   - taskID: 任务ID
+//
+// 返回值:
+//   - string: 锁文件路径
+func TaskPath(dataDir string, taskID int64) string {
+	return filepath.Join(dataDir, DirName, fmt.Sprintf("task-%d%s", taskID, fileExt))
+}
+
+// CatalogPath 返回任务目录锁文件路径
+//
+// 参数:
+//   - dataDir: 数据目录
+//
+// 返回值:
+//   - string: 锁文件路径
+func CatalogPath(dataDir string) string {
+	return filepath.Join(dataDir, DirName, catalogName+fileExt)
+}
+
+// TryAcquire 尝试获取锁，锁被占用时立即返回
+//
+// 参数:
+//   - path: 锁文件路径
+//   - what: 被锁定的对象（用于错误信息）
+//
+// 返回值:
+//   - *Lock: 获取到的锁
+//   - error: 锁被占用时返回 *HeldError，其他失败时返回错误信息
+func TryAcquire(path, what string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建锁目录失败: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开锁文件失败: %w", err)
+	}
+
+	locked, err := tryLock(f)
+	if err != nil {
+		// 文件系统不支持文件锁，改用标记文件互斥
+		_ = f.Close()
+		return acquireMarker(path, what)
+	}
+	if !locked {
+		holder, _ := readHolder(f)
+		_ = f.Close()
+		return nil, &HeldError{What: what, Holder: holder}
+	}
+
+	l := &Lock{f: f}
+	if err := l.writeHolder(); err != nil {
+		_ = l.Release()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Acquire 获取锁，锁被占用时等待其释放
+//
+// 参数:
+//   - ctx: 上下文，被取消时停止等待
+//   - path: 锁文件路径
+//   - what: 被锁定的对象（用于错误信息）
+//   - onWait: 开始等待时调用，参数为当前的持有者（可为 nil）
+//
+// 返回值:
+//   - *Lock: 获取到的锁
+//   - error: 获取失败或等待被取消时返回错误信息
+func Acquire(ctx context.Context, path, what string, onWait func(Holder)) (*Lock, error) {
+	waiting := false
+	for {
+		l, err := TryAcquire(path, what)
+		var held *HeldError
+		if !errors.As(err, &held) {
+			return l, err
+		}
+
+		if !waiting && onWait != nil {
+			onWait(held.Holder)
+		}
+		waiting = true
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("等待%s的锁被取消: %w", what, ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// AcquireCatalog 获取任务目录锁，锁被占用时等待其释放
+//
+// 参数:
+//   - dataDir: 数据目录
+//   - cl: 颜色库对象（用于输出等待信息）
+//
+// 返回值:
+//   - *Lock: 获取到的锁
+//   - error: 获取失败时返回错误信息
+func AcquireCatalog(dataDir string, cl *colorlib.ColorLib) (*Lock, error) {
+	return Acquire(context.Background(), CatalogPath(dataDir), "任务目录", func(h Holder) {
+		cl.Yellowf("任务目录正在被其他进程修改: %s, 等待其结束...\n", h)
+	})
+}
+
+// Release 释放锁
+//
+// 返回值:
+//   - error: 释放失败时返回错误信息
+func (l *Lock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+
+	// 清空持有者信息，不删除锁文件（删除后其他进程可能锁住不同的文件）
+	_ = l.f.Truncate(0)
+	_ = unlock(l.f)
+	err := l.f.Close()
+	l.f = nil
+	if l.marker != "" {
+		if removeErr := os.Remove(l.marker); removeErr != nil && err == nil {
+			err = removeErr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("释放锁失败: %w", err)
+	}
+	return nil
+}
+
+// acquireMarker 在不支持文件锁的文件系统上通过标记文件获取锁
+//
+// 标记文件以 O_CREATE|O_EXCL 创建，同一时间只有一个进程能创建成功。
+// 标记文件的持有者进程已经退出时不自动接管（多个进程可能同时判断为失效并各自重新创建），
+// 返回提示手动删除标记文件的错误。
+func acquireMarker(path, what string) (*Lock, error) {
+	marker := path + markerExt
+	f, err := os.OpenFile(marker, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err == nil {
+		l := &Lock{f: f, marker: marker}
+		if err := l.writeHolder(); err != nil {
+			_ = l.Release()
+			return nil, err
+		}
+		return l, nil
+	}
+	if !os.IsExist(err) {
+		return nil, fmt.Errorf("创建锁标记文件失败: %w", err)
+	}
+
+	var holder Holder
+	if mf, err := os.Open(marker); err == nil {
+		holder, _ = readHolder(mf)
+		_ = mf.Close()
+	}
+	if holder.PID != 0 && !holder.alive() {
+		return nil, fmt.Errorf("%s的锁标记文件 %s 由已退出的 %s 创建 (文件系统不支持文件锁, 无法自动清理), 确认没有其他进程在使用后请手动删除该文件", what, marker, holder)
+	}
+	return nil, &HeldError{What: what, Holder: holder}
+}
+
+// writeHolder 将当前进程作为持有者写入锁文件
+func (l *Lock) writeHolder() error {
+	host, _ := os.Hostname()
+	data, err := json.Marshal(Holder{
+		PID:       os.Getpid(),
+		Host:      host,
+		Command:   filepath.Base(os.Args[0]) + " " + strings.Join(os.Args[1:], " "),
+		StartedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("编码锁持有者信息失败: %w", err)
+	}
+
+	if err := l.f.Truncate(0); err != nil {
+		return fmt.Errorf("写入锁文件失败: %w", err)
+	}
+	if _, err := l.f.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("写入锁文件失败: %w", err)
+	}
+	return nil
+}
+
+// readHolder 读取锁文件中的持有者信息
+func readHolder(f *os.File) (Holder, bool) {
+	var holder Holder
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, 1<<16))
+	if err != nil || len(data) == 0 {
+		return holder, false
+	}
+	if err := json.Unmarshal(data, &holder); err != nil {
+		return holder, false
+	}
+	return holder, true
+}
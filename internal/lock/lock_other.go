@@ -0,0 +1,18 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package lock
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLock 当前平台不支持文件锁，由调用方根据持有者进程是否存活判断
+func tryLock(f *os.File) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+// unlock 当前平台不支持文件锁，无需释放
+func unlock(f *os.File) error {
+	return nil
+}
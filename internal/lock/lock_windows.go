@@ -0,0 +1,42 @@
+//go:build windows
+
+package lock
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockOffset 加锁区域的起始位置
+// Windows 的文件锁会阻止其他进程读取被锁定的区域，因此锁定文件内容之外的区域，
+// 保证其他进程仍能读取持有者信息
+const lockOffset = 1 << 32
+
+// lockRegion 返回加锁区域
+func lockRegion() *windows.Overlapped {
+	return &windows.Overlapped{Offset: uint32(lockOffset & 0xffffffff), OffsetHigh: uint32(lockOffset >> 32)}
+}
+
+// tryLock 以非阻塞方式对文件加排他锁
+//
+// 返回值:
+//   - bool: 是否获取到锁（被其他进程占用时为 false）
+//   - error: 文件系统不支持文件锁等其他错误
+func tryLock(f *os.File) (bool, error) {
+	err := windows.LockFileEx(windows.Handle(f.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, lockRegion())
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	return false, err
+}
+
+// unlock 释放文件锁
+func unlock(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, lockRegion())
+}
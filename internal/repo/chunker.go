@@ -0,0 +1,136 @@
+// Package repo 实现了 bakctl 的内容寻址数据块仓库。
+//
+// 仓库存储模式下，源文件被切分为基于内容的数据块（Content-Defined Chunking），
+// 每个数据块按照其 sha256 哈希只存储一次，多个备份版本之间相同的数据块只占用一份空间。
+//
+// 仓库目录结构：
+//
+//	repo/
+//	├── chunks/               # 数据块目录，按哈希前两位分组
+//	│   └── ab/
+//	│       └── ab12...ef     # 数据块文件，文件名为数据块内容的 sha256
+//	└── snapshots/            # 快照目录，每个备份版本一个快照文件
+//	    └── task_20250903_143022.json
+//
+// 快照文件记录了备份时源目录的完整文件树，以及每个文件由哪些数据块按顺序组成。
+package repo
+
+import (
+	"io"
+)
+
+// 数据块大小参数
+const (
+	MinChunkSize = 256 << 10 // 最小数据块大小（256KB）
+	MaxChunkSize = 4 << 20   // 最大数据块大小（4MB）
+
+	// chunkMask 分块掩码，决定平均数据块大小（约1MB）
+	chunkMask = (1 << 20) - 1
+)
+
+// gearTable Gear 滚动哈希使用的随机表
+// 由固定种子生成，修改种子会导致新旧备份之间无法共享数据块
+var gearTable = func() [256]uint64 {
+	var table [256]uint64
+	seed := uint64(0x62616b63746c) // "bakctl"
+	for i := range table {
+		// splitmix64
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
+
+// Chunker 基于 Gear 滚动哈希的内容定义分块器
+//
+// 数据块边界由内容决定，文件中间插入或删除数据只会影响附近的数据块，
+// 其余数据块仍然可以与之前的版本共享。
+type Chunker struct {
+	r     io.Reader // 数据源
+	buf   []byte    // 读缓冲区
+	start int       // 未处理数据的起始位置
+	end   int       // 未处理数据的结束位置
+	eof   bool      // 数据源是否已读完
+}
+
+// NewChunker 创建分块器
+//
+// 参数:
+//   - r: 数据源
+//
+// 返回值:
+//   - *Chunker: 分块器
+func NewChunker(r io.Reader) *Chunker {
+	return &Chunker{r: r, buf: make([]byte, 2*MaxChunkSize)}
+}
+
+// Next 返回下一个数据块
+//
+// 返回的切片在下一次调用 Next 之前有效，调用者需要在此之前使用完毕或自行复制。
+//
+// 返回值:
+//   - []byte: 数据块内容
+//   - error: 数据读完时返回 io.EOF，读取失败时返回错误信息
+func (c *Chunker) Next() ([]byte, error) {
+	if err := c.fill(); err != nil {
+		return nil, err
+	}
+
+	n := c.end - c.start
+	if n == 0 {
+		return nil, io.EOF
+	}
+
+	// 剩余数据不足最小数据块时直接作为最后一个数据块
+	if n <= MinChunkSize {
+		chunk := c.buf[c.start:c.end]
+		c.start = c.end
+		return chunk, nil
+	}
+
+	data := c.buf[c.start:c.end]
+	limit := min(n, MaxChunkSize)
+	cut := limit
+
+	var h uint64
+	for i := 0; i < limit; i++ {
+		h = (h << 1) + gearTable[data[i]]
+		if i >= MinChunkSize && h&chunkMask == 0 {
+			cut = i + 1
+			break
+		}
+	}
+
+	chunk := data[:cut]
+	c.start += cut
+	return chunk, nil
+}
+
+// fill 保证缓冲区中至少有一个最大数据块的数据（数据源未读完时）
+func (c *Chunker) fill() error {
+	if c.eof || c.end-c.start >= MaxChunkSize {
+		return nil
+	}
+
+	// 将未处理的数据移动到缓冲区开头
+	copy(c.buf, c.buf[c.start:c.end])
+	c.end -= c.start
+	c.start = 0
+
+	for c.end < len(c.buf) {
+		n, err := c.r.Read(c.buf[c.end:])
+		c.end += n
+		if err == io.EOF {
+			c.eof = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
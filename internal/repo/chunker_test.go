@@ -0,0 +1,134 @@
+package repo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// randomData 返回固定种子生成的随机数据
+func randomData(seed int64, n int) []byte {
+	data := make([]byte, n)
+	rand.New(rand.NewSource(seed)).Read(data)
+	return data
+}
+
+// smallReader 每次最多返回 n 字节，模拟短读
+type smallReader struct {
+	r io.Reader
+	n int
+}
+
+// Read 实现 io.Reader 接口
+func (s *smallReader) Read(p []byte) (int, error) {
+	if len(p) > s.n {
+		p = p[:s.n]
+	}
+	return s.r.Read(p)
+}
+
+// chunkAll 读取所有数据块（复制后返回）
+func chunkAll(t *testing.T, r io.Reader) [][]byte {
+	t.Helper()
+	var chunks [][]byte
+	c := NewChunker(r)
+	for {
+		data, err := c.Next()
+		if err == io.EOF {
+			return chunks
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		chunks = append(chunks, bytes.Clone(data))
+	}
+}
+
+func TestChunkerBoundaries(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []byte
+		wantChunks int // 期望的数据块数（-1 表示不检查）
+	}{
+		{"空数据", nil, 0},
+		{"单个字节", []byte{1}, 1},
+		{"恰好最小数据块", randomData(1, MinChunkSize), 1},
+		{"超过最小数据块", randomData(2, MinChunkSize+1), -1},
+		{"全零数据按最大数据块切分", make([]byte, 3*MaxChunkSize+10), 4},
+		{"随机数据", randomData(3, 12<<20), -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := chunkAll(t, bytes.NewReader(tt.data))
+			if tt.wantChunks >= 0 && len(chunks) != tt.wantChunks {
+				t.Fatalf("数据块数 = %d, want %d", len(chunks), tt.wantChunks)
+			}
+
+			for i, chunk := range chunks {
+				if len(chunk) > MaxChunkSize {
+					t.Errorf("数据块 %d 大小 %d 超过最大数据块", i, len(chunk))
+				}
+				if i < len(chunks)-1 && len(chunk) <= MinChunkSize {
+					t.Errorf("数据块 %d 大小 %d 不超过最小数据块（只有最后一块可以更小）", i, len(chunk))
+				}
+			}
+			if got := bytes.Join(chunks, nil); !bytes.Equal(got, tt.data) {
+				t.Fatal("拼接后的数据块与原始数据不一致")
+			}
+		})
+	}
+}
+
+func TestChunkerShortReads(t *testing.T) {
+	data := randomData(4, 10<<20)
+	want := chunkAll(t, bytes.NewReader(data))
+	got := chunkAll(t, &smallReader{r: bytes.NewReader(data), n: 4093})
+
+	if len(got) != len(want) {
+		t.Fatalf("短读时数据块数 = %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !bytes.Equal(got[i], want[i]) {
+			t.Fatalf("短读时数据块 %d 的边界不同", i)
+		}
+	}
+}
+
+func TestChunkerDedupAfterEdit(t *testing.T) {
+	data := randomData(5, 16<<20)
+
+	tests := []struct {
+		name   string
+		edited []byte
+	}{
+		{"开头插入数据", append([]byte("inserted at the beginning"), data...)},
+		{"中间插入数据", bytes.Join([][]byte{data[:8<<20], []byte("inserted"), data[8<<20:]}, nil)},
+		{"中间删除数据", append(bytes.Clone(data[:8<<20]), data[8<<20+1000:]...)},
+		{"末尾追加数据", append(bytes.Clone(data), randomData(6, 1<<20)...)},
+	}
+
+	original := make(map[[sha256.Size]byte]bool)
+	chunks := chunkAll(t, bytes.NewReader(data))
+	for _, chunk := range chunks {
+		original[sha256.Sum256(chunk)] = true
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edited := chunkAll(t, bytes.NewReader(tt.edited))
+			shared := 0
+			for _, chunk := range edited {
+				if original[sha256.Sum256(chunk)] {
+					shared++
+				}
+			}
+			// 修改只影响附近的数据块（最多两个），其余数据块与原数据共享
+			if shared < len(chunks)-2 {
+				t.Errorf("共享的数据块数 = %d, 原数据共 %d 块", shared, len(chunks))
+			}
+		})
+	}
+}
@@ -0,0 +1,665 @@
+package repo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/bakctl/internal/archive"
+	"gitee.com/MM-Q/bakctl/internal/utils"
+)
+
+// 仓库内的目录和文件名
+const (
+	DirName         = "repo"      // 仓库目录名（位于任务存储目录下）
+	chunksDirName   = "chunks"    // 数据块目录名
+	snapshotDirName = "snapshots" // 快照目录名
+	SnapshotExt     = ".json"     // 快照文件扩展名
+	tempFileSuffix  = ".tmp"      // 写入中的临时文件后缀
+	lockFileName    = "repo.lock" // 仓库锁文件名
+)
+
+// 快照条目类型
+const (
+	EntryTypeFile     = "file"     // 普通文件
+	EntryTypeDir      = "dir"      // 目录
+	EntryTypeSymlink  = "symlink"  // 符号链接
+	EntryTypeHardlink = "hardlink" // 硬链接（与快照中之前的文件为同一文件）
+)
+
+// Snapshot 快照，记录一个备份版本的完整文件树
+type Snapshot struct {
+	TaskName  string          `json:"task_name"`  // 任务名称
+	VersionID string          `json:"version_id"` // 备份版本ID
+	CreatedAt time.Time       `json:"created_at"` // 创建时间
+	Entries   []SnapshotEntry `json:"entries"`    // 文件树条目（目录在其子条目之前）
+}
+
+// SnapshotEntry 快照中的文件树条目
+type SnapshotEntry struct {
+	Path    string            `json:"path"`             // 相对路径（使用正斜杠分隔，保留顶层目录名）
+	Type    string            `json:"type"`             // 条目类型（file/dir/symlink/hardlink）
+	Mode    uint32            `json:"mode"`             // 文件权限（包括 setuid、setgid 和粘滞位）
+	Size    int64             `json:"size"`             // 文件大小（字节）
+	ModTime int64             `json:"mod_time"`         // 修改时间（Unix纳秒时间戳）
+	ATime   int64             `json:"atime,omitempty"`  // 访问时间（Unix纳秒时间戳，早期版本的快照中没有）
+	UID     *int              `json:"uid,omitempty"`    // 属主 UID（无法获取时为空）
+	GID     *int              `json:"gid,omitempty"`    // 属组 GID（无法获取时为空）
+	Xattrs  map[string][]byte `json:"xattrs,omitempty"` // 扩展属性
+	Hash    string            `json:"hash,omitempty"`   // 文件内容的 sha256（普通文件和硬链接）
+	Target  string            `json:"target,omitempty"` // 链接目标（符号链接为链接内容，硬链接为同一文件第一次出现的路径）
+	Chunks  []string          `json:"chunks,omitempty"` // 组成文件内容的数据块ID（仅普通文件）
+}
+
+// metadata 返回条目记录的元数据
+func (e SnapshotEntry) metadata() archive.Metadata {
+	m := archive.Metadata{
+		Mode:    fs.FileMode(e.Mode),
+		UID:     -1,
+		GID:     -1,
+		ModTime: time.Unix(0, e.ModTime),
+		Xattrs:  e.Xattrs,
+	}
+	if e.ATime != 0 {
+		m.ATime = time.Unix(0, e.ATime)
+	}
+	if e.UID != nil && e.GID != nil {
+		m.UID, m.GID = *e.UID, *e.GID
+	}
+	return m
+}
+
+// setMetadata 记录条目的元数据
+func (e *SnapshotEntry) setMetadata(m archive.Metadata) {
+	e.Mode = uint32(m.Mode)
+	e.ModTime = m.ModTime.UnixNano()
+	e.Xattrs = m.Xattrs
+	if !m.ATime.IsZero() {
+		e.ATime = m.ATime.UnixNano()
+	}
+	if m.UID >= 0 && m.GID >= 0 {
+		e.UID, e.GID = &m.UID, &m.GID
+	}
+}
+
+// BackupStats 写入快照时的统计信息
+type BackupStats struct {
+	Files       int   // 文件数
+	TotalBytes  int64 // 源文件总大小（字节）
+	TotalChunks int   // 引用的数据块总数
+	NewChunks   int   // 新写入的数据块数
+	NewBytes    int64 // 新写入的数据块大小（字节）
+}
+
+// Repository 数据块仓库
+type Repository struct {
+	root string // 仓库根目录
+}
+
+// Root 返回任务存储目录下的仓库根目录
+//
+// 参数:
+//   - storageDir: 任务存储目录
+//
+// 返回值:
+//   - string: 仓库根目录
+func Root(storageDir string) string {
+	return filepath.Join(storageDir, DirName)
+}
+
+// SnapshotDir 返回任务存储目录下的快照目录
+//
+// 参数:
+//   - storageDir: 任务存储目录
+//
+// 返回值:
+//   - string: 快照目录
+func SnapshotDir(storageDir string) string {
+	return filepath.Join(Root(storageDir), snapshotDirName)
+}
+
+// LockPath 返回仓库的锁文件路径
+//
+// 写入和回收数据块时需要持有该锁，避免回收时删除其他进程刚写入、尚未被快照引用的数据块。
+//
+// 参数:
+//   - storageDir: 任务存储目录
+//
+// 返回值:
+//   - string: 锁文件路径
+func LockPath(storageDir string) string {
+	return filepath.Join(Root(storageDir), lockFileName)
+}
+
+// Open 打开仓库，目录不存在时自动创建
+//
+// 参数:
+//   - root: 仓库根目录
+//
+// 返回值:
+//   - *Repository: 仓库
+//   - error: 创建目录失败时返回错误信息
+func Open(root string) (*Repository, error) {
+	for _, dir := range []string{filepath.Join(root, chunksDirName), filepath.Join(root, snapshotDirName)} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("创建仓库目录失败: %w", err)
+		}
+	}
+	return &Repository{root: root}, nil
+}
+
+// OpenBySnapshot 根据快照文件路径打开其所在的仓库
+//
+// 参数:
+//   - snapshotPath: 快照文件路径
+//
+// 返回值:
+//   - *Repository: 仓库
+//   - error: 打开失败时返回错误信息
+func OpenBySnapshot(snapshotPath string) (*Repository, error) {
+	return Open(filepath.Dir(filepath.Dir(snapshotPath)))
+}
+
+// chunkPath 返回数据块文件的路径
+func (r *Repository) chunkPath(id string) string {
+	return filepath.Join(r.root, chunksDirName, id[:2], id)
+}
+
+// Backup 将条目写入仓库并生成快照
+//
+// 被取消时已写入的数据块保留在仓库中，未被任何快照引用的数据块会在下次回收时删除。
+// 同一文件的多个硬链接只存储一次，之后的硬链接记录为指向第一次出现路径的硬链接条目。
+// 无法读取的条目按错误处理策略跳过，不会出现在快照中。
+//
+// 参数:
+//   - ctx: 上下文，被取消或超时后立即停止写入
+//   - entries: 待备份的条目
+//   - taskName: 任务名称
+//   - versionID: 备份版本ID
+//   - tol: 错误处理策略（为 nil 时任何条目出错都中止备份）
+//
+// 返回值:
+//   - *Snapshot: 快照
+//   - BackupStats: 统计信息
+//   - error: 写入失败时返回错误信息
+func (r *Repository) Backup(ctx context.Context, entries []archive.Entry, taskName, versionID string, tol *archive.Tolerance) (*Snapshot, BackupStats, error) {
+	var stats BackupStats
+	snap := &Snapshot{
+		TaskName:  taskName,
+		VersionID: versionID,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	links := make(map[string]int) // 硬链接文件的唯一标识 -> 第一次出现的条目在快照中的位置
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, stats, err
+		}
+
+		entry := SnapshotEntry{Path: e.Name}
+		entry.setMetadata(archive.ReadMetadata(e))
+
+		switch {
+		case e.Info.IsDir():
+			entry.Type = EntryTypeDir
+
+		case e.IsRegular():
+			key, linked := archive.HardlinkKey(e.Info)
+			if i, ok := links[key]; linked && ok {
+				first := snap.Entries[i]
+				entry.Type = EntryTypeHardlink
+				entry.Target = first.Path
+				entry.Size = first.Size
+				entry.Hash = first.Hash
+				break
+			}
+
+			f, err := os.Open(e.Path)
+			if err != nil {
+				if tol.Skip(e.Name, err) {
+					continue
+				}
+				return nil, stats, fmt.Errorf("打开文件 '%s' 失败: %w", e.Path, err)
+			}
+			entry.Type = EntryTypeFile
+			err = r.storeFile(ctx, f, &entry, &stats)
+			if err == nil {
+				tol.CheckChanged(e, f)
+			}
+			_ = f.Close()
+			if err != nil {
+				return nil, stats, err
+			}
+			if linked {
+				links[key] = len(snap.Entries) // 只有成功存储的文件才能作为之后硬链接的目标
+			}
+			stats.Files++
+			stats.TotalBytes += entry.Size
+
+		case e.Info.Mode()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(e.Path)
+			if err != nil {
+				if tol.Skip(e.Name, err) {
+					continue
+				}
+				return nil, stats, fmt.Errorf("读取符号链接 '%s' 失败: %w", e.Path, err)
+			}
+			entry.Type = EntryTypeSymlink
+			entry.Target = target
+
+		default:
+			// 设备文件、管道等特殊文件无法存储内容，跳过
+			continue
+		}
+
+		snap.Entries = append(snap.Entries, entry)
+	}
+
+	return snap, stats, nil
+}
+
+// storeFile 将文件切分为数据块写入仓库，并填充条目的大小、哈希和数据块列表（不会关闭 f）
+func (r *Repository) storeFile(ctx context.Context, f *os.File, entry *SnapshotEntry, stats *BackupStats) error {
+	fileHash := sha256.New()
+	chunker := NewChunker(io.TeeReader(utils.NewContextReader(ctx, f), fileHash))
+	for {
+		data, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取文件 '%s' 失败: %w", f.Name(), err)
+		}
+
+		id, added, err := r.writeChunk(data)
+		if err != nil {
+			return err
+		}
+
+		entry.Chunks = append(entry.Chunks, id)
+		entry.Size += int64(len(data))
+		stats.TotalChunks++
+		if added {
+			stats.NewChunks++
+			stats.NewBytes += int64(len(data))
+		}
+	}
+
+	entry.Hash = hex.EncodeToString(fileHash.Sum(nil))
+	return nil
+}
+
+// writeChunk 写入数据块，已存在的数据块不会重复写入
+//
+// 返回值:
+//   - string: 数据块ID
+//   - bool: 是否新写入了数据块
+//   - error: 写入失败时返回错误信息
+func (r *Repository) writeChunk(data []byte) (string, bool, error) {
+	sum := sha256.Sum256(data)
+	id := hex.EncodeToString(sum[:])
+	path := r.chunkPath(id)
+
+	if _, err := os.Stat(path); err == nil {
+		return id, false, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", false, fmt.Errorf("创建数据块目录失败: %w", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return "", false, fmt.Errorf("写入数据块 %s 失败: %w", id, err)
+	}
+
+	return id, true, nil
+}
+
+// readChunk 读取数据块并校验其哈希
+func (r *Repository) readChunk(id string) ([]byte, error) {
+	if len(id) != sha256.Size*2 {
+		return nil, fmt.Errorf("无效的数据块ID: %s", id)
+	}
+
+	data, err := os.ReadFile(r.chunkPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("读取数据块 %s 失败: %w", id, err)
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != id {
+		return nil, fmt.Errorf("数据块 %s 校验失败，文件可能已损坏", id)
+	}
+
+	return data, nil
+}
+
+// CheckResult 检查快照引用的数据块的结果
+type CheckResult struct {
+	Chunks  int      // 检查的数据块数（去重后）
+	Missing []string // 不存在的数据块ID
+	Corrupt []string // 无法读取或哈希不一致的数据块ID，以及内容哈希不一致的文件路径
+}
+
+// Check 检查快照引用的所有数据块是否存在且哈希一致
+//
+// 参数:
+//   - ctx: 上下文，被取消或超时后立即停止检查
+//   - snap: 快照
+//   - deep: 是否同时按数据块重新计算每个文件的内容哈希
+//
+// 返回值:
+//   - CheckResult: 检查结果
+//   - error: 检查被取消时返回错误信息
+func (r *Repository) Check(ctx context.Context, snap *Snapshot, deep bool) (CheckResult, error) {
+	var result CheckResult
+	bad := make(map[string]bool)     // 已发现问题的数据块
+	checked := make(map[string]bool) // 已检查通过的数据块
+
+	for _, entry := range snap.Entries {
+		if entry.Type != EntryTypeFile {
+			continue
+		}
+
+		h := sha256.New()
+		fileOK := true
+		for _, id := range entry.Chunks {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			if bad[id] {
+				fileOK = false
+				continue
+			}
+			if checked[id] && !deep {
+				continue
+			}
+
+			data, err := r.readChunk(id)
+			if !checked[id] {
+				result.Chunks++
+			}
+			if err != nil {
+				bad[id] = true
+				fileOK = false
+				if errors.Is(err, fs.ErrNotExist) {
+					result.Missing = append(result.Missing, id)
+				} else {
+					result.Corrupt = append(result.Corrupt, id)
+				}
+				continue
+			}
+			checked[id] = true
+			h.Write(data)
+		}
+
+		if deep && fileOK && entry.Hash != "" && hex.EncodeToString(h.Sum(nil)) != entry.Hash {
+			result.Corrupt = append(result.Corrupt, entry.Path)
+		}
+	}
+
+	return result, nil
+}
+
+// SaveSnapshot 将快照写入文件
+//
+// 参数:
+//   - path: 快照文件路径
+//   - snap: 快照
+//
+// 返回值:
+//   - error: 写入失败时返回错误信息
+func SaveSnapshot(path string, snap *Snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("编码快照失败: %w", err)
+	}
+
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("写入快照失败: %w", err)
+	}
+
+	return nil
+}
+
+// LoadSnapshot 从文件读取快照
+//
+// 参数:
+//   - path: 快照文件路径
+//
+// 返回值:
+//   - *Snapshot: 快照
+//   - error: 读取失败时返回错误信息
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取快照失败: %w", err)
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("解析快照 %s 失败: %w", path, err)
+	}
+
+	return &snap, nil
+}
+
+// Restore 将快照中的文件树恢复到目标目录
+//
+// 目标目录中已存在的文件不会被覆盖，遇到已存在的文件时返回错误。
+// 与解压归档相同，逐级检查条目路径中的目录，拒绝指向目标目录之外的路径和经过符号链接
+// （包括快照中先恢复出的符号链接）的路径。
+// 指定了需要恢复的元数据时，每个条目恢复后重新应用快照中记录的属主、权限、扩展属性和时间，
+// 元数据恢复失败不会中止恢复，失败的条目及原因通过返回值报告。
+//
+// 参数:
+//   - snap: 快照
+//   - targetDir: 目标目录
+//   - preserve: 需要恢复的元数据
+//
+// 返回值:
+//   - int: 恢复的文件数
+//   - []archive.MetadataError: 元数据未能恢复的条目
+//   - error: 恢复失败时返回错误信息
+func (r *Repository) Restore(snap *Snapshot, targetDir string, preserve archive.Preserve) (int, []archive.MetadataError, error) {
+	root, err := filepath.Abs(targetDir)
+	if err != nil {
+		return 0, nil, fmt.Errorf("获取目标目录的绝对路径失败: %w", err)
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return 0, nil, fmt.Errorf("创建目标目录失败: %w", err)
+	}
+
+	restored := 0
+	var dirs []restoredDir
+	var metaErrs []archive.MetadataError
+	applyMetadata := func(path string, entry SnapshotEntry) {
+		if preserve == (archive.Preserve{}) {
+			return
+		}
+		if err := entry.metadata().Apply(path, entry.Type == EntryTypeSymlink, preserve); err != nil {
+			metaErrs = append(metaErrs, archive.MetadataError{Name: entry.Path, Err: err})
+		}
+	}
+
+	for _, entry := range snap.Entries {
+		path, err := archive.ResolvePath(root, entry.Path)
+		if err != nil {
+			return restored, metaErrs, err
+		}
+
+		switch entry.Type {
+		case EntryTypeDir:
+			info, err := os.Lstat(path)
+			switch {
+			case os.IsNotExist(err):
+				if err := os.Mkdir(path, 0755); err != nil {
+					return restored, metaErrs, fmt.Errorf("创建目录 %s 失败: %w", path, err)
+				}
+			case err != nil:
+				return restored, metaErrs, fmt.Errorf("获取 %s 的文件信息失败: %w", path, err)
+			case !info.IsDir():
+				return restored, metaErrs, fmt.Errorf("无法创建目录 %s: 已存在同名的非目录条目", path)
+			}
+			dirs = append(dirs, restoredDir{path: path, entry: entry})
+
+		case EntryTypeFile:
+			if err := r.restoreFile(path, entry); err != nil {
+				return restored, metaErrs, err
+			}
+			applyMetadata(path, entry)
+			restored++
+
+		case EntryTypeSymlink:
+			if err := os.Symlink(entry.Target, path); err != nil {
+				return restored, metaErrs, fmt.Errorf("创建符号链接 %s 失败: %w", path, err)
+			}
+			applyMetadata(path, entry)
+
+		case EntryTypeHardlink:
+			target, err := archive.ResolvePath(root, entry.Target)
+			if err != nil {
+				return restored, metaErrs, err
+			}
+			if err := os.Link(target, path); err != nil {
+				return restored, metaErrs, fmt.Errorf("创建硬链接 %s 失败: %w", path, err)
+			}
+			restored++
+		}
+	}
+
+	// 目录的权限和修改时间在其内容恢复完成后再设置
+	for i := len(dirs) - 1; i >= 0; i-- {
+		d := dirs[i]
+		_ = os.Chmod(d.path, fs.FileMode(d.entry.Mode).Perm())
+		modTime := time.Unix(0, d.entry.ModTime)
+		_ = os.Chtimes(d.path, modTime, modTime)
+		applyMetadata(d.path, d.entry)
+	}
+
+	return restored, metaErrs, nil
+}
+
+// restoredDir 恢复快照时创建的目录
+type restoredDir struct {
+	path  string        // 目录路径
+	entry SnapshotEntry // 快照条目
+}
+
+// restoreFile 按顺序读取数据块恢复单个文件
+func (r *Repository) restoreFile(path string, entry SnapshotEntry) (err error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fs.FileMode(entry.Mode).Perm())
+	if err != nil {
+		return fmt.Errorf("创建文件 %s 失败: %w", path, err)
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("关闭文件 %s 失败: %w", path, closeErr)
+		}
+	}()
+
+	fileHash := sha256.New()
+	w := io.MultiWriter(f, fileHash)
+	for _, id := range entry.Chunks {
+		data, err := r.readChunk(id)
+		if err != nil {
+			return fmt.Errorf("恢复文件 %s 失败: %w", entry.Path, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("写入文件 %s 失败: %w", path, err)
+		}
+	}
+
+	if entry.Hash != "" && hex.EncodeToString(fileHash.Sum(nil)) != entry.Hash {
+		return fmt.Errorf("文件 %s 校验失败，数据块可能已损坏", entry.Path)
+	}
+
+	modTime := time.Unix(0, entry.ModTime)
+	_ = os.Chtimes(path, modTime, modTime)
+	return nil
+}
+
+// GC 删除不再被任何快照引用的数据块
+//
+// 任意一个快照无法读取时放弃回收，避免误删仍被引用的数据块。
+//
+// 返回值:
+//   - int: 删除的数据块数
+//   - int64: 释放的空间（字节）
+//   - error: 回收失败时返回错误信息
+func (r *Repository) GC() (int, int64, error) {
+	// 1. 标记：收集所有快照引用的数据块
+	snapshotDir := filepath.Join(r.root, snapshotDirName)
+	entries, err := os.ReadDir(snapshotDir)
+	if err != nil {
+		return 0, 0, fmt.Errorf("读取快照目录失败: %w", err)
+	}
+
+	referenced := make(map[string]bool)
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != SnapshotExt {
+			continue
+		}
+		snap, err := LoadSnapshot(filepath.Join(snapshotDir, e.Name()))
+		if err != nil {
+			return 0, 0, fmt.Errorf("放弃回收数据块: %w", err)
+		}
+		for _, entry := range snap.Entries {
+			for _, id := range entry.Chunks {
+				referenced[id] = true
+			}
+		}
+	}
+
+	// 2. 清除：删除未被引用的数据块和残留的临时文件
+	removed := 0
+	var freed int64
+	chunksDir := filepath.Join(r.root, chunksDirName)
+	walkErr := filepath.WalkDir(chunksDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		name := d.Name()
+		if !strings.HasSuffix(name, tempFileSuffix) && referenced[name] {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		freed += info.Size()
+		return nil
+	})
+	if walkErr != nil {
+		return removed, freed, fmt.Errorf("回收数据块失败: %w", walkErr)
+	}
+
+	return removed, freed, nil
+}
+
+// writeFileAtomic 先写入临时文件再重命名，避免留下不完整的文件
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + tempFileSuffix
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,151 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitee.com/MM-Q/bakctl/internal/archive"
+)
+
+// backupDir 将目录备份到仓库
+func backupDir(t *testing.T, r *Repository, src, versionID string) (*Snapshot, BackupStats) {
+	t.Helper()
+	entries, err := archive.Collect(src, nil)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	snap, stats, err := r.Backup(context.Background(), entries, "test", versionID, nil)
+	if err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	return snap, stats
+}
+
+func TestBackupDedup(t *testing.T) {
+	src := t.TempDir()
+	data := randomData(7, 3<<20)
+	for _, name := range []string{"a.bin", "copy.bin"} {
+		if err := os.WriteFile(filepath.Join(src, name), data, 0644); err != nil {
+			t.Fatalf("写入测试文件失败: %v", err)
+		}
+	}
+
+	r, err := Open(filepath.Join(t.TempDir(), DirName))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	_, first := backupDir(t, r, src, "v1")
+	if first.NewChunks*2 != first.TotalChunks {
+		t.Errorf("第一次备份新增数据块 %d, 引用 %d: 相同内容的两个文件应共享数据块", first.NewChunks, first.TotalChunks)
+	}
+
+	_, second := backupDir(t, r, src, "v2")
+	if second.NewChunks != 0 || second.NewBytes != 0 {
+		t.Errorf("未修改的源目录再次备份新增了 %d 个数据块 (%d 字节)", second.NewChunks, second.NewBytes)
+	}
+	if second.TotalChunks != first.TotalChunks {
+		t.Errorf("第二次备份引用 %d 个数据块, want %d", second.TotalChunks, first.TotalChunks)
+	}
+}
+
+func TestRestoreRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := randomData(8, MaxChunkSize+123)
+	if err := os.WriteFile(filepath.Join(src, "dir", "file.bin"), data, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("file.bin", filepath.Join(src, "dir", "link")); err != nil {
+		t.Skipf("不支持符号链接: %v", err)
+	}
+
+	r, err := Open(filepath.Join(t.TempDir(), DirName))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	snap, _ := backupDir(t, r, src, "v1")
+
+	dst := t.TempDir()
+	if _, _, err := r.Restore(snap, dst, archive.Preserve{}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	top := filepath.Base(src)
+	got, err := os.ReadFile(filepath.Join(dst, top, "dir", "file.bin"))
+	if err != nil || string(got) != string(data) {
+		t.Fatalf("恢复的文件内容不一致: %v", err)
+	}
+	if target, err := os.Readlink(filepath.Join(dst, top, "dir", "link")); err != nil || target != "file.bin" {
+		t.Fatalf("恢复的符号链接 = %q, %v", target, err)
+	}
+}
+
+func TestRestoreRejectsUnsafePaths(t *testing.T) {
+	r, err := Open(filepath.Join(t.TempDir(), DirName))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	id, _, err := r.writeChunk([]byte("payload"))
+	if err != nil {
+		t.Fatalf("writeChunk() error = %v", err)
+	}
+	file := func(path string) SnapshotEntry {
+		return SnapshotEntry{Path: path, Type: EntryTypeFile, Mode: 0644, Size: 7, Chunks: []string{id}}
+	}
+
+	tests := []struct {
+		name    string
+		entries func(outside string) []SnapshotEntry
+	}{
+		{"上级目录", func(string) []SnapshotEntry {
+			return []SnapshotEntry{file("../escape.txt")}
+		}},
+		{"绝对路径", func(outside string) []SnapshotEntry {
+			return []SnapshotEntry{file(filepath.ToSlash(filepath.Join(outside, "escape.txt")))}
+		}},
+		{"经过恢复出的符号链接写入文件", func(outside string) []SnapshotEntry {
+			return []SnapshotEntry{
+				{Path: "top", Type: EntryTypeDir, Mode: 0755},
+				{Path: "top/link", Type: EntryTypeSymlink, Target: outside},
+				file("top/link/escape.txt"),
+			}
+		}},
+		{"经过恢复出的符号链接创建目录", func(outside string) []SnapshotEntry {
+			return []SnapshotEntry{
+				{Path: "link", Type: EntryTypeSymlink, Target: outside},
+				{Path: "link/sub", Type: EntryTypeDir, Mode: 0755},
+			}
+		}},
+		{"目录条目覆盖符号链接", func(outside string) []SnapshotEntry {
+			return []SnapshotEntry{
+				{Path: "link", Type: EntryTypeSymlink, Target: outside},
+				{Path: "link", Type: EntryTypeDir, Mode: 0777},
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outside := t.TempDir()
+			snap := &Snapshot{Entries: tt.entries(outside)}
+			if _, _, err := r.Restore(snap, t.TempDir(), archive.Preserve{}); err == nil {
+				t.Fatal("Restore() 应拒绝不安全的路径")
+			}
+
+			written, err := os.ReadDir(outside)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(written) > 0 {
+				t.Fatalf("目标目录之外被写入了 %d 个条目", len(written))
+			}
+			if info, err := os.Stat(outside); err == nil && info.Mode().Perm() == 0777 {
+				t.Fatal("目标目录之外的目录权限被修改")
+			}
+		})
+	}
+}
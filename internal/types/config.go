@@ -4,7 +4,14 @@ package types
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
+
+	"gitee.com/MM-Q/bakctl/internal/crypt"
+	"gitee.com/MM-Q/bakctl/internal/filter"
 )
 
 // RootConfig 根配置结构体, 用于解析TOML配置文件
@@ -15,30 +22,84 @@ type RootConfig struct {
 // AddTaskConfig 表示添加备份任务的配置结构, 仅用于读取TOML配置文件
 // 对应TOML配置文件中的[AddTaskConfig]部分
 type AddTaskConfig struct {
-	Name         string   `toml:"name" comment:"任务名称(必填, 唯一, 不可重复)"`                                          // 任务名称
-	BackupDir    string   `toml:"backup_dir" comment:"备份源目录(必填, 单个路径, 支持Windows和Linux路径)"`                    // 备份源目录
-	StorageDir   string   `toml:"storage_dir" comment:"备份存储目录(必填, 单个路径, 备份文件最终存放位置, 如果为'', 则默认使用 ~/.bakctl)"` // 备份存储目录
-	RetainCount  int      `toml:"retain_count" comment:"保留备份文件的数量(可选, 默认0个; 设置为0表示不按数量限制)"`                   // 保留备份文件的数量
-	RetainDays   int      `toml:"retain_days" comment:"保留备份文件的天数(可选, 默认0天; 设置为0表示不按天数限制)"`                    // 保留备份文件的天数
-	Compress     bool     `toml:"compress" comment:"是否压缩(可选, 默认false)"`                                       // 是否压缩
-	IncludeRules []string `toml:"include_rules" comment:"包含规则(可选, 仅备份符合规则的文件; 空数组表示备份所有文件)"`                  // 包含规则
-	ExcludeRules []string `toml:"exclude_rules" comment:"排除规则(可选, 不备份符合规则的文件; 即\"先包含后排除\")"`                  // 排除规则
-	MaxFileSize  string   `toml:"max_file_size" comment:"最大文件大小(可选, 超过此尺寸的文件不备份, 默认为0表示不限制;"`                 // 最大文件大小
-	MinFileSize  string   `toml:"min_file_size" comment:"最小文件大小(可选, 小于此尺寸的文件不备份, 默认为0表示不限制;"`                 // 最小文件大小
+	Name          string   `toml:"name" comment:"任务名称(必填, 唯一, 不可重复)"`                                                // 任务名称
+	BackupDir     string   `toml:"backup_dir" comment:"备份源目录(必填, 目录或单个文件, 支持Windows和Linux路径; 指定backup_sources时可省略)"` // 备份源目录
+	BackupSources []string `toml:"backup_sources" comment:"其他备份源路径(可选, 目录或单个文件, 与备份源目录一起备份到同一个备份中)"`                 // 其他备份源路径
+	StorageDir    string   `toml:"storage_dir" comment:"备份存储目录(必填, 单个路径, 备份文件最终存放位置, 如果为'', 则默认使用 ~/.bakctl)"`       // 备份存储目录
+	RetainCount   int      `toml:"retain_count" comment:"保留备份文件的数量(可选, 默认0个; 设置为0表示不按数量限制)"`                         // 保留备份文件的数量
+	RetainDays    int      `toml:"retain_days" comment:"保留备份文件的天数(可选, 默认0天; 设置为0表示不按天数限制)"`                          // 保留备份文件的天数
+	Compress      bool     `toml:"compress" comment:"是否压缩(可选, 默认false)"`                                             // 是否压缩
+	Compression   string   `toml:"compression" comment:"压缩等级(可选, none/fast/default/best/huffman; 默认由compress决定)"`    // 压缩等级
+	StoreExts     []string `toml:"store_exts" comment:"始终不压缩的文件扩展名(可选, 如jpg, mp4, zip, 7z; 仅zip格式有效)"`               // 不压缩的文件扩展名
+	Encrypt       bool     `toml:"encrypt" comment:"是否加密备份文件(可选, 默认false; 未指定key_file时使用密码加密)"`                      // 是否加密
+	KeyFile       string   `toml:"key_file" comment:"密钥文件路径(可选, 指定后使用密钥文件加密, 至少32字节)"`                               // 密钥文件路径
+	Recipients    []string `toml:"recipients" comment:"接收者公钥(可选, 指定后加密给这些公钥, 只有对应的身份文件能解密)"`                         // 接收者公钥
+	IncludeRules  []string `toml:"include_rules" comment:"包含规则(可选, 仅备份符合规则的文件; 空数组表示备份所有文件)"`                        // 包含规则
+	ExcludeRules  []string `toml:"exclude_rules" comment:"排除规则(可选, 不备份符合规则的文件; 即\"先包含后排除\")"`                        // 排除规则
+	IgnoreFiles   bool     `toml:"ignore_files" comment:"是否使用备份源目录中的.bakignore忽略文件(可选, 语法同.gitignore; 默认false)"`     // 是否使用忽略文件
+	NewerThan     string   `toml:"newer_than" comment:"只备份在此之后修改的文件(可选, 时长如7d、12h或日期如2024-01-02)"`                   // 修改时间下限
+	OlderThan     string   `toml:"older_than" comment:"只备份在此之前修改的文件(可选, 时长如30d或日期如2024-01-02)"`                      // 修改时间上限
+	FileTypes     []string `toml:"file_types" comment:"只备份这些类型的文件(可选, file, symlink)"`                               // 文件类型
+	FileOwner     string   `toml:"file_owner" comment:"只备份属于此用户的文件(可选, 用户名或UID; 仅Unix平台)"`                           // 文件属主
+	FileGroup     string   `toml:"file_group" comment:"只备份属于此用户组的文件(可选, 组名或GID; 仅Unix平台)"`                           // 文件属组
+	ExcludeHidden bool     `toml:"exclude_hidden" comment:"是否排除名称以.开头的隐藏文件和目录(可选, 默认false)"`                         // 是否排除隐藏文件
+	Symlinks      string   `toml:"symlinks" comment:"符号链接处理策略(可选, store: 存储链接, follow: 跟随链接, skip: 跳过; 默认store)"`    // 符号链接处理策略
+	ErrorPolicy   string   `toml:"error_policy" comment:"出错处理策略(可选, strict, skip-unreadable, skip-changed)"`         // 错误处理策略
+	MaxFileSize   string   `toml:"max_file_size" comment:"最大文件大小(可选, 超过此尺寸的文件不备份, 默认为0表示不限制;"`                       // 最大文件大小
+	MinFileSize   string   `toml:"min_file_size" comment:"最小文件大小(可选, 小于此尺寸的文件不备份, 默认为0表示不限制;"`                       // 最小文件大小
+	BackupMode    string   `toml:"backup_mode" comment:"备份模式(可选, full: 全量备份, incremental: 增量备份; 默认full)"`            // 备份模式
+	FullEvery     int      `toml:"full_every" comment:"增量备份每隔多少次执行一次全量备份(可选, 默认7; 仅增量模式有效)"`                         // 增量备份链的最大长度
+	StorageMode   string   `toml:"storage_mode" comment:"存储模式(可选, archive: 归档文件, repository: 去重数据块仓库; 默认archive)"`   // 存储模式
+	Format        string   `toml:"format" comment:"归档格式(可选, zip, tar, tar.gz, tar.bz2; 默认zip; 仅归档存储模式有效)"`           // 归档格式
+	VolumeSize    string   `toml:"volume_size" comment:"分卷大小(可选, 如4GB, 备份文件按此大小拆分为多个分卷; 默认0表示不分卷)"`                  // 分卷大小
+	HashAlgorithm string   `toml:"hash_algorithm" comment:"校验码哈希算法(可选, sha256, sha512, blake2b, xxhash; 默认sha256)"`  // 校验码哈希算法
+	PreHook       string   `toml:"pre_hook" comment:"备份前执行的命令(可选, 执行失败时中止备份)"`                                       // 备份前钩子
+	PostHook      string   `toml:"post_hook" comment:"备份成功后执行的命令(可选)"`                                               // 备份后钩子
+	OnFailureHook string   `toml:"on_failure_hook" comment:"备份失败时执行的命令(可选)"`                                         // 失败钩子
+	HookTimeout   int      `toml:"hook_timeout" comment:"钩子命令的超时时间(可选, 单位秒, 默认300秒)"`                                // 钩子超时时间
+	Timeout       int      `toml:"timeout" comment:"任务超时时间(可选, 单位秒, 超时后中止备份; 默认0表示不限制)"`                             // 任务超时时间
+	ReadLimit     string   `toml:"read_limit" comment:"读取限速(可选, 如50MB, 每秒读取的最大字节数; 默认0表示不限速)"`                       // 读取限速
 }
 
 // TaskConfig 表示备份任务的配置结构
 type TaskConfig struct {
-	Name         string   // 任务名称
-	BackupDir    string   // 备份源目录
-	StorageDir   string   // 备份存储目录
-	RetainCount  int      // 保留备份文件的数量
-	RetainDays   int      // 保留备份文件的天数
-	Compress     bool     // 是否压缩
-	IncludeRules []string // 包含规则
-	ExcludeRules []string // 排除规则
-	MaxFileSize  int64    // 最大文件大小
-	MinFileSize  int64    // 最小文件大小
+	Name          string   // 任务名称
+	BackupDir     string   // 备份源目录
+	StorageDir    string   // 备份存储目录
+	RetainCount   int      // 保留备份文件的数量
+	RetainDays    int      // 保留备份文件的天数
+	Compress      bool     // 是否压缩
+	IncludeRules  []string // 包含规则
+	ExcludeRules  []string // 排除规则
+	MaxFileSize   int64    // 最大文件大小
+	MinFileSize   int64    // 最小文件大小
+	BackupMode    string   // 备份模式
+	StorageMode   string   // 存储模式
+	PreHook       string   // 备份前执行的钩子命令
+	PostHook      string   // 备份成功后执行的钩子命令
+	OnFailureHook string   // 备份失败时执行的钩子命令
+	HookTimeout   int      // 钩子命令超时时间（秒）
+	Timeout       int      // 任务超时时间（秒，0表示不限制）
+	BackupSources []string // 备份源路径列表（包含备份源目录）
+	Format        string   // 归档格式 (zip/tar/tar.gz/tar.bz2)
+	Compression   string   // 压缩等级（为空时根据 compress 确定）
+	StoreExts     []string // 不压缩的文件扩展名
+	Encryption    string   // 加密算法（为空表示不加密）
+	KeyFile       string   // 密钥文件路径（为空时使用密码）
+	Recipients    []string // 接收者公钥
+	VolumeSize    int64    // 分卷大小（字节，0表示不分卷）
+	ReadLimit     int64    // 读取限速（字节/秒，0表示不限速）
+	HashAlgorithm string   // 校验码哈希算法 (sha256/sha512/blake2b/xxhash)
+	IgnoreFiles   bool     // 是否使用源目录中的 .bakignore 忽略文件
+	NewerThan     string   // 只备份在此之后修改的文件（时长或日期，为空表示不限制）
+	OlderThan     string   // 只备份在此之前修改的文件（时长或日期，为空表示不限制）
+	FileTypes     []string // 只备份这些类型的文件（为空表示不限制）
+	FileOwner     string   // 只备份属于此用户的文件（用户名或UID，为空表示不限制）
+	FileGroup     string   // 只备份属于此用户组的文件（组名或GID，为空表示不限制）
+	ExcludeHidden bool     // 是否排除隐藏文件和目录（名称以 . 开头）
+	Symlinks      string   // 符号链接处理策略 (store/follow/skip)
+	ErrorPolicy   string   // 单个条目出错时的处理策略 (strict/skip-unreadable/skip-changed)
+	FullEvery     int      // 增量备份链的最大长度（每隔多少次备份执行一次全量备份）
 }
 
 // invalidChars 全局map，用于定义不允许的特殊字符。
@@ -79,10 +140,476 @@ func (cfg *TaskConfig) Validate() error {
 		return fmt.Errorf("任务名称 %w", err)
 	}
 
-	// 验证备份源目录
-	if err := isValidString(cfg.BackupDir, true); err != nil {
-		return fmt.Errorf("备份源目录 %w", err)
+	// 验证备份源路径（备份源目录为第一个备份源）
+	sources := cfg.BackupSources
+	if cfg.BackupDir != "" {
+		sources = append([]string{cfg.BackupDir}, sources...)
+	}
+	sources, err := ValidateSources(sources)
+	if err != nil {
+		return err
+	}
+	cfg.BackupDir = sources[0]
+	cfg.BackupSources = sources
+
+	// 验证备份模式（为空时使用全量备份）
+	if cfg.BackupMode == "" {
+		cfg.BackupMode = BackupModeFull
+	}
+	if err := ValidateBackupMode(cfg.BackupMode); err != nil {
+		return err
+	}
+
+	// 验证增量备份链的最大长度（为0时使用默认值）
+	if cfg.FullEvery == 0 {
+		cfg.FullEvery = DefaultFullEvery
+	}
+	if cfg.FullEvery < 0 {
+		return fmt.Errorf("全量备份间隔必须大于0")
+	}
+
+	// 验证存储模式（为空时使用归档模式）
+	if cfg.StorageMode == "" {
+		cfg.StorageMode = StorageModeArchive
+	}
+	if err := ValidateStorageMode(cfg.StorageMode, cfg.BackupMode); err != nil {
+		return err
+	}
+
+	// 验证压缩等级（为空时根据是否压缩确定）
+	if cfg.Compression == "" {
+		cfg.Compression = CompressionNone
+		if cfg.Compress {
+			cfg.Compression = CompressionDefault
+		}
+	}
+	if err := ValidateCompression(cfg.Compression); err != nil {
+		return err
+	}
+	cfg.Compress = cfg.Compression != CompressionNone
+
+	// 验证包含和排除规则
+	if err := filter.Validate(cfg.IncludeRules); err != nil {
+		return fmt.Errorf("包含规则无效: %w", err)
+	}
+	if err := filter.Validate(cfg.ExcludeRules); err != nil {
+		return fmt.Errorf("排除规则无效: %w", err)
+	}
+
+	// 验证文件属性过滤条件
+	fileTypes, err := filter.NormalizeTypes(cfg.FileTypes)
+	if err != nil {
+		return err
+	}
+	cfg.FileTypes = fileTypes
+	if err := ValidateAttrFilters(cfg.NewerThan, cfg.OlderThan, cfg.FileOwner, cfg.FileGroup); err != nil {
+		return err
+	}
+
+	// 规范化不压缩的文件扩展名
+	storeExts, err := NormalizeStoreExts(cfg.StoreExts)
+	if err != nil {
+		return err
+	}
+	cfg.StoreExts = storeExts
+
+	// 验证归档格式（为空时使用 zip 格式）
+	if cfg.Format == "" {
+		cfg.Format = FormatZip
+	}
+	if err := ValidateFormat(cfg.Format); err != nil {
+		return err
+	}
+
+	// 验证符号链接处理策略（为空时存储链接本身）
+	if cfg.Symlinks == "" {
+		cfg.Symlinks = SymlinkStore
+	}
+	if err := ValidateSymlinkPolicy(cfg.Symlinks); err != nil {
+		return err
+	}
+
+	// 验证错误处理策略（为空时任何错误都中止备份）
+	if cfg.ErrorPolicy == "" {
+		cfg.ErrorPolicy = ErrorPolicyStrict
+	}
+	if err := ValidateErrorPolicy(cfg.ErrorPolicy); err != nil {
+		return err
+	}
+
+	// 验证校验码哈希算法（为空时使用默认算法）
+	if cfg.HashAlgorithm == "" {
+		cfg.HashAlgorithm = DefaultHashAlgorithm
+	}
+	if err := ValidateHashAlgorithm(cfg.HashAlgorithm); err != nil {
+		return err
+	}
+
+	// 验证分卷大小（0表示不分卷）
+	if err := ValidateVolumeSize(cfg.VolumeSize, cfg.StorageMode); err != nil {
+		return err
+	}
+
+	// 验证加密设置（指定密钥文件或接收者公钥时自动启用加密）
+	if err := cfg.validateEncryption(); err != nil {
+		return err
+	}
+
+	// 验证钩子超时时间（为0时使用默认值）
+	if cfg.HookTimeout == 0 {
+		cfg.HookTimeout = DefaultHookTimeout
+	}
+	if cfg.HookTimeout < 0 {
+		return fmt.Errorf("钩子超时时间必须大于0")
+	}
+
+	// 验证任务超时时间（0表示不限制）
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("任务超时时间不能为负数")
+	}
+
+	// 验证读取限速（0表示不限速）
+	if cfg.ReadLimit < 0 {
+		return fmt.Errorf("读取限速不能为负数")
+	}
+
+	return nil
+}
+
+// validateEncryption 验证加密设置
+func (cfg *TaskConfig) validateEncryption() error {
+	recipients, err := NormalizeRecipients(cfg.Recipients)
+	if err != nil {
+		return err
+	}
+	cfg.Recipients = recipients
+	if len(recipients) > 0 && cfg.KeyFile != "" {
+		return fmt.Errorf("不能同时指定接收者公钥和密钥文件")
+	}
+
+	if (cfg.KeyFile != "" || len(recipients) > 0) && cfg.Encryption == "" {
+		cfg.Encryption = EncryptionAES256GCM
+	}
+	if err := ValidateEncryption(cfg.Encryption); err != nil {
+		return err
+	}
+	if cfg.Encryption == "" {
+		return nil
+	}
+	if cfg.StorageMode == StorageModeRepository {
+		return fmt.Errorf("仓库存储模式不支持加密备份")
+	}
+
+	if cfg.KeyFile != "" {
+		keyFile, err := ValidateKeyFile(cfg.KeyFile)
+		if err != nil {
+			return err
+		}
+		cfg.KeyFile = keyFile
+	}
+	return nil
+}
+
+// ValidateAttrFilters 验证按文件属性过滤的条件
+//
+// 参数:
+//   - newerThan: 只备份在此之后修改的文件（时长或日期，为空表示不限制）
+//   - olderThan: 只备份在此之前修改的文件（时长或日期，为空表示不限制）
+//   - owner: 文件属主（用户名或UID，为空表示不限制）
+//   - group: 文件属组（组名或GID，为空表示不限制）
+//
+// 返回值:
+//   - error: 时间格式无效、时间范围为空或用户（组）不存在时返回错误信息
+func ValidateAttrFilters(newerThan, olderThan, owner, group string) error {
+	now := time.Now()
+	newer, err := filter.ParseTime(newerThan, now)
+	if err != nil {
+		return fmt.Errorf("修改时间下限: %w", err)
+	}
+	older, err := filter.ParseTime(olderThan, now)
+	if err != nil {
+		return fmt.Errorf("修改时间上限: %w", err)
+	}
+	if !newer.IsZero() && !older.IsZero() && !newer.Before(older) {
+		return fmt.Errorf("修改时间下限 '%s' 必须早于修改时间上限 '%s'", newerThan, olderThan)
+	}
+
+	if _, err := filter.LookupOwner(owner); err != nil {
+		return err
 	}
+	if _, err := filter.LookupGroup(group); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidateEncryption 验证加密算法是否受支持
+//
+// 参数:
+//   - encryption: 加密算法（为空表示不加密）
+//
+// 返回值:
+//   - error: 如果加密算法不受支持，则返回错误信息
+func ValidateEncryption(encryption string) error {
+	if encryption == "" || encryption == EncryptionAES256GCM {
+		return nil
+	}
+	return fmt.Errorf("不支持的加密算法 '%s', 可选值: %s", encryption, EncryptionAES256GCM)
+}
 
+// ValidateVolumeSize 验证分卷大小
+//
+// 参数:
+//   - volumeSize: 分卷大小（字节，0表示不分卷）
+//   - storageMode: 存储模式
+//
+// 返回值:
+//   - error: 如果分卷大小无效或与存储模式冲突，则返回错误信息
+func ValidateVolumeSize(volumeSize int64, storageMode string) error {
+	if volumeSize == 0 {
+		return nil
+	}
+	if volumeSize < MinVolumeSize {
+		return fmt.Errorf("分卷大小不能小于 1MB")
+	}
+	if storageMode == StorageModeRepository {
+		return fmt.Errorf("仓库存储模式不支持分卷备份")
+	}
 	return nil
 }
+
+// NormalizeRecipients 验证并规范化接收者公钥列表
+//
+// 参数:
+//   - recipients: 接收者公钥列表
+//
+// 返回值:
+//   - []string: 去除空白和重复后的接收者公钥列表
+//   - error: 如果公钥格式无效，则返回错误信息
+func NormalizeRecipients(recipients []string) ([]string, error) {
+	normalized := make([]string, 0, len(recipients))
+	for _, r := range recipients {
+		r = strings.TrimSpace(r)
+		if r == "" || slices.Contains(normalized, r) {
+			continue
+		}
+		if _, err := crypt.ParseRecipient(r); err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, r)
+	}
+	return normalized, nil
+}
+
+// ValidateKeyFile 验证密钥文件是否存在
+//
+// 参数:
+//   - keyFile: 密钥文件路径
+//
+// 返回值:
+//   - string: 密钥文件的绝对路径
+//   - error: 如果密钥文件不存在或不是普通文件，则返回错误信息
+func ValidateKeyFile(keyFile string) (string, error) {
+	abs, err := filepath.Abs(keyFile)
+	if err != nil {
+		return "", fmt.Errorf("无法获取密钥文件的绝对路径: %w", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("密钥文件不可用: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("密钥文件不是普通文件: %s", abs)
+	}
+	return abs, nil
+}
+
+// ValidateSources 验证备份源路径列表
+//
+// 参数:
+//   - sources: 备份源路径列表（目录或单个文件）
+//
+// 返回值:
+//   - []string: 清理后的备份源路径列表（去除重复的路径）
+//   - error: 列表为空、路径包含不允许的字符或路径之间互相包含时返回错误信息
+func ValidateSources(sources []string) ([]string, error) {
+	var cleaned []string
+	seen := make(map[string]bool, len(sources))
+	for _, src := range sources {
+		if err := isValidString(src, true); err != nil {
+			return nil, fmt.Errorf("备份源路径 %w", err)
+		}
+		src = filepath.Clean(strings.TrimSpace(src))
+		if seen[src] {
+			continue
+		}
+		seen[src] = true
+		cleaned = append(cleaned, src)
+	}
+	if len(cleaned) == 0 {
+		return nil, fmt.Errorf("备份源目录 不能为空")
+	}
+
+	// 互相包含的路径会被重复备份
+	for i, a := range cleaned {
+		for _, b := range cleaned[i+1:] {
+			if isSubPath(a, b) || isSubPath(b, a) {
+				return nil, fmt.Errorf("备份源路径不能互相包含: %s, %s", a, b)
+			}
+		}
+	}
+
+	return cleaned, nil
+}
+
+// isSubPath 判断 path 是否位于 parent 之下
+func isSubPath(parent, path string) bool {
+	rel, err := filepath.Rel(parent, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// ValidateBackupMode 验证备份模式是否受支持
+//
+// 参数:
+//   - mode: 备份模式
+//
+// 返回值:
+//   - error: 如果备份模式不受支持，则返回错误信息
+func ValidateBackupMode(mode string) error {
+	for _, m := range BackupModeList {
+		if mode == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("不支持的备份模式 '%s', 可选值: %v", mode, BackupModeList)
+}
+
+// ValidateCompression 验证压缩等级是否受支持
+//
+// 参数:
+//   - compression: 压缩等级
+//
+// 返回值:
+//   - error: 如果压缩等级不受支持，则返回错误信息
+func ValidateCompression(compression string) error {
+	for _, c := range CompressionList {
+		if compression == c {
+			return nil
+		}
+	}
+	return fmt.Errorf("不支持的压缩等级 '%s', 可选值: %v", compression, CompressionList)
+}
+
+// NormalizeStoreExts 规范化不压缩的文件扩展名列表
+//
+// 扩展名统一转为小写并去掉前缀的 "*." 或 "."，如 "*.JPG" 和 ".jpg" 都规范化为 "jpg"。
+//
+// 参数:
+//   - exts: 文件扩展名列表
+//
+// 返回值:
+//   - []string: 规范化并去除重复后的扩展名列表
+//   - error: 扩展名为空或包含路径分隔符时返回错误信息
+func NormalizeStoreExts(exts []string) ([]string, error) {
+	normalized := make([]string, 0, len(exts))
+	seen := make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		ext = strings.TrimPrefix(strings.TrimPrefix(ext, "*"), ".")
+		if ext == "" || strings.ContainsAny(ext, `/\*?`) {
+			return nil, fmt.Errorf("无效的文件扩展名: '%s'", ext)
+		}
+		if seen[ext] {
+			continue
+		}
+		seen[ext] = true
+		normalized = append(normalized, ext)
+	}
+	return normalized, nil
+}
+
+// ValidateFormat 验证归档格式是否受支持
+//
+// 参数:
+//   - format: 归档格式
+//
+// 返回值:
+//   - error: 如果归档格式不受支持，则返回错误信息
+func ValidateFormat(format string) error {
+	for _, f := range FormatList {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("不支持的归档格式 '%s', 可选值: %v", format, FormatList)
+}
+
+// ValidateSymlinkPolicy 验证符号链接处理策略是否受支持
+//
+// 参数:
+//   - policy: 符号链接处理策略
+//
+// 返回值:
+//   - error: 如果处理策略不受支持，则返回错误信息
+func ValidateSymlinkPolicy(policy string) error {
+	if slices.Contains(SymlinkPolicyList, policy) {
+		return nil
+	}
+	return fmt.Errorf("不支持的符号链接处理策略 '%s', 可选值: %v", policy, SymlinkPolicyList)
+}
+
+// ValidateErrorPolicy 验证错误处理策略是否受支持
+//
+// 参数:
+//   - policy: 错误处理策略
+//
+// 返回值:
+//   - error: 如果处理策略不受支持，则返回错误信息
+func ValidateErrorPolicy(policy string) error {
+	if slices.Contains(ErrorPolicyList, policy) {
+		return nil
+	}
+	return fmt.Errorf("不支持的错误处理策略 '%s', 可选值: %v", policy, ErrorPolicyList)
+}
+
+// ValidateHashAlgorithm 验证校验码哈希算法是否可以为任务选择
+//
+// 参数:
+//   - algorithm: 哈希算法
+//
+// 返回值:
+//   - error: 如果哈希算法不受支持，则返回错误信息
+func ValidateHashAlgorithm(algorithm string) error {
+	for _, a := range HashAlgorithmList {
+		if algorithm == a {
+			return nil
+		}
+	}
+	if algorithm == HashSHA1 {
+		return fmt.Errorf("哈希算法 '%s' 已不再安全, 只用于验证早期的备份记录, 可选值: %v", algorithm, HashAlgorithmList)
+	}
+	return fmt.Errorf("不支持的哈希算法 '%s', 可选值: %v", algorithm, HashAlgorithmList)
+}
+
+// ValidateStorageMode 验证存储模式是否受支持, 以及是否能与备份模式组合使用
+//
+// 参数:
+//   - mode: 存储模式
+//   - backupMode: 备份模式
+//
+// 返回值:
+//   - error: 如果存储模式不受支持或与备份模式冲突，则返回错误信息
+func ValidateStorageMode(mode, backupMode string) error {
+	switch mode {
+	case StorageModeArchive:
+		return nil
+	case StorageModeRepository:
+		// 仓库模式本身按数据块去重, 每个快照都是完整的, 不需要增量备份
+		if backupMode == BackupModeIncremental {
+			return fmt.Errorf("仓库存储模式已按数据块去重, 不支持与增量备份模式同时使用")
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的存储模式 '%s', 可选值: %v", mode, StorageModeList)
+	}
+}
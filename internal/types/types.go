@@ -3,7 +3,9 @@
 package types
 
 import (
+	"fmt"
 	"path/filepath"
+	"time"
 
 	"gitee.com/MM-Q/bakctl/internal/utils"
 	"github.com/jedib0t/go-pretty/v6/table"
@@ -21,6 +23,9 @@ const (
 
 	// 默认备份目录名字
 	BackupDirName = "bak"
+
+	// 写入中的备份文件后缀（备份完成后重命名为最终文件名）
+	PartialFileSuffix = ".partial"
 )
 
 var (
@@ -36,29 +41,179 @@ var (
 // 1. 增加了ID字段（数据库自增主键）
 // 2. IncludeRules和ExcludeRules为字符串类型（存储JSON数组格式）
 type BackupTask struct {
-	ID           int64  `db:"ID" json:"id"`                       // 任务唯一标识（自增主键）
-	Name         string `db:"name" json:"name"`                   // 任务名称
-	RetainCount  int    `db:"retain_count" json:"retain_count"`   // 保留备份数量
-	RetainDays   int    `db:"retain_days" json:"retain_days"`     // 保留天数
-	BackupDir    string `db:"backup_dir" json:"backup_dir"`       // 备份源目录
-	StorageDir   string `db:"storage_dir" json:"storage_dir"`     // 存储目录
-	Compress     bool   `db:"compress" json:"compress"`           // 是否压缩
-	IncludeRules string `db:"include_rules" json:"include_rules"` // 包含规则（JSON格式字符串）
-	ExcludeRules string `db:"exclude_rules" json:"exclude_rules"` // 排除规则（JSON格式字符串）
-	MaxFileSize  int64  `db:"max_file_size" json:"max_file_size"` // 最大文件大小（字节）
-	MinFileSize  int64  `db:"min_file_size" json:"min_file_size"` // 最小文件大小（字节）
+	ID            int64  `db:"ID" json:"id"`                           // 任务唯一标识（自增主键）
+	Name          string `db:"name" json:"name"`                       // 任务名称
+	RetainCount   int    `db:"retain_count" json:"retain_count"`       // 保留备份数量
+	RetainDays    int    `db:"retain_days" json:"retain_days"`         // 保留天数
+	BackupDir     string `db:"backup_dir" json:"backup_dir"`           // 备份源目录
+	StorageDir    string `db:"storage_dir" json:"storage_dir"`         // 存储目录
+	Compress      bool   `db:"compress" json:"compress"`               // 是否压缩
+	IncludeRules  string `db:"include_rules" json:"include_rules"`     // 包含规则（JSON格式字符串）
+	ExcludeRules  string `db:"exclude_rules" json:"exclude_rules"`     // 排除规则（JSON格式字符串）
+	MaxFileSize   int64  `db:"max_file_size" json:"max_file_size"`     // 最大文件大小（字节）
+	MinFileSize   int64  `db:"min_file_size" json:"min_file_size"`     // 最小文件大小（字节）
+	BackupMode    string `db:"backup_mode" json:"backup_mode"`         // 备份模式（full/incremental）
+	StorageMode   string `db:"storage_mode" json:"storage_mode"`       // 存储模式（archive/repository）
+	PreHook       string `db:"pre_hook" json:"pre_hook"`               // 备份前执行的钩子命令
+	PostHook      string `db:"post_hook" json:"post_hook"`             // 备份成功后执行的钩子命令
+	OnFailureHook string `db:"on_failure_hook" json:"on_failure_hook"` // 备份失败时执行的钩子命令
+	HookTimeout   int    `db:"hook_timeout" json:"hook_timeout"`       // 钩子命令超时时间（秒）
+	Timeout       int    `db:"timeout" json:"timeout"`                 // 任务超时时间（秒，0表示不限制）
+	BackupSources string `db:"backup_sources" json:"backup_sources"`   // 备份源路径列表（JSON格式字符串，为空表示只有备份源目录）
+	Format        string `db:"format" json:"format"`                   // 归档格式 (zip/tar/tar.gz/tar.bz2)
+	Compression   string `db:"compression" json:"compression"`         // 压缩等级（为空时根据 compress 确定）
+	StoreExts     string `db:"store_exts" json:"store_exts"`           // 不压缩的文件扩展名（JSON格式字符串）
+	Encryption    string `db:"encryption" json:"encryption"`           // 加密算法（为空表示不加密）
+	KeyFile       string `db:"key_file" json:"key_file"`               // 密钥文件路径（为空时使用密码）
+	Recipients    string `db:"recipients" json:"recipients"`           // 接收者公钥（JSON数组格式）
+	VolumeSize    int64  `db:"volume_size" json:"volume_size"`         // 分卷大小（字节，0表示不分卷）
+	ReadLimit     int64  `db:"read_limit" json:"read_limit"`           // 读取限速（字节/秒，0表示不限速）
+	HashAlgorithm string `db:"hash_algorithm" json:"hash_algorithm"`   // 校验码哈希算法 (sha256/sha512/blake2b/xxhash)
+	IgnoreFiles   bool   `db:"ignore_files" json:"ignore_files"`       // 是否使用源目录中的 .bakignore 忽略文件
+	NewerThan     string `db:"newer_than" json:"newer_than"`           // 只备份在此之后修改的文件（时长或日期，为空表示不限制）
+	OlderThan     string `db:"older_than" json:"older_than"`           // 只备份在此之前修改的文件（时长或日期，为空表示不限制）
+	FileTypes     string `db:"file_types" json:"file_types"`           // 只备份这些类型的文件（JSON数组格式，为空表示不限制）
+	FileOwner     string `db:"file_owner" json:"file_owner"`           // 只备份属于此用户的文件（用户名或UID，为空表示不限制）
+	FileGroup     string `db:"file_group" json:"file_group"`           // 只备份属于此用户组的文件（组名或GID，为空表示不限制）
+	ExcludeHidden bool   `db:"exclude_hidden" json:"exclude_hidden"`   // 是否排除隐藏文件和目录（名称以 . 开头）
+	Symlinks      string `db:"symlinks" json:"symlinks"`               // 符号链接处理策略 (store/follow/skip)
+	ErrorPolicy   string `db:"error_policy" json:"error_policy"`       // 单个条目出错时的处理策略 (strict/skip-unreadable/skip-changed)
+	FullEvery     int    `db:"full_every" json:"full_every"`           // 增量备份链的最大长度（每隔多少次备份执行一次全量备份）
+}
+
+// Sources 返回任务的所有备份源路径
+//
+// 只有一个备份源的任务（包括早期版本创建的任务）不记录备份源路径列表，直接返回备份源目录。
+func (t *BackupTask) Sources() []string {
+	if t.BackupSources != "" {
+		if sources, err := utils.UnmarshalRules(t.BackupSources); err == nil && len(sources) > 0 {
+			return sources
+		}
+	}
+	return []string{t.BackupDir}
+}
+
+// CompressionName 返回任务的压缩等级
+//
+// 早期版本创建的任务没有记录压缩等级，根据是否压缩确定（压缩时使用默认等级）。
+func (t *BackupTask) CompressionName() string {
+	if t.Compression != "" {
+		return t.Compression
+	}
+	if t.Compress {
+		return CompressionDefault
+	}
+	return CompressionNone
+}
+
+// HashAlgorithmName 返回任务计算校验码使用的哈希算法（未记录时使用默认算法）
+func (t *BackupTask) HashAlgorithmName() string {
+	if t.HashAlgorithm != "" {
+		return t.HashAlgorithm
+	}
+	return DefaultHashAlgorithm
+}
+
+// IgnoreFile 返回任务使用的忽略文件名（未启用忽略文件时为空）
+func (t *BackupTask) IgnoreFile() string {
+	if t.IgnoreFiles {
+		return IgnoreFileName
+	}
+	return ""
+}
+
+// SymlinkPolicy 返回任务的符号链接处理策略（未记录时存储链接本身）
+func (t *BackupTask) SymlinkPolicy() string {
+	if t.Symlinks != "" {
+		return t.Symlinks
+	}
+	return SymlinkStore
+}
+
+// ErrorPolicyName 返回任务中单个条目出错时的处理策略（未记录时任何错误都中止备份）
+func (t *BackupTask) ErrorPolicyName() string {
+	if t.ErrorPolicy != "" {
+		return t.ErrorPolicy
+	}
+	return ErrorPolicyStrict
+}
+
+// FullEveryCount 返回任务中增量备份链的最大长度（未记录时使用默认值）
+func (t *BackupTask) FullEveryCount() int {
+	if t.FullEvery > 0 {
+		return t.FullEvery
+	}
+	return DefaultFullEvery
+}
+
+// StoreExtList 返回任务中始终不压缩的文件扩展名列表
+func (t *BackupTask) StoreExtList() []string {
+	exts, err := utils.UnmarshalRules(t.StoreExts)
+	if err != nil {
+		return nil
+	}
+	return exts
+}
+
+// FileTypeList 返回任务只备份的文件类型列表（为空表示不限制文件类型）
+func (t *BackupTask) FileTypeList() []string {
+	if t.FileTypes == "" {
+		return nil
+	}
+	list, err := utils.UnmarshalRules(t.FileTypes)
+	if err != nil {
+		return nil
+	}
+	return list
+}
+
+// RecipientList 返回任务的接收者公钥列表
+func (t *BackupTask) RecipientList() []string {
+	recipients, err := utils.UnmarshalRules(t.Recipients)
+	if err != nil {
+		return nil
+	}
+	return recipients
 }
 
 // UpdateTaskParams 封装了更新任务所需的参数
 type UpdateTaskParams struct {
-	ID           int64  `json:"id"`            // 任务唯一标识（自增主键）
-	RetainCount  int    `json:"retain_count"`  // 保留备份数量
-	RetainDays   int    `json:"retain_days"`   // 保留天数
-	Compress     bool   `json:"compress"`      // 是否压缩
-	IncludeRules string `json:"include_rules"` // 包含规则（JSON格式字符串）
-	ExcludeRules string `json:"exclude_rules"` // 排除规则（JSON格式字符串）
-	MaxFileSize  int64  `json:"max_file_size"` // 最大文件大小（字节）
-	MinFileSize  int64  `json:"min_file_size"` // 最小文件大小（字节）
+	ID            int64  `json:"id"`              // 任务唯一标识（自增主键）
+	RetainCount   int    `json:"retain_count"`    // 保留备份数量
+	RetainDays    int    `json:"retain_days"`     // 保留天数
+	Compress      bool   `json:"compress"`        // 是否压缩
+	IncludeRules  string `json:"include_rules"`   // 包含规则（JSON格式字符串）
+	ExcludeRules  string `json:"exclude_rules"`   // 排除规则（JSON格式字符串）
+	MaxFileSize   int64  `json:"max_file_size"`   // 最大文件大小（字节）
+	MinFileSize   int64  `json:"min_file_size"`   // 最小文件大小（字节）
+	BackupMode    string `json:"backup_mode"`     // 备份模式（full/incremental）
+	StorageMode   string `json:"storage_mode"`    // 存储模式（archive/repository）
+	PreHook       string `json:"pre_hook"`        // 备份前执行的钩子命令
+	PostHook      string `json:"post_hook"`       // 备份成功后执行的钩子命令
+	OnFailureHook string `json:"on_failure_hook"` // 备份失败时执行的钩子命令
+	HookTimeout   int    `json:"hook_timeout"`    // 钩子命令超时时间（秒）
+	Timeout       int    `json:"timeout"`         // 任务超时时间（秒，0表示不限制）
+	BackupDir     string `json:"backup_dir"`      // 备份源目录（第一个备份源路径）
+	BackupSources string `json:"backup_sources"`  // 备份源路径列表（JSON格式字符串，为空表示只有备份源目录）
+	Format        string `json:"format"`          // 归档格式 (zip/tar/tar.gz/tar.bz2)
+	Compression   string `json:"compression"`     // 压缩等级（为空时根据 compress 确定）
+	StoreExts     string `json:"store_exts"`      // 不压缩的文件扩展名（JSON格式字符串）
+	Encryption    string `json:"encryption"`      // 加密算法（为空表示不加密）
+	KeyFile       string `json:"key_file"`        // 密钥文件路径（为空时使用密码）
+	Recipients    string `json:"recipients"`      // 接收者公钥（JSON数组格式）
+	VolumeSize    int64  `json:"volume_size"`     // 分卷大小（字节，0表示不分卷）
+	ReadLimit     int64  `json:"read_limit"`      // 读取限速（字节/秒，0表示不限速）
+	HashAlgorithm string `json:"hash_algorithm"`  // 校验码哈希算法 (sha256/sha512/blake2b/xxhash)
+	IgnoreFiles   bool   `json:"ignore_files"`    // 是否使用源目录中的 .bakignore 忽略文件
+	NewerThan     string `json:"newer_than"`      // 只备份在此之后修改的文件（时长或日期，为空表示不限制）
+	OlderThan     string `json:"older_than"`      // 只备份在此之前修改的文件（时长或日期，为空表示不限制）
+	FileTypes     string `json:"file_types"`      // 只备份这些类型的文件（JSON数组格式，为空表示不限制）
+	FileOwner     string `json:"file_owner"`      // 只备份属于此用户的文件（用户名或UID，为空表示不限制）
+	FileGroup     string `json:"file_group"`      // 只备份属于此用户组的文件（组名或GID，为空表示不限制）
+	ExcludeHidden bool   `json:"exclude_hidden"`  // 是否排除隐藏文件和目录（名称以 . 开头）
+	Symlinks      string `json:"symlinks"`        // 符号链接处理策略 (store/follow/skip)
+	ErrorPolicy   string `json:"error_policy"`    // 单个条目出错时的处理策略 (strict/skip-unreadable/skip-changed)
+	FullEvery     int    `json:"full_every"`      // 增量备份链的最大长度（每隔多少次备份执行一次全量备份）
 }
 
 // BackupRecord 对应 backup_records 表的结构体（适配 sqlx + SQLite）
@@ -66,26 +221,141 @@ type UpdateTaskParams struct {
 // - db:"列名"：sqlx用于映射SQLite表列，确保与表字段名完全一致
 // - json:"字段名"：可选，用于API返回或日志打印（按需保留）
 type BackupRecord struct {
-	ID             int64  `db:"ID" json:"id"`                                     // 主键（自增）
-	TaskID         int64  `db:"task_id" json:"task_id"`                           // 关联的任务ID（外键，非空，关联backup_tasks.ID）
-	TaskName       string `db:"task_name" json:"task_name"`                       // 关联的任务名称（冗余存储，非空，便于查询）
-	VersionID      string `db:"version_id" json:"version_id"`                     // 备份版本唯一标识（非空+唯一，如UUID/时间戳）
-	BackupFilename string `db:"backup_filename" json:"backup_filename"`           // 备份文件名（非空，如"db_20240520.sql.gz"）
-	BackupSize     int64  `db:"backup_size" json:"backup_size"`                   // 备份文件大小（非空，单位：字节，用int64支持大文件）
-	StoragePath    string `db:"storage_path" json:"storage_path"`                 // 备份文件完整路径（非空，如"/mnt/backup/db_20240520.sql.gz"）
-	Status         bool   `db:"status" json:"status"`                             // 备份状态（非空，仅支持true/false）
-	FailureMessage string `db:"failure_message" json:"failure_message,omitempty"` // 失败信息（可空，成功时存NULL，用指针接收NULL值）
-	Checksum       string `db:"checksum" json:"checksum,omitempty"`               // 校验码（可空，如"MD5:abc123"，用指针接收NULL值）
-	CreatedAt      string `db:"created_at" json:"created_at"`                     // 备份时间（默认SQLite自动生成，ISO8601格式字符串，如"2024-05-20T15:30:00Z"）
+	ID              int64  `db:"ID" json:"id"`                                     // 主键（自增）
+	TaskID          int64  `db:"task_id" json:"task_id"`                           // 关联的任务ID（外键，非空，关联backup_tasks.ID）
+	TaskName        string `db:"task_name" json:"task_name"`                       // 关联的任务名称（冗余存储，非空，便于查询）
+	VersionID       string `db:"version_id" json:"version_id"`                     // 备份版本唯一标识（非空+唯一，如UUID/时间戳）
+	BackupFilename  string `db:"backup_filename" json:"backup_filename"`           // 备份文件名（非空，如"db_20240520.sql.gz"）
+	BackupSize      int64  `db:"backup_size" json:"backup_size"`                   // 备份文件大小（非空，单位：字节，用int64支持大文件）
+	StoragePath     string `db:"storage_path" json:"storage_path"`                 // 备份文件完整路径（非空，如"/mnt/backup/db_20240520.sql.gz"）
+	Status          bool   `db:"status" json:"status"`                             // 备份状态（非空，仅支持true/false）
+	FailureMessage  string `db:"failure_message" json:"failure_message,omitempty"` // 失败信息（可空，成功时存NULL，用指针接收NULL值）
+	Checksum        string `db:"checksum" json:"checksum,omitempty"`               // 校验码（可空，如"MD5:abc123"，用指针接收NULL值）
+	CreatedAt       string `db:"created_at" json:"created_at"`                     // 备份时间（默认SQLite自动生成，ISO8601格式字符串，如"2024-05-20T15:30:00Z"）
+	ParentVersionID string `db:"parent_version_id" json:"parent_version_id"`       // 增量备份所依赖的上一个版本ID（全量备份为空）
+	StorageMode     string `db:"storage_mode" json:"storage_mode"`                 // 存储模式（archive: 归档文件, repository: 数据块仓库快照）
+	State           string `db:"state" json:"state"`                               // 备份状态（success/partial/failed/cancelled/timeout）
+	Encryption      string `db:"encryption" json:"encryption"`                     // 加密方案（为空表示未加密）
+	VolumeCount     int    `db:"volume_count" json:"volume_count"`                 // 分卷数量（0表示未分卷）
+	StartedAt       string `db:"started_at" json:"started_at"`                     // 开始时间（UTC，格式同 created_at）
+	FinishedAt      string `db:"finished_at" json:"finished_at"`                   // 结束时间（UTC，格式同 created_at）
+	DurationMs      int64  `db:"duration_ms" json:"duration_ms"`                   // 执行耗时（毫秒）
+	FileCount       int64  `db:"file_count" json:"file_count"`                     // 备份源中的文件数
+	DirCount        int64  `db:"dir_count" json:"dir_count"`                       // 备份源中的目录数
+	SourceBytes     int64  `db:"source_bytes" json:"source_bytes"`                 // 从备份源读取的字节数
+	SkippedCount    int64  `db:"skipped_count" json:"skipped_count"`               // 被过滤器跳过的条目数
+	HostName        string `db:"host_name" json:"host_name"`                       // 执行备份的主机名
+	Version         string `db:"bakctl_version" json:"bakctl_version"`             // 执行备份的 bakctl 版本
+	VerifiedAt      string `db:"verified_at" json:"verified_at"`                   // 最近一次校验时间（UTC，为空表示从未校验）
+	VerifyResult    string `db:"verify_result" json:"verify_result"`               // 最近一次校验结果（ok/missing/mismatch/corrupt/skipped）
+	VerifyMessage   string `db:"verify_message" json:"verify_message"`             // 最近一次校验发现的问题
+}
+
+// IsRepository 判断该备份记录是否存储在数据块仓库中
+func (r *BackupRecord) IsRepository() bool {
+	return r.StorageMode == StorageModeRepository
+}
+
+// CompressionRatio 返回备份大小与读取量的比值（没有读取量时返回 0）
+func (r *BackupRecord) CompressionRatio() float64 {
+	if r.SourceBytes <= 0 {
+		return 0
+	}
+	return float64(r.BackupSize) / float64(r.SourceBytes)
+}
+
+// ResultState 返回备份记录的执行状态
+//
+// 早期版本的记录没有 state 字段，按 status 推断为成功或失败。
+func (r *BackupRecord) ResultState() string {
+	if r.State != "" {
+		return r.State
+	}
+	if r.Status {
+		return BackupStateSuccess
+	}
+	return BackupStateFailed
+}
+
+// VolumePaths 返回备份记录的所有备份文件路径
+//
+// 分卷备份返回各分卷文件的路径，未分卷的备份只返回存储路径。
+func (r *BackupRecord) VolumePaths() []string {
+	if r.VolumeCount <= 0 {
+		return []string{r.StoragePath}
+	}
+	paths := make([]string, 0, r.VolumeCount)
+	for seq := 1; seq <= r.VolumeCount; seq++ {
+		paths = append(paths, VolumePath(r.StoragePath, seq))
+	}
+	return paths
+}
+
+// IsIncremental 判断该备份记录是否为增量备份
+func (r *BackupRecord) IsIncremental() bool {
+	return r.ParentVersionID != ""
+}
+
+// BackupFile 对应 backup_files 表的结构体, 记录某个备份版本的文件清单
+// 每个成功备份的清单描述了备份时源目录的完整状态, 用于查询文件所在的版本,
+// 增量备份也通过与上一个版本的清单对比确定变化的文件
+type BackupFile struct {
+	ID         int64  `db:"ID" json:"id"`                   // 主键（自增）
+	VersionID  string `db:"version_id" json:"version_id"`   // 所属的备份版本ID
+	Path       string `db:"path" json:"path"`               // 归档内的相对路径（使用正斜杠分隔）
+	Size       int64  `db:"size" json:"size"`               // 文件大小（字节）
+	ModTime    int64  `db:"mod_time" json:"mod_time"`       // 修改时间（Unix纳秒时间戳）
+	Mode       uint32 `db:"mode" json:"mode"`               // 文件权限
+	Hash       string `db:"hash" json:"hash"`               // 文件内容哈希（sha256）
+	ChangeTime int64  `db:"change_time" json:"change_time"` // 状态变化时间（Unix纳秒时间戳，0表示未知）
+	ChangeType string `db:"change_type" json:"change_type"` // 相对上一个版本的变化类型（added/modified/unchanged/deleted）
+}
+
+// BackupFileFilter 查询文件清单时使用的过滤条件
+type BackupFileFilter struct {
+	Pattern        string // 路径匹配模式（通配符, 不含 / 时也匹配文件名; 为空表示不过滤）
+	ChangeType     string // 变化类型（为空表示不过滤）
+	ExcludeDeleted bool   // 是否排除已删除的文件
+}
+
+// BackupVolume 对应 backup_volumes 表的结构体, 记录分卷备份中每个分卷文件的大小和校验码
+type BackupVolume struct {
+	ID        int64  `db:"ID" json:"id"`                 // 主键（自增）
+	VersionID string `db:"version_id" json:"version_id"` // 所属的备份版本ID
+	Seq       int    `db:"seq" json:"seq"`               // 分卷序号（从1开始）
+	Size      int64  `db:"size" json:"size"`             // 分卷文件大小（字节）
+	Checksum  string `db:"checksum" json:"checksum"`     // 分卷文件校验码
+}
+
+// BackupWarning 对应 backup_warnings 表的结构体, 记录按错误处理策略被跳过或未能完整备份的条目
+// 记录了警告的备份版本的执行状态为 partial, 其余条目仍然可以正常恢复
+type BackupWarning struct {
+	ID        int64  `db:"ID" json:"id"`                 // 主键（自增）
+	VersionID string `db:"version_id" json:"version_id"` // 所属的备份版本ID
+	Path      string `db:"path" json:"path"`             // 条目在归档内的路径
+	Message   string `db:"message" json:"message"`       // 警告信息
 }
 
 // BackupResult 备份执行结果
 type BackupResult struct {
-	Success    bool   // 是否成功
-	ErrorMsg   string // 错误信息
-	BackupPath string // 备份文件路径
-	FileSize   int64  // 文件大小
-	Checksum   string // 校验码
+	Success         bool            // 是否成功
+	State           string          // 执行状态（为空时根据 Success 推断为成功或失败）
+	ErrorMsg        string          // 错误信息
+	VersionID       string          // 版本ID
+	BackupPath      string          // 备份文件路径
+	TempPath        string          // 写入中的临时文件路径（收集文件信息成功后重命名为备份文件路径）
+	FileSize        int64           // 文件大小
+	Checksum        string          // 校验码
+	ParentVersionID string          // 增量备份所依赖的上一个版本ID
+	Encryption      string          // 加密方案（为空表示未加密）
+	Files           []BackupFile    // 文件清单
+	Volumes         []BackupVolume  // 分卷信息（仅分卷备份记录）
+	StartedAt       time.Time       // 开始时间
+	FileCount       int64           // 备份源中的文件数
+	DirCount        int64           // 备份源中的目录数
+	SourceBytes     int64           // 从备份源读取的字节数
+	SkippedCount    int64           // 被过滤器跳过的条目数
+	FileWarnings    []BackupWarning // 被跳过或未能完整备份的条目，如特殊文件、形成循环的符号链接和按错误处理策略跳过的条目（不为空时备份记录为 partial）
 }
 
 // 定义存放表格样式的MAP
@@ -119,8 +389,170 @@ var StyleNone = table.Style{
 // 定义存放表格样式的切片
 var TableStyleList = []string{"df", "bd", "cb", "cd", "de", "lt", "ro", "none"}
 
-// 常量定义
+// 校验码哈希算法
 const (
-	HashAlgorithm = "sha1"
-	BackupFileExt = ".zip"
+	HashSHA1    = "sha1"    // SHA-1（早期版本使用的算法，仅用于验证早期的备份记录）
+	HashSHA256  = "sha256"  // SHA-256（默认）
+	HashSHA512  = "sha512"  // SHA-512
+	HashBLAKE2b = "blake2b" // BLAKE2b-256
+	HashXXHash  = "xxhash"  // XXH64（非加密哈希，速度最快，只能发现意外损坏）
+
+	DefaultHashAlgorithm = HashSHA256 // 默认的校验码哈希算法
+)
+
+// HashAlgorithmList 可以为任务选择的校验码哈希算法列表
+var HashAlgorithmList = []string{HashSHA256, HashSHA512, HashBLAKE2b, HashXXHash}
+
+// 压缩等级
+const (
+	CompressionNone    = "none"    // 不压缩（仅存储）
+	CompressionFast    = "fast"    // 快速压缩
+	CompressionDefault = "default" // 默认压缩等级
+	CompressionBest    = "best"    // 最佳压缩
+	CompressionHuffman = "huffman" // 仅使用 Huffman 编码（速度最快, 适合已经压缩过的数据）
+)
+
+// CompressionList 支持的压缩等级列表
+var CompressionList = []string{CompressionNone, CompressionFast, CompressionDefault, CompressionBest, CompressionHuffman}
+
+// 备份文件加密
+const (
+	EncryptionAES256GCM = "aes-256-gcm" // 加密算法: AES-256-GCM 分块流式加密
+	EncryptedExt        = ".enc"        // 加密后的备份文件追加的扩展名
+)
+
+// IgnoreFileName 备份源目录中的忽略文件名（语法同 .gitignore）
+const IgnoreFileName = ".bakignore"
+
+// 分卷备份
+const (
+	MinVolumeSize = 1000 * 1000 // 最小分卷大小（1MB）
+)
+
+// VolumePath 返回分卷备份中指定序号的分卷文件路径
+//
+// 参数:
+//   - backupPath: 备份文件路径，如 name_20250903_143022.zip
+//   - seq: 分卷序号（从1开始）
+//
+// 返回值:
+//   - string: 分卷文件路径，如 name_20250903_143022.zip.001
+func VolumePath(backupPath string, seq int) string {
+	return fmt.Sprintf("%s.%03d", backupPath, seq)
+}
+
+// 归档格式
+const (
+	FormatZip    = "zip"     // ZIP 归档（默认, 可在 Windows 上直接打开）
+	FormatTar    = "tar"     // tar 归档（不压缩, 保留 Unix 权限、属主和修改时间）
+	FormatTarGz  = "tar.gz"  // gzip 压缩的 tar 归档
+	FormatTarBz2 = "tar.bz2" // bzip2 压缩的 tar 归档（压缩率更高, 速度较慢）
+)
+
+// FormatList 支持的归档格式列表
+var FormatList = []string{FormatZip, FormatTar, FormatTarGz, FormatTarBz2}
+
+// FormatExt 返回归档格式对应的备份文件扩展名
+//
+// 参数:
+//   - format: 归档格式（为空时视为 zip）
+//
+// 返回值:
+//   - string: 备份文件扩展名，如 ".tar.gz"
+func FormatExt(format string) string {
+	if format == "" {
+		return "." + FormatZip
+	}
+	return "." + format
+}
+
+// FormatExtList 返回所有归档格式的备份文件扩展名
+func FormatExtList() []string {
+	exts := make([]string, 0, len(FormatList))
+	for _, format := range FormatList {
+		exts = append(exts, FormatExt(format))
+	}
+	return exts
+}
+
+// BackupExtList 返回所有归档格式的备份文件扩展名（包含加密后的扩展名）
+func BackupExtList() []string {
+	exts := FormatExtList()
+	for _, ext := range FormatExtList() {
+		exts = append(exts, ext+EncryptedExt)
+	}
+	return exts
+}
+
+// 备份模式
+const (
+	BackupModeFull        = "full"        // 全量备份: 每次打包整个源目录
+	BackupModeIncremental = "incremental" // 增量备份: 只打包相对上一次备份新增或修改的文件
+)
+
+// BackupModeList 支持的备份模式列表
+var BackupModeList = []string{BackupModeFull, BackupModeIncremental}
+
+// DefaultFullEvery 增量备份链的默认最大长度: 每 7 次备份执行一次全量备份, 之后的增量备份以它为基础开始新的备份链
+const DefaultFullEvery = 7
+
+// 存储模式
+const (
+	StorageModeArchive    = "archive"    // 归档模式: 每次备份生成一个独立的归档文件
+	StorageModeRepository = "repository" // 仓库模式: 文件切分为数据块按内容去重存储, 每次备份生成一个快照
+)
+
+// StorageModeList 支持的存储模式列表
+var StorageModeList = []string{StorageModeArchive, StorageModeRepository}
+
+// 符号链接处理策略
+const (
+	SymlinkStore  = "store"  // 存储链接本身（默认, 恢复时重建链接）
+	SymlinkFollow = "follow" // 跟随链接, 备份链接指向的文件或目录（跳过形成循环的链接）
+	SymlinkSkip   = "skip"   // 跳过符号链接
+)
+
+// SymlinkPolicyList 支持的符号链接处理策略列表
+var SymlinkPolicyList = []string{SymlinkStore, SymlinkFollow, SymlinkSkip}
+
+// 单个条目出错时的处理策略
+const (
+	ErrorPolicyStrict         = "strict"          // 任何条目出错都中止备份（默认）
+	ErrorPolicySkipUnreadable = "skip-unreadable" // 跳过无法读取的条目（权限不足、备份过程中被删除）并记录警告
+	ErrorPolicySkipChanged    = "skip-changed"    // 在 skip-unreadable 的基础上, 读取过程中被修改的文件也只记录警告
+)
+
+// ErrorPolicyList 支持的错误处理策略列表
+var ErrorPolicyList = []string{ErrorPolicyStrict, ErrorPolicySkipUnreadable, ErrorPolicySkipChanged}
+
+// DefaultHookTimeout 钩子命令的默认超时时间（秒）
+const DefaultHookTimeout = 300
+
+// 备份记录的执行状态
+const (
+	BackupStateSuccess   = "success"   // 备份成功
+	BackupStatePartial   = "partial"   // 备份成功, 但有条目被跳过或未能完整备份, 或后置钩子、清理历史备份失败
+	BackupStateFailed    = "failed"    // 备份失败
+	BackupStateCancelled = "cancelled" // 收到中断信号, 备份被取消
+	BackupStateTimeout   = "timeout"   // 超过任务超时时间, 备份被中止
 )
+
+// 备份版本的校验结果
+const (
+	VerifyResultOK       = "ok"       // 备份文件完整
+	VerifyResultMissing  = "missing"  // 备份文件（或分卷、数据块）不存在
+	VerifyResultMismatch = "mismatch" // 备份文件的哈希值与记录的校验码不一致
+	VerifyResultCorrupt  = "corrupt"  // 备份文件无法读取或归档内容校验失败
+	VerifyResultSkipped  = "skipped"  // 备份文件完整，但未能检查归档条目（如无法解密加密备份）
+)
+
+// 文件清单中的变化类型
+const (
+	ChangeTypeAdded     = "added"     // 新增的文件
+	ChangeTypeModified  = "modified"  // 修改过的文件
+	ChangeTypeUnchanged = "unchanged" // 未变化的文件（不包含在本次归档中）
+	ChangeTypeDeleted   = "deleted"   // 已删除的文件（不包含在本次归档中）
+)
+
+// ChangeTypeList 文件清单中的变化类型列表
+var ChangeTypeList = []string{ChangeTypeAdded, ChangeTypeModified, ChangeTypeUnchanged, ChangeTypeDeleted}
@@ -3,13 +3,21 @@
 package utils
 
 import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"fmt"
 	"hash"
 	"io"
 	"os"
+	"strings"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/schollz/progressbar/v3"
+	"golang.org/x/crypto/blake2b"
 )
 
 // 字节单位定义
@@ -22,12 +30,56 @@ const (
 )
 
 // 支持的哈希算法列表
-// var supportedAlgorithms = map[string]func() hash.Hash{
-// 	"md5":    md5.New,
-// 	"sha1":   sha1.New,
-// 	"sha256": sha256.New,
-// 	"sha512": sha512.New,
-// }
+var supportedAlgorithms = map[string]func() hash.Hash{
+	"md5":     md5.New,
+	"sha1":    sha1.New,
+	"sha256":  sha256.New,
+	"sha512":  sha512.New,
+	"blake2b": newBlake2b256,
+	"xxhash":  func() hash.Hash { return xxhash.New() },
+}
+
+// legacyChecksumAlgorithm 早期版本记录的校验码不带算法前缀，均使用 sha1 计算
+const legacyChecksumAlgorithm = "sha1"
+
+// newBlake2b256 创建 BLAKE2b-256 哈希对象（不使用密钥时不会返回错误）
+func newBlake2b256() hash.Hash {
+	h, _ := blake2b.New256(nil)
+	return h
+}
+
+// IsHashSupported 检查是否支持指定的哈希算法
+func IsHashSupported(algorithm string) bool {
+	_, ok := supportedAlgorithms[algorithm]
+	return ok
+}
+
+// FormatChecksum 返回带算法前缀的校验码
+//
+// 参数:
+//   - algorithm: 哈希算法名称
+//   - sum: 十六进制哈希值
+//
+// 返回:
+//   - string: 格式为 "算法:十六进制哈希值" 的校验码，如 "sha256:9f86d0..."
+func FormatChecksum(algorithm, sum string) string {
+	return algorithm + ":" + sum
+}
+
+// ParseChecksum 解析带算法前缀的校验码
+//
+// 参数:
+//   - checksum: 校验码（早期版本记录的校验码不带算法前缀，视为 sha1）
+//
+// 返回:
+//   - algorithm: 哈希算法名称
+//   - sum: 十六进制哈希值
+func ParseChecksum(checksum string) (algorithm, sum string) {
+	if i := strings.IndexByte(checksum, ':'); i >= 0 {
+		return checksum[:i], checksum[i+1:]
+	}
+	return legacyChecksumAlgorithm, checksum
+}
 
 // Checksum 计算文件哈希值
 //
@@ -151,6 +203,84 @@ func ChecksumProgress(filePath string, hashFunc func() hash.Hash) (string, error
 	return hashStr, nil
 }
 
+// ChecksumContext 计算文件哈希值(可取消)
+//
+// 参数:
+//   - ctx: 上下文，被取消或超时后立即停止计算
+//   - filePath: 文件路径
+//   - algorithm: 哈希算法名称(md5/sha1/sha256/sha512/blake2b/xxhash)
+//   - showProgress: 是否显示进度条
+//
+// 返回:
+//   - string: 文件的十六进制哈希值
+//   - error: 错误信息，如果计算失败或被取消
+func ChecksumContext(ctx context.Context, filePath, algorithm string, showProgress bool) (string, error) {
+	hashFunc, ok := supportedAlgorithms[algorithm]
+	if !ok {
+		return "", fmt.Errorf("不支持的哈希算法: %s", algorithm)
+	}
+
+	// 检查文件是否存在
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return "", fmt.Errorf("文件不存在或无法访问: %w", err)
+	}
+
+	// 打开文件
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("无法打开文件: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	// 创建哈希对象
+	hash := hashFunc()
+	var dst io.Writer = hash
+
+	// 创建进度条
+	if showProgress {
+		bar := progressbar.NewOptions64(
+			fileInfo.Size(),                   // 总进度
+			progressbar.OptionClearOnFinish(), // 完成后清除进度条
+			progressbar.OptionSetDescription(file.Name()+" 计算中"), // 设置进度条描述
+		)
+		defer func() {
+			_ = bar.Finish()
+			_ = bar.Close()
+		}()
+		dst = io.MultiWriter(hash, bar)
+	}
+
+	// 每次读取前检查上下文，取消后立即返回
+	buffer := make([]byte, calculateBufferSize(fileInfo.Size()))
+	if _, err := io.CopyBuffer(dst, NewContextReader(ctx, file), buffer); err != nil {
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// VerifyChecksum 使用校验码中记录的哈希算法验证文件
+//
+// 参数:
+//   - ctx: 上下文，被取消或超时后立即停止计算
+//   - filePath: 文件路径
+//   - checksum: 期望的校验码（带算法前缀，早期版本记录的校验码视为 sha1）
+//   - showProgress: 是否显示进度条
+//
+// 返回:
+//   - bool: 文件的哈希值是否与校验码一致
+//   - string: 文件实际的校验码（带算法前缀）
+//   - error: 错误信息，如果哈希算法不受支持或计算失败
+func VerifyChecksum(ctx context.Context, filePath, checksum string, showProgress bool) (bool, string, error) {
+	algorithm, want := ParseChecksum(checksum)
+	sum, err := ChecksumContext(ctx, filePath, algorithm, showProgress)
+	if err != nil {
+		return false, "", err
+	}
+	return strings.EqualFold(sum, want), FormatChecksum(algorithm, sum), nil
+}
+
 // calculateBufferSize 根据文件大小计算最佳缓冲区大小
 //
 // 参数:
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter 读取限速器，限制每秒读取的字节数，可被多个协程共享
+type RateLimiter struct {
+	mu   sync.Mutex // 保护 next
+	rate int64      // 每秒允许读取的字节数
+	next time.Time  // 已读取的字节按限速读完的时间点
+}
+
+// NewRateLimiter 创建读取限速器
+//
+// 参数:
+//   - rate: 每秒允许读取的字节数
+//
+// 返回:
+//   - *RateLimiter: 读取限速器（rate 小于等于 0 时返回 nil，表示不限速）
+func NewRateLimiter(rate int64) *RateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &RateLimiter{rate: rate}
+}
+
+// Rate 返回每秒允许读取的字节数
+func (l *RateLimiter) Rate() int64 {
+	return l.rate
+}
+
+// WaitN 登记已读取的 n 个字节，读取速度超过限速时等待到按限速应读完的时间点
+//
+// 参数:
+//   - ctx: 上下文，被取消或超时后立即返回
+//   - n: 已读取的字节数
+//
+// 返回:
+//   - error: 上下文被取消或超时时返回上下文的错误
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now // 空闲期间不累积额度，避免之后突发读取
+	}
+	l.next = l.next.Add(time.Duration(int64(n) * int64(time.Second) / l.rate))
+	wait := l.next.Sub(now)
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rateLimitersKey 上下文中保存读取限速器的键
+type rateLimitersKey struct{}
+
+// WithRateLimiter 返回附带读取限速器的上下文
+//
+// 通过 NewContextReader 创建的读取器会同时遵守上下文中的所有限速器，
+// 如 run 命令的全局限速和任务自身的限速。
+//
+// 参数:
+//   - ctx: 父上下文
+//   - l: 读取限速器（为 nil 时直接返回父上下文）
+//
+// 返回:
+//   - context.Context: 附带读取限速器的上下文
+func WithRateLimiter(ctx context.Context, l *RateLimiter) context.Context {
+	if l == nil {
+		return ctx
+	}
+	parent := rateLimiters(ctx)
+	limiters := make([]*RateLimiter, 0, len(parent)+1)
+	limiters = append(limiters, parent...)
+	return context.WithValue(ctx, rateLimitersKey{}, append(limiters, l))
+}
+
+// rateLimiters 返回上下文中的所有读取限速器
+func rateLimiters(ctx context.Context) []*RateLimiter {
+	limiters, _ := ctx.Value(rateLimitersKey{}).([]*RateLimiter)
+	return limiters
+}
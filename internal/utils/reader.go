@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"context"
+	"io"
+)
+
+// contextReader 可取消的读取器
+type contextReader struct {
+	ctx      context.Context // 上下文
+	r        io.Reader       // 底层读取器
+	limiters []*RateLimiter  // 上下文中的读取限速器
+}
+
+// NewContextReader 创建可取消的读取器
+//
+// 每次读取前检查上下文，上下文被取消或超时后读取立即返回上下文的错误，
+// 用于中止耗时的复制、压缩和哈希计算。上下文附带读取限速器时（见 WithRateLimiter），
+// 读取速度超过限速后等待，直到符合限速。
+//
+// 参数:
+//   - ctx: 上下文
+//   - r: 底层读取器
+//
+// 返回:
+//   - io.Reader: 可取消的读取器
+func NewContextReader(ctx context.Context, r io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, r: r, limiters: rateLimiters(ctx)}
+}
+
+// Read 实现 io.Reader 接口
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := c.r.Read(p)
+	if n > 0 {
+		for _, l := range c.limiters {
+			if waitErr := l.WaitN(c.ctx, n); waitErr != nil {
+				return n, waitErr
+			}
+		}
+	}
+	return n, err
+}
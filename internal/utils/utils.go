@@ -3,8 +3,11 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"syscall"
 	"time"
 )
 
@@ -70,3 +73,46 @@ func ConvertUTCToLocal(utcTimeStr string) string {
 	// 格式化为相同的格式
 	return localTime.Format("2006-01-02 15:04:05")
 }
+
+// ProcessAlive 检查进程是否仍在运行
+//
+// 参数:
+//   - pid: 进程ID
+//
+// 返回值:
+//   - bool: 进程仍在运行时返回 true
+func ProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false // Windows 下进程不存在时查找失败
+	}
+
+	// Unix 下发送信号0检测进程是否存在；
+	// Windows 不支持信号0，能找到进程即视为仍在运行
+	err = p.Signal(syscall.Signal(0))
+	if err == nil {
+		return true
+	}
+	return !errors.Is(err, os.ErrProcessDone) && !errors.Is(err, syscall.ESRCH)
+}
+
+// RemoveIfExists 删除文件，文件不存在时忽略
+//
+// 参数:
+//   - path: 文件路径（为空时不做任何操作）
+//
+// 返回值:
+//   - error: 删除失败时返回错误信息
+func RemoveIfExists(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除文件 %s 失败: %w", path, err)
+	}
+	return nil
+}